@@ -0,0 +1,109 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaveformSamples is the number of bars in a voice message waveform
+const WaveformSamples = 64
+
+// WaveformMax is the value of the loudest bar, the quietest possible bar is 0
+const WaveformMax = 100
+
+// Longest duration a header may claim. It is far beyond anything that fits in an upload and
+// keeps the conversion to time.Duration from overflowing.
+const maxDurationSeconds = 24 * 60 * 60
+
+var (
+	// ErrUnsupportedFormat is returned for anything other than Ogg Opus or M4A with AAC audio
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	// ErrMalformed is returned when the container is damaged or incomplete
+	ErrMalformed = errors.New("malformed audio")
+)
+
+// Info describes a validated voice recording
+type Info struct {
+	ContentType string
+	Ext         string
+	Duration    time.Duration
+	// Waveform has up to WaveformSamples bars scaled to 0..WaveformMax
+	Waveform []int
+}
+
+// frame is a compressed audio frame, with its position and length in the stream's time base
+type frame struct {
+	start    int64
+	duration int64
+	size     int
+}
+
+// Probe validates an uploaded recording and reads its duration and waveform without decoding
+// the audio. The codecs cannot be decoded in pure Go, so the waveform follows the size of the
+// compressed frames instead: both Opus and AAC spend more bits on louder passages, and close to
+// none on silence.
+func Probe(data []byte) (*Info, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return probeOgg(data)
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return probeMP4(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// duration converts a length counted in units of 1/rate seconds, such as a granule position or
+// an mdhd duration. The values come straight from the upload, so they are checked before any
+// multiplication.
+func duration(units, rate uint64) (time.Duration, error) {
+	if units == 0 || rate == 0 || units/rate >= maxDurationSeconds {
+		return 0, fmt.Errorf("%w: invalid duration", ErrMalformed)
+	}
+
+	seconds := units / rate
+	// The remainder is below rate, which is at most 2^32, so this cannot overflow either
+	fraction := units % rate * uint64(time.Second) / rate
+	return time.Duration(seconds)*time.Second + time.Duration(fraction), nil
+}
+
+// waveform averages the bitrate over equal slices of the recording and scales the result so the
+// loudest slice is WaveformMax
+func waveform(frames []frame, total int64) []int {
+	if len(frames) == 0 || total <= 0 {
+		return []int{}
+	}
+
+	buckets := min(WaveformSamples, len(frames))
+	bytesPerBucket := make([]float64, buckets)
+	timePerBucket := make([]float64, buckets)
+	for _, f := range frames {
+		i := int(f.start * int64(buckets) / total)
+		i = max(0, min(buckets-1, i))
+		bytesPerBucket[i] += float64(f.size)
+		timePerBucket[i] += float64(max(f.duration, 1))
+	}
+
+	rates := make([]float64, buckets)
+	peak := 0.0
+	for i := range rates {
+		if timePerBucket[i] > 0 {
+			rates[i] = bytesPerBucket[i] / timePerBucket[i]
+		} else if i > 0 {
+			// Slices without a frame of their own continue the previous one
+			rates[i] = rates[i-1]
+		}
+		peak = max(peak, rates[i])
+	}
+
+	bars := make([]int, buckets)
+	if peak == 0 {
+		return bars
+	}
+	for i, rate := range rates {
+		bars[i] = int(rate/peak*WaveformMax + 0.5)
+	}
+	return bars
+}
@@ -0,0 +1,353 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// oggPage builds one Ogg page holding whole packets, with a valid checksum
+func oggPage(sequence uint32, headerType byte, granule int64, packets ...[]byte) []byte {
+	var segments, body []byte
+	for _, packet := range packets {
+		n := len(packet)
+		for n >= 255 {
+			segments = append(segments, 255)
+			n -= 255
+		}
+		segments = append(segments, byte(n))
+		body = append(body, packet...)
+	}
+
+	page := make([]byte, 27, 27+len(segments)+len(body))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:], 1)
+	binary.LittleEndian.PutUint32(page[18:], sequence)
+	page[26] = byte(len(segments))
+	page = append(page, segments...)
+	page = append(page, body...)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+	return page
+}
+
+func opusHead(preSkip uint16) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 1
+	binary.LittleEndian.PutUint16(head[10:], preSkip)
+	return head
+}
+
+// opusFile builds a mono Ogg Opus file of 20ms CELT packets whose last page has the given
+// granule position. Packets in the middle are larger, as louder audio would be.
+func opusFile(packets int, granule int64) []byte {
+	data := oggPage(0, 0x02, 0, opusHead(312))
+	data = append(data, oggPage(1, 0, 0, []byte("OpusTags\x00\x00\x00\x00\x00\x00\x00\x00"))...)
+
+	var audio [][]byte
+	for i := 0; i < packets; i++ {
+		size := 10
+		if i >= packets/3 && i < 2*packets/3 {
+			size = 200
+		}
+		packet := make([]byte, size)
+		packet[0] = 31 << 3 // CELT fullband, 20ms
+		audio = append(audio, packet)
+	}
+
+	for sequence := uint32(2); len(audio) > 0; sequence++ {
+		n := min(len(audio), 50)
+		var headerType byte
+		pageGranule := granule * int64(sequence-1) / int64((packets+49)/50)
+		if n == len(audio) {
+			headerType = 0x04
+			pageGranule = granule
+		}
+		data = append(data, oggPage(sequence, headerType, pageGranule, audio[:n]...)...)
+		audio = audio[n:]
+	}
+	return data
+}
+
+// box builds an ISO base media box around the payloads
+func box(kind string, payloads ...[]byte) []byte {
+	var body []byte
+	for _, payload := range payloads {
+		body = append(body, payload...)
+	}
+	header := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+	copy(header[4:], kind)
+	return append(header, body...)
+}
+
+func be32(values ...uint32) []byte {
+	out := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return out
+}
+
+type m4aOptions struct {
+	mdhd   []byte
+	codec  string
+	frames int
+}
+
+// m4aFile builds an M4A file with one sound track of 1024-sample AAC frames
+func m4aFile(opts m4aOptions) []byte {
+	if opts.codec == "" {
+		opts.codec = "mp4a"
+	}
+
+	hdlr := append(be32(0, 0), []byte("soun")...)
+	hdlr = append(hdlr, make([]byte, 13)...)
+	stsd := append(be32(0, 1), box(opts.codec, make([]byte, 28))...)
+
+	sizes := be32(0, 0, uint32(opts.frames))
+	for i := 0; i < opts.frames; i++ {
+		sizes = append(sizes, be32(uint32(100+i))...)
+	}
+	stts := be32(0, 1, uint32(opts.frames), 1024)
+
+	stbl := box("stbl", box("stsd", stsd), box("stts", stts), box("stsz", sizes))
+	mdia := box("mdia", box("mdhd", opts.mdhd), box("hdlr", hdlr), box("minf", stbl))
+
+	data := box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	data = append(data, box("moov", box("trak", mdia))...)
+	return append(data, box("mdat", make([]byte, 64))...)
+}
+
+// mdhdV0 is a version 0 media header with 32-bit times
+func mdhdV0(timescale, units uint32) []byte {
+	return append(be32(0, 0, 0, timescale, units), 0, 0, 0, 0)
+}
+
+// mdhdV1 is a version 1 media header with a 64-bit duration
+func mdhdV1(timescale uint32, units uint64) []byte {
+	header := be32(1<<24, 0, 0, 0, 0, timescale)
+	header = binary.BigEndian.AppendUint64(header, units)
+	return append(header, 0, 0, 0, 0)
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		wantType     string
+		wantDuration time.Duration
+		wantErr      error
+	}{
+		{
+			name:         "ogg opus",
+			data:         opusFile(250, 250*960+312),
+			wantType:     "audio/ogg",
+			wantDuration: 5 * time.Second,
+		},
+		{
+			name:         "m4a version 0 header",
+			data:         m4aFile(m4aOptions{mdhd: mdhdV0(44100, 44100*3), frames: 130}),
+			wantType:     "audio/mp4",
+			wantDuration: 3 * time.Second,
+		},
+		{
+			name:         "m4a version 1 header",
+			data:         m4aFile(m4aOptions{mdhd: mdhdV1(48000, 48000*90+24000), frames: 130}),
+			wantType:     "audio/mp4",
+			wantDuration: 90*time.Second + 500*time.Millisecond,
+		},
+		{
+			name:         "m4a duration from sample table",
+			data:         m4aFile(m4aOptions{mdhd: mdhdV0(1024, 0), frames: 130}),
+			wantType:     "audio/mp4",
+			wantDuration: 130 * time.Second,
+		},
+		{
+			name:    "empty",
+			data:    nil,
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "not audio",
+			data:    []byte("%PDF-1.7 not a recording"),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name: "ogg vorbis",
+			data: append(oggPage(0, 0x02, 0, []byte("\x01vorbis0000000000000000000000")),
+				oggPage(1, 0x04, 48000, []byte("\x03vorbis"), []byte{0x00})...),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "ogg granule wraps a time.Duration",
+			data:    opusFile(250, 1<<62),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "ogg granule just past the limit",
+			data:    opusFile(250, maxDurationSeconds*opusSampleRate+312),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "ogg granule before pre-skip",
+			data:    opusFile(250, 100),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "ogg negative granule",
+			data:    opusFile(250, -2),
+			wantErr: ErrMalformed,
+		},
+		{
+			name: "ogg bad checksum",
+			data: func() []byte {
+				data := opusFile(250, 250*960+312)
+				data[len(data)-1] ^= 0xFF
+				return data
+			}(),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "ogg truncated",
+			data:    opusFile(250, 250*960+312)[:500],
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a 64-bit duration wraps a time.Duration",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV1(1, 1<<63), frames: 10}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a maximum 64-bit duration",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV1(48000, ^uint64(0)), frames: 10}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a 32-bit duration over a day",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV0(1, 0xFFFFFFFF), frames: 10}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a zero timescale",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV0(0, 1000), frames: 10}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a short media header",
+			data:    m4aFile(m4aOptions{mdhd: be32(0, 0), frames: 10}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a without samples",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV0(1024, 0), frames: 0}),
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "m4a other codec",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV0(44100, 44100), codec: "alac", frames: 10}),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "m4a truncated",
+			data:    m4aFile(m4aOptions{mdhd: mdhdV0(44100, 44100), frames: 10})[:100],
+			wantErr: ErrMalformed,
+		},
+		{
+			name: "m4a box larger than the file",
+			data: func() []byte {
+				data := m4aFile(m4aOptions{mdhd: mdhdV0(44100, 44100), frames: 10})
+				binary.BigEndian.PutUint32(data, 0xFFFFFFF0)
+				return data
+			}(),
+			wantErr: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Probe(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Probe() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Probe() error = %v", err)
+			}
+
+			if info.ContentType != tt.wantType {
+				t.Errorf("ContentType = %q, want %q", info.ContentType, tt.wantType)
+			}
+			if info.Duration != tt.wantDuration {
+				t.Errorf("Duration = %s, want %s", info.Duration, tt.wantDuration)
+			}
+			if len(info.Waveform) == 0 || len(info.Waveform) > WaveformSamples {
+				t.Fatalf("waveform has %d bars, want 1 to %d", len(info.Waveform), WaveformSamples)
+			}
+			peak := 0
+			for _, bar := range info.Waveform {
+				if bar < 0 || bar > WaveformMax {
+					t.Fatalf("waveform bar %d outside 0..%d", bar, WaveformMax)
+				}
+				peak = max(peak, bar)
+			}
+			if peak != WaveformMax {
+				t.Errorf("loudest bar = %d, want %d", peak, WaveformMax)
+			}
+		})
+	}
+}
+
+func TestProbeOggWaveformFollowsBitrate(t *testing.T) {
+	info, err := Probe(opusFile(192, 192*960+312))
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+
+	bars := info.Waveform
+	if len(bars) != WaveformSamples {
+		t.Fatalf("waveform has %d bars, want %d", len(bars), WaveformSamples)
+	}
+
+	// The middle third of the recording uses twenty times the bytes of the rest
+	quiet, loud := bars[0], bars[WaveformSamples/2]
+	if loud != WaveformMax || quiet >= loud/4 {
+		t.Errorf("quiet bar = %d, loud bar = %d, want the loud passage to stand out", quiet, loud)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		units, rate uint64
+		want        time.Duration
+		wantErr     bool
+	}{
+		{units: 48000, rate: 48000, want: time.Second},
+		{units: 1, rate: 48000, want: 20833},
+		{units: 44100*60 + 22050, rate: 44100, want: time.Minute + 500*time.Millisecond},
+		{units: 0, rate: 48000, wantErr: true},
+		{units: 48000, rate: 0, wantErr: true},
+		{units: maxDurationSeconds * 1000, rate: 1000, wantErr: true},
+		{units: ^uint64(0), rate: 1, wantErr: true},
+		{units: ^uint64(0), rate: 0xFFFFFFFF, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := duration(tt.units, tt.rate)
+		if tt.wantErr {
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("duration(%d, %d) error = %v, want ErrMalformed", tt.units, tt.rate, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("duration(%d, %d) = %s, %v, want %s", tt.units, tt.rate, got, err, tt.want)
+		}
+	}
+}
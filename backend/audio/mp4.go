@@ -0,0 +1,249 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+type mp4Box struct {
+	kind    string
+	payload []byte
+}
+
+// readBoxes splits data into consecutive ISO base media boxes
+func readBoxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("%w: truncated box", ErrMalformed)
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data))
+		kind := string(data[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			// The last box may extend to the end of the file
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("%w: truncated box", ErrMalformed)
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			headerLen = 16
+		}
+
+		if size < headerLen || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: invalid %q box size", ErrMalformed, kind)
+		}
+
+		boxes = append(boxes, mp4Box{kind: kind, payload: data[headerLen:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// findBox returns the payload of the first box of the given kind
+func findBox(boxes []mp4Box, kind string) ([]byte, bool) {
+	for _, box := range boxes {
+		if box.kind == kind {
+			return box.payload, true
+		}
+	}
+	return nil, false
+}
+
+// findPath follows nested boxes, e.g. "mdia", "minf", "stbl"
+func findPath(data []byte, path ...string) ([]byte, error) {
+	for _, kind := range path {
+		boxes, err := readBoxes(data)
+		if err != nil {
+			return nil, err
+		}
+		payload, ok := findBox(boxes, kind)
+		if !ok {
+			return nil, fmt.Errorf("%w: missing %q box", ErrMalformed, kind)
+		}
+		data = payload
+	}
+	return data, nil
+}
+
+// probeMP4 reads an M4A file and the first AAC sound track in it
+func probeMP4(data []byte) (*Info, error) {
+	boxes, err := readBoxes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(boxes) == 0 || boxes[0].kind != "ftyp" {
+		return nil, fmt.Errorf("%w: missing file type", ErrMalformed)
+	}
+	if _, ok := findBox(boxes, "mdat"); !ok {
+		return nil, fmt.Errorf("%w: no media data", ErrMalformed)
+	}
+	moov, ok := findBox(boxes, "moov")
+	if !ok {
+		return nil, fmt.Errorf("%w: no movie header", ErrMalformed)
+	}
+
+	tracks, err := readBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, track := range tracks {
+		if track.kind != "trak" {
+			continue
+		}
+
+		mdia, err := findPath(track.payload, "mdia")
+		if err != nil {
+			return nil, err
+		}
+
+		hdlr, err := findPath(mdia, "hdlr")
+		if err != nil {
+			return nil, err
+		}
+		if len(hdlr) < 12 || string(hdlr[8:12]) != "soun" {
+			continue
+		}
+
+		return probeMP4Track(mdia, len(data))
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// probeMP4Track reads a sound track, fileSize bounds how many samples it can hold
+func probeMP4Track(mdia []byte, fileSize int) (*Info, error) {
+	mdhd, err := findPath(mdia, "mdhd")
+	if err != nil {
+		return nil, err
+	}
+
+	var timescale, units uint64
+	switch {
+	case len(mdhd) >= 20 && mdhd[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(mdhd[12:]))
+		units = uint64(binary.BigEndian.Uint32(mdhd[16:]))
+	case len(mdhd) >= 32 && mdhd[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(mdhd[20:]))
+		units = binary.BigEndian.Uint64(mdhd[24:])
+	default:
+		return nil, fmt.Errorf("%w: invalid media header", ErrMalformed)
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("%w: invalid media header", ErrMalformed)
+	}
+
+	stbl, err := findPath(mdia, "minf", "stbl")
+	if err != nil {
+		return nil, err
+	}
+	sampleTables, err := readBoxes(stbl)
+	if err != nil {
+		return nil, err
+	}
+
+	stsd, ok := findBox(sampleTables, "stsd")
+	if !ok || len(stsd) < 8 {
+		return nil, fmt.Errorf("%w: missing sample description", ErrMalformed)
+	}
+	entries, err := readBoxes(stsd[8:])
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 || entries[0].kind != "mp4a" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	sizes, err := sampleSizes(sampleTables, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := sampleFrames(sampleTables, sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if len(frames) > 0 {
+		last := frames[len(frames)-1]
+		total = last.start + last.duration
+	}
+
+	// Some encoders leave the header duration at zero and rely on the sample table
+	if units == 0 {
+		units = uint64(total)
+	}
+	if units == 0 || len(frames) == 0 {
+		return nil, fmt.Errorf("%w: no audio", ErrMalformed)
+	}
+	length, err := duration(units, timescale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		ContentType: "audio/mp4",
+		Ext:         ".m4a",
+		Duration:    length,
+		Waveform:    waveform(frames, max(total, 1)),
+	}, nil
+}
+
+// sampleSizes reads the size of every sample from the stsz box
+func sampleSizes(sampleTables []mp4Box, fileSize int) ([]int, error) {
+	stsz, ok := findBox(sampleTables, "stsz")
+	if !ok || len(stsz) < 12 {
+		return nil, fmt.Errorf("%w: missing sample sizes", ErrMalformed)
+	}
+
+	uniform := int(binary.BigEndian.Uint32(stsz[4:]))
+	count := int(binary.BigEndian.Uint32(stsz[8:]))
+
+	// Each sample takes at least one byte of the file
+	if count > fileSize {
+		return nil, fmt.Errorf("%w: too many samples", ErrMalformed)
+	}
+	if uniform == 0 && len(stsz)-12 < count*4 {
+		return nil, fmt.Errorf("%w: truncated sample sizes", ErrMalformed)
+	}
+
+	sizes := make([]int, count)
+	for i := range sizes {
+		if uniform != 0 {
+			sizes[i] = uniform
+		} else {
+			sizes[i] = int(binary.BigEndian.Uint32(stsz[12+i*4:]))
+		}
+	}
+	return sizes, nil
+}
+
+// sampleFrames places each sample in time using the run-length encoded durations in stts
+func sampleFrames(sampleTables []mp4Box, sizes []int) ([]frame, error) {
+	stts, ok := findBox(sampleTables, "stts")
+	if !ok || len(stts) < 8 {
+		return nil, fmt.Errorf("%w: missing sample durations", ErrMalformed)
+	}
+
+	entries := int(binary.BigEndian.Uint32(stts[4:]))
+	if len(stts)-8 < entries*8 {
+		return nil, fmt.Errorf("%w: truncated sample durations", ErrMalformed)
+	}
+
+	frames := make([]frame, 0, len(sizes))
+	var position int64
+	for i := 0; i < entries && len(frames) < len(sizes); i++ {
+		count := int(binary.BigEndian.Uint32(stts[8+i*8:]))
+		delta := int64(binary.BigEndian.Uint32(stts[12+i*8:]))
+		for j := 0; j < count && len(frames) < len(sizes); j++ {
+			frames = append(frames, frame{start: position, duration: delta, size: sizes[len(frames)]})
+			position += delta
+		}
+	}
+	return frames, nil
+}
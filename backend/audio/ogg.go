@@ -0,0 +1,178 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Opus always counts granule positions at 48kHz, whatever the input sample rate was
+const opusSampleRate = 48000
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// oggCRC is the page checksum, computed with the checksum field itself zeroed
+func oggCRC(page []byte) uint32 {
+	var crc uint32
+	for i, b := range page {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// probeOgg reads an Ogg stream holding a single Opus track, see RFC 7845
+func probeOgg(data []byte) (*Info, error) {
+	var (
+		packets     [][]byte
+		partial     []byte
+		serial      uint32
+		lastGranule int64 = -1
+	)
+
+	for pos, pageIndex := 0, 0; pos < len(data); pageIndex++ {
+		if len(data)-pos < 27 || string(data[pos:pos+4]) != "OggS" || data[pos+4] != 0 {
+			return nil, fmt.Errorf("%w: invalid ogg page", ErrMalformed)
+		}
+
+		headerType := data[pos+5]
+		granule := int64(binary.LittleEndian.Uint64(data[pos+6:]))
+		pageSerial := binary.LittleEndian.Uint32(data[pos+14:])
+		checksum := binary.LittleEndian.Uint32(data[pos+22:])
+
+		segments := int(data[pos+26])
+		headerLen := 27 + segments
+		if len(data)-pos < headerLen {
+			return nil, fmt.Errorf("%w: truncated ogg page", ErrMalformed)
+		}
+
+		segmentTable := data[pos+27 : pos+headerLen]
+		bodyLen := 0
+		for _, s := range segmentTable {
+			bodyLen += int(s)
+		}
+		if len(data)-pos < headerLen+bodyLen {
+			return nil, fmt.Errorf("%w: truncated ogg page", ErrMalformed)
+		}
+
+		page := data[pos : pos+headerLen+bodyLen]
+		if oggCRC(page) != checksum {
+			return nil, fmt.Errorf("%w: ogg page checksum mismatch", ErrMalformed)
+		}
+
+		if pageIndex == 0 {
+			if headerType&0x02 == 0 {
+				return nil, fmt.Errorf("%w: missing beginning of stream", ErrMalformed)
+			}
+			serial = pageSerial
+		} else if pageSerial != serial {
+			// Voice notes have exactly one logical stream
+			return nil, ErrUnsupportedFormat
+		}
+
+		body := page[headerLen:]
+		offset := 0
+		for _, s := range segmentTable {
+			partial = append(partial, body[offset:offset+int(s)]...)
+			offset += int(s)
+			// A segment shorter than 255 bytes ends the packet
+			if s < 255 {
+				packets = append(packets, partial)
+				partial = nil
+			}
+		}
+
+		// -1 marks pages on which no packet ends
+		if granule != -1 {
+			lastGranule = granule
+		}
+
+		pos += len(page)
+	}
+
+	if len(packets) < 3 {
+		return nil, fmt.Errorf("%w: no audio", ErrMalformed)
+	}
+
+	head := packets[0]
+	if !bytes.HasPrefix(head, []byte("OpusHead")) {
+		return nil, ErrUnsupportedFormat
+	}
+	if len(head) < 19 || head[8]&0xF0 != 0 || head[9] == 0 {
+		return nil, fmt.Errorf("%w: invalid opus header", ErrMalformed)
+	}
+	if !bytes.HasPrefix(packets[1], []byte("OpusTags")) {
+		return nil, fmt.Errorf("%w: missing opus tags", ErrMalformed)
+	}
+
+	preSkip := int64(binary.LittleEndian.Uint16(head[10:]))
+	samples := lastGranule - preSkip
+	if samples <= 0 {
+		return nil, fmt.Errorf("%w: invalid duration", ErrMalformed)
+	}
+	length, err := duration(uint64(samples), opusSampleRate)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]frame, 0, len(packets)-2)
+	var position int64
+	for _, packet := range packets[2:] {
+		duration := opusPacketSamples(packet)
+		frames = append(frames, frame{start: position, duration: duration, size: len(packet)})
+		position += duration
+	}
+
+	return &Info{
+		ContentType: "audio/ogg",
+		Ext:         ".ogg",
+		Duration:    length,
+		Waveform:    waveform(frames, max(position, 1)),
+	}, nil
+}
+
+// opusFrameSamples is the frame length for each TOC configuration at 48kHz (RFC 6716, section 3.1)
+var opusFrameSamples = [32]int64{
+	// SILK: 10, 20, 40 and 60ms for each bandwidth
+	480, 960, 1920, 2880, 480, 960, 1920, 2880, 480, 960, 1920, 2880,
+	// Hybrid: 10 and 20ms
+	480, 960, 480, 960,
+	// CELT: 2.5, 5, 10 and 20ms
+	120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960,
+}
+
+// opusPacketSamples reads how much audio a packet holds from its table of contents byte
+func opusPacketSamples(packet []byte) int64 {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	frameSamples := opusFrameSamples[packet[0]>>3]
+	switch packet[0] & 0x03 {
+	case 0:
+		return frameSamples
+	case 1, 2:
+		return 2 * frameSamples
+	default:
+		if len(packet) < 2 {
+			return 0
+		}
+		return int64(packet[1]&0x3F) * frameSamples
+	}
+}
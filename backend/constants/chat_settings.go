@@ -0,0 +1,9 @@
+package constants
+
+const (
+	MaxEditWindowSeconds = 7 * 24 * 60 * 60 // 7 days
+	MaxMessageTTLSeconds = 7 * 24 * 60 * 60 // 7 days
+
+	WhoCanPostEveryone = "everyone"
+	WhoCanPostAdmin    = "admin"
+)
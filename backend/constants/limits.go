@@ -1,9 +1,27 @@
 package constants
 
 const (
-	MaxMessageLength       = 5000
-	MaxImagesPerMessage    = 5
-	MaxMessagesPerPage     = 50
-	DefaultMessagesPerPage = 20
-	MaxImageSize           = 4 * 1024 * 1024 // 4MB
+	MaxMessageLength          = 5000
+	MaxEntitiesPerMessage     = 100
+	MaxEntityURLLength        = 2048
+	MaxEntityLanguageLength   = 32
+	MaxImagesPerMessage       = 5
+	MaxAttachmentsPerMessage  = 10
+	MaxMessagesPerPage        = 50
+	MaxBatchMessageIDs        = 50
+	MaxForwardTargets         = 10
+	MaxLinkPreviewsPerMessage = 3
+	MinPollOptions            = 2
+	MaxPollOptions            = 10
+	MaxPollOptionLength       = 100
+	MaxPollQuestionLength     = 300
+	DefaultMessagesPerPage    = 20
+	MaxImageSize              = 4 * 1024 * 1024    // 4MB
+	DefaultMaxAttachmentSize  = 25 * 1024 * 1024   // 25MB
+	MaxVoiceMessageSize       = 10 * 1024 * 1024   // 10MB
+	DefaultStorageQuota       = 1024 * 1024 * 1024 // 1GB
+	MaxVoiceMessageSeconds    = 15 * 60            // 15 minutes
+	MaxScheduleAheadSeconds   = 365 * 24 * 60 * 60 // 1 year
+	PresignedUploadTTLSeconds = 15 * 60            // 15 minutes
+	SignedURLTTLSeconds       = 60 * 60            // 1 hour
 )
@@ -0,0 +1,27 @@
+package constants
+
+const (
+	MessageTypeUser   = "user"
+	MessageTypeSystem = "system"
+
+	SystemEventMemberAdded       = "member_added"
+	SystemEventMemberRemoved     = "member_removed"
+	SystemEventMemberLeft        = "member_left"
+	SystemEventChatRenamed       = "chat_renamed"
+	SystemEventAdminChanged      = "admin_changed"
+	SystemEventSettingsUpdated   = "settings_updated"
+	SystemEventMessageTTLChanged = "message_ttl_changed"
+
+	EntityTypeBold    = "bold"
+	EntityTypeItalic  = "italic"
+	EntityTypeCode    = "code"
+	EntityTypePre     = "pre"
+	EntityTypeLink    = "link"
+	EntityTypeMention = "mention"
+	EntityTypeSpoiler = "spoiler"
+
+	NotificationTypeReminder = "reminder"
+
+	AttachmentKindFile  = "file"
+	AttachmentKindVoice = "voice"
+)
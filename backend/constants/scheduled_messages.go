@@ -0,0 +1,8 @@
+package constants
+
+const (
+	ScheduledStatusPending   = "pending"
+	ScheduledStatusSent      = "sent"
+	ScheduledStatusCancelled = "cancelled"
+	ScheduledStatusFailed    = "failed"
+)
@@ -11,6 +11,42 @@ import (
 	"github.com/google/uuid"
 )
 
+const canAccessImage = `-- name: CanAccessImage :one
+SELECT EXISTS(
+    SELECT 1
+    FROM images i
+    JOIN messages m ON m.id = i.message_id
+    JOIN chat_members cm ON cm.chat_id = m.chat_id
+    WHERE cm.user_id = $1
+      AND NOT m.is_deleted
+      AND (i.url = $2::text OR i.variants @> jsonb_build_array(jsonb_build_object('url', $2::text)))
+) OR EXISTS(
+    SELECT 1
+    FROM uploads u
+    WHERE u.owner_id = $1
+      AND (u.url = $2::text OR u.variants @> jsonb_build_array(jsonb_build_object('url', $2::text)))
+) OR EXISTS(
+    SELECT 1
+    FROM users us
+    JOIN chat_members them ON them.user_id = us.id
+    JOIN chat_members me ON me.chat_id = them.chat_id
+    WHERE me.user_id = $1
+      AND us.profile_image_url = $2::text
+) AS can_access
+`
+
+type CanAccessImageParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Url    string    `json:"url"`
+}
+
+func (q *Queries) CanAccessImage(ctx context.Context, arg CanAccessImageParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canAccessImage, arg.UserID, arg.Url)
+	var can_access bool
+	err := row.Scan(&can_access)
+	return can_access, err
+}
+
 const isChatMember = `-- name: IsChatMember :one
 SELECT EXISTS(
     SELECT 1
@@ -30,3 +66,31 @@ func (q *Queries) IsChatMember(ctx context.Context, arg IsChatMemberParams) (boo
 	err := row.Scan(&is_member)
 	return is_member, err
 }
+
+const isMessageVisible = `-- name: IsMessageVisible :one
+SELECT EXISTS(
+    SELECT 1
+    FROM messages m
+    JOIN chat_members cm ON cm.chat_id = m.chat_id AND cm.user_id = $1::uuid
+    WHERE m.id = $2::uuid
+      AND (cm.cleared_at IS NULL OR m.created_at > cm.cleared_at)
+      AND NOT EXISTS (
+        SELECT 1
+        FROM hidden_messages hm
+        WHERE hm.message_id = m.id AND hm.user_id = $1::uuid
+      )
+      AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+) AS is_visible
+`
+
+type IsMessageVisibleParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	MessageID uuid.UUID `json:"message_id"`
+}
+
+func (q *Queries) IsMessageVisible(ctx context.Context, arg IsMessageVisibleParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isMessageVisible, arg.UserID, arg.MessageID)
+	var is_visible bool
+	err := row.Scan(&is_visible)
+	return is_visible, err
+}
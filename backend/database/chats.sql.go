@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -263,28 +264,52 @@ WITH user_chats AS (
         lm.content as msg_content,
         lm.sender_id as msg_sender_id,
         lm.is_deleted as msg_is_deleted,
+        lm.message_type as msg_message_type,
+        lm.metadata as msg_metadata,
         lm.created_at as msg_created_at,
         sender.username as msg_sender_username,
         sender.profile_image_url as msg_sender_profile_image_url,
         COALESCE(unread_counts.unread_count, 0) as unread_count,
+        COALESCE(unread_counts.unread_mention_count, 0) as unread_mention_count,
         ROW_NUMBER() OVER (PARTITION BY c.id ORDER BY COALESCE(lm.created_at, c.created_at) DESC) as rn
     FROM chats c
     INNER JOIN chat_members cm_user ON c.id = cm_user.chat_id AND cm_user.user_id = $1
     LEFT JOIN LATERAL (
-        SELECT id, chat_id, sender_id, content, is_deleted, created_at
+        SELECT id, chat_id, sender_id, content, is_deleted, message_type, metadata, created_at
         FROM messages
         WHERE chat_id = c.id
           AND (cm_user.cleared_at IS NULL OR created_at > cm_user.cleared_at)
+          AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+          AND NOT EXISTS (
+            SELECT 1
+            FROM hidden_messages hm
+            WHERE hm.message_id = messages.id AND hm.user_id = $1
+          )
         ORDER BY created_at DESC
         LIMIT 1
     ) lm ON true
     LEFT JOIN users sender ON lm.sender_id = sender.id
     LEFT JOIN chat_read_receipts cr ON cr.chat_id = c.id AND cr.user_id = $1
     LEFT JOIN LATERAL (
-        SELECT COUNT(*) as unread_count
+        SELECT
+            COUNT(*) as unread_count,
+            COUNT(*) FILTER (
+                WHERE NOT m.is_deleted
+                  AND EXISTS (
+                    SELECT 1
+                    FROM message_mentions mm
+                    WHERE mm.message_id = m.id AND mm.user_id = $1
+                  )
+            ) as unread_mention_count
         FROM messages m
         WHERE m.chat_id = c.id
           AND m.sender_id <> $1
+          AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+          AND NOT EXISTS (
+            SELECT 1
+            FROM hidden_messages hm
+            WHERE hm.message_id = m.id AND hm.user_id = $1
+          )
           AND (
             cr.last_read_at IS NULL
             OR m.created_at > cr.last_read_at
@@ -310,7 +335,10 @@ SELECT
     COALESCE(uc.msg_created_at, uc.created_at) as last_message_created_at,
     uc.msg_sender_username as last_message_sender_username,
     uc.msg_sender_profile_image_url as last_message_sender_profile_image_url,
-    uc.unread_count
+    COALESCE(uc.msg_message_type, 'user') as last_message_type,
+    COALESCE(uc.msg_metadata, '{}'::jsonb) as last_message_metadata,
+    uc.unread_count,
+    uc.unread_mention_count
 FROM user_chats uc
 INNER JOIN chat_members cm ON uc.chat_id = cm.chat_id
 INNER JOIN users u ON cm.user_id = u.id
@@ -320,24 +348,27 @@ ORDER BY COALESCE(uc.msg_created_at, uc.created_at) DESC, u.username ASC
 `
 
 type GetChatsWithMembersRow struct {
-	ChatID                           uuid.UUID      `json:"chat_id"`
-	ChatName                         sql.NullString `json:"chat_name"`
-	IsGroup                          bool           `json:"is_group"`
-	CreatedBy                        uuid.UUID      `json:"created_by"`
-	ChatCreatedAt                    time.Time      `json:"chat_created_at"`
-	ChatUpdatedAt                    time.Time      `json:"chat_updated_at"`
-	MemberID                         uuid.UUID      `json:"member_id"`
-	MemberUsername                   string         `json:"member_username"`
-	MemberEmail                      string         `json:"member_email"`
-	MemberProfileImageUrl            sql.NullString `json:"member_profile_image_url"`
-	LastMessageID                    uuid.UUID      `json:"last_message_id"`
-	LastMessageContent               sql.NullString `json:"last_message_content"`
-	LastMessageSenderID              uuid.UUID      `json:"last_message_sender_id"`
-	LastMessageIsDeleted             bool           `json:"last_message_is_deleted"`
-	LastMessageCreatedAt             time.Time      `json:"last_message_created_at"`
-	LastMessageSenderUsername        sql.NullString `json:"last_message_sender_username"`
-	LastMessageSenderProfileImageUrl sql.NullString `json:"last_message_sender_profile_image_url"`
-	UnreadCount                      int64          `json:"unread_count"`
+	ChatID                           uuid.UUID       `json:"chat_id"`
+	ChatName                         sql.NullString  `json:"chat_name"`
+	IsGroup                          bool            `json:"is_group"`
+	CreatedBy                        uuid.UUID       `json:"created_by"`
+	ChatCreatedAt                    time.Time       `json:"chat_created_at"`
+	ChatUpdatedAt                    time.Time       `json:"chat_updated_at"`
+	MemberID                         uuid.UUID       `json:"member_id"`
+	MemberUsername                   string          `json:"member_username"`
+	MemberEmail                      string          `json:"member_email"`
+	MemberProfileImageUrl            sql.NullString  `json:"member_profile_image_url"`
+	LastMessageID                    uuid.UUID       `json:"last_message_id"`
+	LastMessageContent               sql.NullString  `json:"last_message_content"`
+	LastMessageSenderID              uuid.UUID       `json:"last_message_sender_id"`
+	LastMessageIsDeleted             bool            `json:"last_message_is_deleted"`
+	LastMessageCreatedAt             time.Time       `json:"last_message_created_at"`
+	LastMessageSenderUsername        sql.NullString  `json:"last_message_sender_username"`
+	LastMessageSenderProfileImageUrl sql.NullString  `json:"last_message_sender_profile_image_url"`
+	LastMessageType                  string          `json:"last_message_type"`
+	LastMessageMetadata              json.RawMessage `json:"last_message_metadata"`
+	UnreadCount                      int64           `json:"unread_count"`
+	UnreadMentionCount               int64           `json:"unread_mention_count"`
 }
 
 func (q *Queries) GetChatsWithMembers(ctx context.Context, userID uuid.UUID) ([]GetChatsWithMembersRow, error) {
@@ -367,7 +398,10 @@ func (q *Queries) GetChatsWithMembers(ctx context.Context, userID uuid.UUID) ([]
 			&i.LastMessageCreatedAt,
 			&i.LastMessageSenderUsername,
 			&i.LastMessageSenderProfileImageUrl,
+			&i.LastMessageType,
+			&i.LastMessageMetadata,
 			&i.UnreadCount,
+			&i.UnreadMentionCount,
 		); err != nil {
 			return nil, err
 		}
@@ -383,26 +417,30 @@ func (q *Queries) GetChatsWithMembers(ctx context.Context, userID uuid.UUID) ([]
 }
 
 const getLastMessageImages = `-- name: GetLastMessageImages :many
-SELECT message_id, url
+SELECT id, message_id, url, created_at, width, height, variants, blurhash
 FROM images
 WHERE message_id = ANY($1::uuid[])
 `
 
-type GetLastMessageImagesRow struct {
-	MessageID uuid.UUID `json:"message_id"`
-	Url       string    `json:"url"`
-}
-
-func (q *Queries) GetLastMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([]GetLastMessageImagesRow, error) {
+func (q *Queries) GetLastMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([]Image, error) {
 	rows, err := q.db.QueryContext(ctx, getLastMessageImages, pq.Array(dollar_1))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []GetLastMessageImagesRow{}
+	items := []Image{}
 	for rows.Next() {
-		var i GetLastMessageImagesRow
-		if err := rows.Scan(&i.MessageID, &i.Url); err != nil {
+		var i Image
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Url,
+			&i.CreatedAt,
+			&i.Width,
+			&i.Height,
+			&i.Variants,
+			&i.Blurhash,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
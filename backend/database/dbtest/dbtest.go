@@ -0,0 +1,140 @@
+// Package dbtest gives tests a migrated Postgres database. Tests that use it are skipped unless
+// TEST_DATABASE_URL points at a server they may create schemas on.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+
+	"github.com/anmol7470/bubbles/backend/database"
+)
+
+// Open creates a schema of its own, applies every migration in sql/schema to it and drops it
+// again when the test ends, so tests can run in parallel against one server.
+func Open(t testing.TB) *database.Service {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	// Installed up front so the migration's CREATE EXTENSION does not put it in a test schema
+	if _, err := admin.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm SCHEMA public"); err != nil {
+		t.Fatalf("create extension: %v", err)
+	}
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Logf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	schemaURL, err := withSearchPath(dbURL, schema)
+	if err != nil {
+		t.Fatalf("set search path: %v", err)
+	}
+
+	db, err := sql.Open("postgres", schemaURL)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return &database.Service{
+		DB:      db,
+		Queries: database.New(db),
+	}
+}
+
+// withSearchPath points every connection at the test schema. Extensions such as pg_trgm stay
+// reachable through public.
+func withSearchPath(dbURL, schema string) (string, error) {
+	searchPath := schema + ",public"
+
+	if !strings.HasPrefix(dbURL, "postgres://") && !strings.HasPrefix(dbURL, "postgresql://") {
+		return dbURL + " search_path=" + searchPath, nil
+	}
+
+	parsed, err := url.Parse(dbURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	query.Set("search_path", searchPath)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// migrate runs the Up section of each goose migration in order
+func migrate(db *sql.DB) error {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return fmt.Errorf("cannot locate migrations")
+	}
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "sql", "schema")
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(upSection(string(contents))); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// upSection returns the statements between "-- +goose Up" and "-- +goose Down"
+func upSection(migration string) string {
+	var up strings.Builder
+	inUp := false
+	for _, line := range strings.Split(migration, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			inUp = true
+			continue
+		case "-- +goose Down":
+			inUp = false
+			continue
+		case "-- +goose StatementBegin", "-- +goose StatementEnd":
+			continue
+		}
+		if inUp {
+			up.WriteString(line)
+			up.WriteString("\n")
+		}
+	}
+	return up.String()
+}
@@ -8,15 +8,19 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
-const addMessageImage = `-- name: AddMessageImage :exec
-INSERT INTO images (message_id, url)
-VALUES ($1, $2)
+const addMessageImage = `-- name: AddMessageImage :one
+INSERT INTO images (message_id, url, width, height, variants, blurhash)
+SELECT $1::uuid, $2::text, u.width, u.height, COALESCE(u.variants, '[]'), u.blurhash
+FROM (SELECT 1) AS image
+LEFT JOIN uploads u ON u.url = $2::text
+RETURNING id, message_id, url, created_at, width, height, variants, blurhash
 `
 
 type AddMessageImageParams struct {
@@ -24,22 +28,93 @@ type AddMessageImageParams struct {
 	Url       string    `json:"url"`
 }
 
-func (q *Queries) AddMessageImage(ctx context.Context, arg AddMessageImageParams) error {
-	_, err := q.db.ExecContext(ctx, addMessageImage, arg.MessageID, arg.Url)
-	return err
+func (q *Queries) AddMessageImage(ctx context.Context, arg AddMessageImageParams) (Image, error) {
+	row := q.db.QueryRowContext(ctx, addMessageImage, arg.MessageID, arg.Url)
+	var i Image
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.Url,
+		&i.CreatedAt,
+		&i.Width,
+		&i.Height,
+		&i.Variants,
+		&i.Blurhash,
+	)
+	return i, err
+}
+
+const createForwardedMessage = `-- name: CreateForwardedMessage :one
+INSERT INTO messages (chat_id, sender_id, content, forwarded_from_message_id, forwarded_from_sender_id, entities, expires_at)
+VALUES (
+    $1, $2, $3, $4, $5, $6,
+    (
+        SELECT CURRENT_TIMESTAMP + make_interval(secs => cs.message_ttl_seconds)
+        FROM chat_settings cs
+        WHERE cs.chat_id = $1 AND cs.message_ttl_seconds > 0
+    )
+)
+RETURNING id, chat_id, sender_id, content, is_deleted, created_at, updated_at, is_edited, reply_to_message_id, forwarded_from_message_id, forwarded_from_sender_id, expires_at, message_type, metadata, entities
+`
+
+type CreateForwardedMessageParams struct {
+	ChatID                 uuid.UUID       `json:"chat_id"`
+	SenderID               uuid.UUID       `json:"sender_id"`
+	Content                sql.NullString  `json:"content"`
+	ForwardedFromMessageID uuid.NullUUID   `json:"forwarded_from_message_id"`
+	ForwardedFromSenderID  uuid.NullUUID   `json:"forwarded_from_sender_id"`
+	Entities               json.RawMessage `json:"entities"`
+}
+
+func (q *Queries) CreateForwardedMessage(ctx context.Context, arg CreateForwardedMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, createForwardedMessage,
+		arg.ChatID,
+		arg.SenderID,
+		arg.Content,
+		arg.ForwardedFromMessageID,
+		arg.ForwardedFromSenderID,
+		arg.Entities,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.SenderID,
+		&i.Content,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsEdited,
+		&i.ReplyToMessageID,
+		&i.ForwardedFromMessageID,
+		&i.ForwardedFromSenderID,
+		&i.ExpiresAt,
+		&i.MessageType,
+		&i.Metadata,
+		&i.Entities,
+	)
+	return i, err
 }
 
 const createMessage = `-- name: CreateMessage :one
-INSERT INTO messages (chat_id, sender_id, content, reply_to_message_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, chat_id, sender_id, content, is_deleted, created_at, updated_at, is_edited, reply_to_message_id
+INSERT INTO messages (chat_id, sender_id, content, reply_to_message_id, entities, expires_at)
+VALUES (
+    $1, $2, $3, $4, $5,
+    (
+        SELECT CURRENT_TIMESTAMP + make_interval(secs => cs.message_ttl_seconds)
+        FROM chat_settings cs
+        WHERE cs.chat_id = $1 AND cs.message_ttl_seconds > 0
+    )
+)
+RETURNING id, chat_id, sender_id, content, is_deleted, created_at, updated_at, is_edited, reply_to_message_id, forwarded_from_message_id, forwarded_from_sender_id, expires_at, message_type, metadata, entities
 `
 
 type CreateMessageParams struct {
-	ChatID           uuid.UUID      `json:"chat_id"`
-	SenderID         uuid.UUID      `json:"sender_id"`
-	Content          sql.NullString `json:"content"`
-	ReplyToMessageID uuid.NullUUID  `json:"reply_to_message_id"`
+	ChatID           uuid.UUID       `json:"chat_id"`
+	SenderID         uuid.UUID       `json:"sender_id"`
+	Content          sql.NullString  `json:"content"`
+	ReplyToMessageID uuid.NullUUID   `json:"reply_to_message_id"`
+	Entities         json.RawMessage `json:"entities"`
 }
 
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
@@ -48,6 +123,48 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		arg.SenderID,
 		arg.Content,
 		arg.ReplyToMessageID,
+		arg.Entities,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.SenderID,
+		&i.Content,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsEdited,
+		&i.ReplyToMessageID,
+		&i.ForwardedFromMessageID,
+		&i.ForwardedFromSenderID,
+		&i.ExpiresAt,
+		&i.MessageType,
+		&i.Metadata,
+		&i.Entities,
+	)
+	return i, err
+}
+
+const createSystemMessage = `-- name: CreateSystemMessage :one
+INSERT INTO messages (chat_id, sender_id, content, message_type, metadata)
+VALUES ($1, $2, $3, 'system', $4)
+RETURNING id, chat_id, sender_id, content, is_deleted, created_at, updated_at, is_edited, reply_to_message_id, forwarded_from_message_id, forwarded_from_sender_id, expires_at, message_type, metadata, entities
+`
+
+type CreateSystemMessageParams struct {
+	ChatID   uuid.UUID       `json:"chat_id"`
+	SenderID uuid.UUID       `json:"sender_id"`
+	Content  sql.NullString  `json:"content"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+func (q *Queries) CreateSystemMessage(ctx context.Context, arg CreateSystemMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, createSystemMessage,
+		arg.ChatID,
+		arg.SenderID,
+		arg.Content,
+		arg.Metadata,
 	)
 	var i Message
 	err := row.Scan(
@@ -60,6 +177,12 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.UpdatedAt,
 		&i.IsEdited,
 		&i.ReplyToMessageID,
+		&i.ForwardedFromMessageID,
+		&i.ForwardedFromSenderID,
+		&i.ExpiresAt,
+		&i.MessageType,
+		&i.Metadata,
+		&i.Entities,
 	)
 	return i, err
 }
@@ -100,19 +223,31 @@ func (q *Queries) DeleteMessageImages(ctx context.Context, arg DeleteMessageImag
 	return err
 }
 
+const deleteMessages = `-- name: DeleteMessages :exec
+UPDATE messages
+SET is_deleted = true, updated_at = CURRENT_TIMESTAMP
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) DeleteMessages(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteMessages, pq.Array(ids))
+	return err
+}
+
 const editMessage = `-- name: EditMessage :exec
 UPDATE messages
-SET content = $2, is_edited = true, updated_at = CURRENT_TIMESTAMP
+SET content = $2, entities = $3, is_edited = true, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 `
 
 type EditMessageParams struct {
-	ID      uuid.UUID      `json:"id"`
-	Content sql.NullString `json:"content"`
+	ID       uuid.UUID       `json:"id"`
+	Content  sql.NullString  `json:"content"`
+	Entities json.RawMessage `json:"entities"`
 }
 
 func (q *Queries) EditMessage(ctx context.Context, arg EditMessageParams) error {
-	_, err := q.db.ExecContext(ctx, editMessage, arg.ID, arg.Content)
+	_, err := q.db.ExecContext(ctx, editMessage, arg.ID, arg.Content, arg.Entities)
 	return err
 }
 
@@ -180,6 +315,81 @@ func (q *Queries) GetChatReadReceipts(ctx context.Context, chatID uuid.UUID) ([]
 	return items, nil
 }
 
+const getExpiredMessages = `-- name: GetExpiredMessages :many
+SELECT id, chat_id
+FROM messages
+WHERE expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP
+ORDER BY expires_at ASC
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+type GetExpiredMessagesRow struct {
+	ID     uuid.UUID `json:"id"`
+	ChatID uuid.UUID `json:"chat_id"`
+}
+
+func (q *Queries) GetExpiredMessages(ctx context.Context, limit int32) ([]GetExpiredMessagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredMessages, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetExpiredMessagesRow{}
+	for rows.Next() {
+		var i GetExpiredMessagesRow
+		if err := rows.Scan(&i.ID, &i.ChatID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImageUrlsInUse = `-- name: GetImageUrlsInUse :many
+SELECT i.url
+FROM images i
+INNER JOIN messages m ON i.message_id = m.id
+WHERE i.url = ANY($1::text[])
+  AND m.is_deleted = false
+UNION
+SELECT r.url
+FROM message_revisions mr
+INNER JOIN messages m ON mr.message_id = m.id
+CROSS JOIN LATERAL unnest(mr.images) AS r(url)
+WHERE r.url = ANY($1::text[])
+  AND m.is_deleted = false
+`
+
+func (q *Queries) GetImageUrlsInUse(ctx context.Context, urls []string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getImageUrlsInUse, pq.Array(urls))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		items = append(items, url)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMessageById = `-- name: GetMessageById :one
 SELECT
     m.id,
@@ -189,6 +399,10 @@ SELECT
     m.is_deleted,
     m.is_edited,
     m.reply_to_message_id,
+    m.forwarded_from_message_id,
+    m.forwarded_from_sender_id,
+    m.message_type,
+    m.entities,
     m.created_at,
     m.updated_at
 FROM messages m
@@ -196,15 +410,19 @@ WHERE m.id = $1
 `
 
 type GetMessageByIdRow struct {
-	ID               uuid.UUID      `json:"id"`
-	ChatID           uuid.UUID      `json:"chat_id"`
-	SenderID         uuid.UUID      `json:"sender_id"`
-	Content          sql.NullString `json:"content"`
-	IsDeleted        bool           `json:"is_deleted"`
-	IsEdited         bool           `json:"is_edited"`
-	ReplyToMessageID uuid.NullUUID  `json:"reply_to_message_id"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
+	ID                     uuid.UUID       `json:"id"`
+	ChatID                 uuid.UUID       `json:"chat_id"`
+	SenderID               uuid.UUID       `json:"sender_id"`
+	Content                sql.NullString  `json:"content"`
+	IsDeleted              bool            `json:"is_deleted"`
+	IsEdited               bool            `json:"is_edited"`
+	ReplyToMessageID       uuid.NullUUID   `json:"reply_to_message_id"`
+	ForwardedFromMessageID uuid.NullUUID   `json:"forwarded_from_message_id"`
+	ForwardedFromSenderID  uuid.NullUUID   `json:"forwarded_from_sender_id"`
+	MessageType            string          `json:"message_type"`
+	Entities               json.RawMessage `json:"entities"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
 }
 
 func (q *Queries) GetMessageById(ctx context.Context, id uuid.UUID) (GetMessageByIdRow, error) {
@@ -218,6 +436,10 @@ func (q *Queries) GetMessageById(ctx context.Context, id uuid.UUID) (GetMessageB
 		&i.IsDeleted,
 		&i.IsEdited,
 		&i.ReplyToMessageID,
+		&i.ForwardedFromMessageID,
+		&i.ForwardedFromSenderID,
+		&i.MessageType,
+		&i.Entities,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -225,7 +447,7 @@ func (q *Queries) GetMessageById(ctx context.Context, id uuid.UUID) (GetMessageB
 }
 
 const getMessageImages = `-- name: GetMessageImages :many
-SELECT id, message_id, url, created_at
+SELECT id, message_id, url, created_at, width, height, variants, blurhash
 FROM images
 WHERE message_id = ANY($1::uuid[])
 `
@@ -244,6 +466,10 @@ func (q *Queries) GetMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([
 			&i.MessageID,
 			&i.Url,
 			&i.CreatedAt,
+			&i.Width,
+			&i.Height,
+			&i.Variants,
+			&i.Blurhash,
 		); err != nil {
 			return nil, err
 		}
@@ -361,14 +587,29 @@ SELECT
     rm.is_deleted AS reply_is_deleted,
     rm.sender_id AS reply_sender_id,
     ru.username AS reply_sender_username,
-    ru.profile_image_url AS reply_sender_profile_image_url
+    ru.profile_image_url AS reply_sender_profile_image_url,
+    m.forwarded_from_message_id,
+    m.forwarded_from_sender_id,
+    fu.username AS forwarded_from_sender_username,
+    m.message_type,
+    m.metadata,
+    m.expires_at,
+    m.entities,
+    COALESCE(rm.entities, '[]'::jsonb) AS reply_entities
 FROM messages m
 INNER JOIN chat_members cm_filter ON cm_filter.chat_id = m.chat_id AND cm_filter.user_id = $1::uuid
 INNER JOIN users u ON m.sender_id = u.id
 LEFT JOIN messages rm ON m.reply_to_message_id = rm.id
 LEFT JOIN users ru ON rm.sender_id = ru.id
+LEFT JOIN users fu ON m.forwarded_from_sender_id = fu.id
 WHERE m.chat_id = $2::uuid
   AND (cm_filter.cleared_at IS NULL OR m.created_at > cm_filter.cleared_at)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM hidden_messages hm
+    WHERE hm.message_id = m.id AND hm.user_id = $1::uuid
+  )
+  AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
   AND (
     $3::timestamp IS NULL OR
     m.created_at < $3::timestamp OR
@@ -387,22 +628,30 @@ type GetMessagesByChatPaginatedParams struct {
 }
 
 type GetMessagesByChatPaginatedRow struct {
-	ID                         uuid.UUID      `json:"id"`
-	ChatID                     uuid.UUID      `json:"chat_id"`
-	SenderID                   uuid.UUID      `json:"sender_id"`
-	Content                    sql.NullString `json:"content"`
-	IsDeleted                  bool           `json:"is_deleted"`
-	IsEdited                   bool           `json:"is_edited"`
-	ReplyToMessageID           uuid.NullUUID  `json:"reply_to_message_id"`
-	CreatedAt                  time.Time      `json:"created_at"`
-	UpdatedAt                  time.Time      `json:"updated_at"`
-	SenderUsername             string         `json:"sender_username"`
-	SenderProfileImageUrl      sql.NullString `json:"sender_profile_image_url"`
-	ReplyContent               sql.NullString `json:"reply_content"`
-	ReplyIsDeleted             sql.NullBool   `json:"reply_is_deleted"`
-	ReplySenderID              uuid.NullUUID  `json:"reply_sender_id"`
-	ReplySenderUsername        sql.NullString `json:"reply_sender_username"`
-	ReplySenderProfileImageUrl sql.NullString `json:"reply_sender_profile_image_url"`
+	ID                          uuid.UUID       `json:"id"`
+	ChatID                      uuid.UUID       `json:"chat_id"`
+	SenderID                    uuid.UUID       `json:"sender_id"`
+	Content                     sql.NullString  `json:"content"`
+	IsDeleted                   bool            `json:"is_deleted"`
+	IsEdited                    bool            `json:"is_edited"`
+	ReplyToMessageID            uuid.NullUUID   `json:"reply_to_message_id"`
+	CreatedAt                   time.Time       `json:"created_at"`
+	UpdatedAt                   time.Time       `json:"updated_at"`
+	SenderUsername              string          `json:"sender_username"`
+	SenderProfileImageUrl       sql.NullString  `json:"sender_profile_image_url"`
+	ReplyContent                sql.NullString  `json:"reply_content"`
+	ReplyIsDeleted              sql.NullBool    `json:"reply_is_deleted"`
+	ReplySenderID               uuid.NullUUID   `json:"reply_sender_id"`
+	ReplySenderUsername         sql.NullString  `json:"reply_sender_username"`
+	ReplySenderProfileImageUrl  sql.NullString  `json:"reply_sender_profile_image_url"`
+	ForwardedFromMessageID      uuid.NullUUID   `json:"forwarded_from_message_id"`
+	ForwardedFromSenderID       uuid.NullUUID   `json:"forwarded_from_sender_id"`
+	ForwardedFromSenderUsername sql.NullString  `json:"forwarded_from_sender_username"`
+	MessageType                 string          `json:"message_type"`
+	Metadata                    json.RawMessage `json:"metadata"`
+	ExpiresAt                   sql.NullTime    `json:"expires_at"`
+	Entities                    json.RawMessage `json:"entities"`
+	ReplyEntities               json.RawMessage `json:"reply_entities"`
 }
 
 func (q *Queries) GetMessagesByChatPaginated(ctx context.Context, arg GetMessagesByChatPaginatedParams) ([]GetMessagesByChatPaginatedRow, error) {
@@ -437,6 +686,57 @@ func (q *Queries) GetMessagesByChatPaginated(ctx context.Context, arg GetMessage
 			&i.ReplySenderID,
 			&i.ReplySenderUsername,
 			&i.ReplySenderProfileImageUrl,
+			&i.ForwardedFromMessageID,
+			&i.ForwardedFromSenderID,
+			&i.ForwardedFromSenderUsername,
+			&i.MessageType,
+			&i.Metadata,
+			&i.ExpiresAt,
+			&i.Entities,
+			&i.ReplyEntities,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesByIds = `-- name: GetMessagesByIds :many
+SELECT id, chat_id, sender_id, is_deleted, message_type
+FROM messages
+WHERE id = ANY($1::uuid[])
+`
+
+type GetMessagesByIdsRow struct {
+	ID          uuid.UUID `json:"id"`
+	ChatID      uuid.UUID `json:"chat_id"`
+	SenderID    uuid.UUID `json:"sender_id"`
+	IsDeleted   bool      `json:"is_deleted"`
+	MessageType string    `json:"message_type"`
+}
+
+func (q *Queries) GetMessagesByIds(ctx context.Context, ids []uuid.UUID) ([]GetMessagesByIdsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMessagesByIds, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetMessagesByIdsRow{}
+	for rows.Next() {
+		var i GetMessagesByIdsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.SenderID,
+			&i.IsDeleted,
+			&i.MessageType,
 		); err != nil {
 			return nil, err
 		}
@@ -451,6 +751,42 @@ func (q *Queries) GetMessagesByChatPaginated(ctx context.Context, arg GetMessage
 	return items, nil
 }
 
+const hardDeleteMessages = `-- name: HardDeleteMessages :exec
+DELETE FROM messages
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) HardDeleteMessages(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hardDeleteMessages, pq.Array(ids))
+	return err
+}
+
+const moveReadReceiptsOffMessages = `-- name: MoveReadReceiptsOffMessages :exec
+UPDATE chat_read_receipts cr
+SET last_read_message_id = (
+    SELECT m.id
+    FROM messages m
+    WHERE m.chat_id = cr.chat_id
+      AND m.created_at <= cr.last_read_at
+      AND m.id <> ALL($1::uuid[])
+    ORDER BY m.created_at DESC, m.id DESC
+    LIMIT 1
+)
+WHERE cr.last_read_message_id = ANY($1::uuid[])
+  AND EXISTS (
+    SELECT 1
+    FROM messages m
+    WHERE m.chat_id = cr.chat_id
+      AND m.created_at <= cr.last_read_at
+      AND m.id <> ALL($1::uuid[])
+  )
+`
+
+func (q *Queries) MoveReadReceiptsOffMessages(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, moveReadReceiptsOffMessages, pq.Array(ids))
+	return err
+}
+
 const upsertChatReadReceipt = `-- name: UpsertChatReadReceipt :exec
 INSERT INTO chat_read_receipts (chat_id, user_id, last_read_message_id, last_read_at)
 VALUES ($1, $2, $3, $4)
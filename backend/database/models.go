@@ -6,11 +6,25 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Attachment struct {
+	ID          uuid.UUID       `json:"id"`
+	MessageID   uuid.UUID       `json:"message_id"`
+	Key         string          `json:"key"`
+	FileName    string          `json:"file_name"`
+	ContentType string          `json:"content_type"`
+	Size        int64           `json:"size"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Kind        string          `json:"kind"`
+	DurationMs  sql.NullInt32   `json:"duration_ms"`
+	Waveform    json.RawMessage `json:"waveform"`
+}
+
 type Chat struct {
 	ID        uuid.UUID      `json:"id"`
 	Name      sql.NullString `json:"name"`
@@ -36,23 +50,166 @@ type ChatReadReceipt struct {
 	LastReadAt        time.Time `json:"last_read_at"`
 }
 
-type Image struct {
-	ID        uuid.UUID `json:"id"`
+type ChatSetting struct {
+	ChatID            uuid.UUID `json:"chat_id"`
+	EditWindowSeconds int32     `json:"edit_window_seconds"`
+	AllowDeletes      bool      `json:"allow_deletes"`
+	MaxImages         int32     `json:"max_images"`
+	MaxMessageLength  int32     `json:"max_message_length"`
+	WhoCanPost        string    `json:"who_can_post"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	MessageTtlSeconds int32     `json:"message_ttl_seconds"`
+}
+
+type HiddenMessage struct {
+	UserID    uuid.UUID `json:"user_id"`
 	MessageID uuid.UUID `json:"message_id"`
-	Url       string    `json:"url"`
-	CreatedAt time.Time `json:"created_at"`
+	HiddenAt  time.Time `json:"hidden_at"`
+}
+
+type Image struct {
+	ID        uuid.UUID       `json:"id"`
+	MessageID uuid.UUID       `json:"message_id"`
+	Url       string          `json:"url"`
+	CreatedAt time.Time       `json:"created_at"`
+	Width     sql.NullInt32   `json:"width"`
+	Height    sql.NullInt32   `json:"height"`
+	Variants  json.RawMessage `json:"variants"`
+	Blurhash  sql.NullString  `json:"blurhash"`
+}
+
+type LinkPreview struct {
+	Url         string         `json:"url"`
+	Title       sql.NullString `json:"title"`
+	Description sql.NullString `json:"description"`
+	ImageUrl    sql.NullString `json:"image_url"`
+	SiteName    sql.NullString `json:"site_name"`
+	FetchFailed bool           `json:"fetch_failed"`
+	FetchedAt   time.Time      `json:"fetched_at"`
 }
 
 type Message struct {
-	ID               uuid.UUID      `json:"id"`
+	ID                     uuid.UUID       `json:"id"`
+	ChatID                 uuid.UUID       `json:"chat_id"`
+	SenderID               uuid.UUID       `json:"sender_id"`
+	Content                sql.NullString  `json:"content"`
+	IsDeleted              bool            `json:"is_deleted"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
+	IsEdited               bool            `json:"is_edited"`
+	ReplyToMessageID       uuid.NullUUID   `json:"reply_to_message_id"`
+	ForwardedFromMessageID uuid.NullUUID   `json:"forwarded_from_message_id"`
+	ForwardedFromSenderID  uuid.NullUUID   `json:"forwarded_from_sender_id"`
+	ExpiresAt              sql.NullTime    `json:"expires_at"`
+	MessageType            string          `json:"message_type"`
+	Metadata               json.RawMessage `json:"metadata"`
+	Entities               json.RawMessage `json:"entities"`
+}
+
+type MessageDraft struct {
+	UserID           uuid.UUID      `json:"user_id"`
 	ChatID           uuid.UUID      `json:"chat_id"`
-	SenderID         uuid.UUID      `json:"sender_id"`
 	Content          sql.NullString `json:"content"`
-	IsDeleted        bool           `json:"is_deleted"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	IsEdited         bool           `json:"is_edited"`
+	Images           []string       `json:"images"`
 	ReplyToMessageID uuid.NullUUID  `json:"reply_to_message_id"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type MessageLinkPreview struct {
+	MessageID uuid.UUID `json:"message_id"`
+	Url       string    `json:"url"`
+	Position  int32     `json:"position"`
+}
+
+type MessageMention struct {
+	MessageID uuid.UUID `json:"message_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Position  int32     `json:"position"`
+	Length    int32     `json:"length"`
+}
+
+type MessageReminder struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	MessageID uuid.UUID `json:"message_id"`
+	ChatID    uuid.UUID `json:"chat_id"`
+	RemindAt  time.Time `json:"remind_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MessageRevision struct {
+	ID        uuid.UUID      `json:"id"`
+	MessageID uuid.UUID      `json:"message_id"`
+	Content   sql.NullString `json:"content"`
+	Images    []string       `json:"images"`
+	EditedAt  time.Time      `json:"edited_at"`
+}
+
+type Poll struct {
+	ID             uuid.UUID    `json:"id"`
+	MessageID      uuid.UUID    `json:"message_id"`
+	Question       string       `json:"question"`
+	AllowsMultiple bool         `json:"allows_multiple"`
+	IsAnonymous    bool         `json:"is_anonymous"`
+	ClosesAt       sql.NullTime `json:"closes_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `json:"id"`
+	PollID   uuid.UUID `json:"poll_id"`
+	Text     string    `json:"text"`
+	Position int32     `json:"position"`
+}
+
+type PollVote struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	OptionID  uuid.UUID `json:"option_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SavedMessage struct {
+	UserID    uuid.UUID `json:"user_id"`
+	MessageID uuid.UUID `json:"message_id"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+type ScheduledMessage struct {
+	ID               uuid.UUID       `json:"id"`
+	ChatID           uuid.UUID       `json:"chat_id"`
+	SenderID         uuid.UUID       `json:"sender_id"`
+	Content          sql.NullString  `json:"content"`
+	Images           []string        `json:"images"`
+	ReplyToMessageID uuid.NullUUID   `json:"reply_to_message_id"`
+	SendAt           time.Time       `json:"send_at"`
+	Status           string          `json:"status"`
+	FailureReason    sql.NullString  `json:"failure_reason"`
+	SentMessageID    uuid.NullUUID   `json:"sent_message_id"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	Entities         json.RawMessage `json:"entities"`
+}
+
+type Upload struct {
+	ID          uuid.UUID       `json:"id"`
+	OwnerID     uuid.UUID       `json:"owner_id"`
+	Key         string          `json:"key"`
+	Url         string          `json:"url"`
+	Size        int64           `json:"size"`
+	ContentType string          `json:"content_type"`
+	UsedAt      sql.NullTime    `json:"used_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Width       sql.NullInt32   `json:"width"`
+	Height      sql.NullInt32   `json:"height"`
+	Variants    json.RawMessage `json:"variants"`
+	Blurhash    sql.NullString  `json:"blurhash"`
+	Pending     bool            `json:"pending"`
+	Kind        string          `json:"kind"`
+	FileName    sql.NullString  `json:"file_name"`
+	DurationMs  sql.NullInt32   `json:"duration_ms"`
+	Waveform    json.RawMessage `json:"waveform"`
+	Charged     bool            `json:"charged"`
 }
 
 type User struct {
@@ -64,3 +221,9 @@ type User struct {
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
+
+type UserStorage struct {
+	UserID    uuid.UUID `json:"user_id"`
+	BytesUsed int64     `json:"bytes_used"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
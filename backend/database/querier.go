@@ -12,41 +12,124 @@ import (
 
 type Querier interface {
 	AddChatMember(ctx context.Context, arg AddChatMemberParams) error
-	AddMessageImage(ctx context.Context, arg AddMessageImageParams) error
+	AddMessageAttachments(ctx context.Context, arg AddMessageAttachmentsParams) ([]Attachment, error)
+	AddMessageImage(ctx context.Context, arg AddMessageImageParams) (Image, error)
+	AddMessageLinkPreview(ctx context.Context, arg AddMessageLinkPreviewParams) error
+	AddMessageMentions(ctx context.Context, arg AddMessageMentionsParams) error
+	AddPollVotes(ctx context.Context, arg AddPollVotesParams) error
+	CanAccessImage(ctx context.Context, arg CanAccessImageParams) (bool, error)
+	CancelMessageReminder(ctx context.Context, arg CancelMessageReminderParams) (int64, error)
+	CancelScheduledMessage(ctx context.Context, id uuid.UUID) (int64, error)
+	ClaimDueMessageReminders(ctx context.Context, limit int32) ([]ClaimDueMessageRemindersRow, error)
+	ClaimDueScheduledMessage(ctx context.Context) (ScheduledMessage, error)
+	ClaimFileUploads(ctx context.Context, arg ClaimFileUploadsParams) ([]uuid.UUID, error)
+	ClaimUploads(ctx context.Context, arg ClaimUploadsParams) ([]string, error)
+	CompleteUpload(ctx context.Context, arg CompleteUploadParams) (Upload, error)
+	CopyMessageAttachments(ctx context.Context, arg CopyMessageAttachmentsParams) ([]Attachment, error)
 	CreateChat(ctx context.Context, arg CreateChatParams) (Chat, error)
+	CreateChatSettings(ctx context.Context, chatID uuid.UUID) error
+	CreateFileUpload(ctx context.Context, arg CreateFileUploadParams) (Upload, error)
+	CreateForwardedMessage(ctx context.Context, arg CreateForwardedMessageParams) (Message, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateMessageReminder(ctx context.Context, arg CreateMessageReminderParams) (MessageReminder, error)
+	CreateMessageRevision(ctx context.Context, messageID uuid.UUID) error
+	CreatePendingUpload(ctx context.Context, arg CreatePendingUploadParams) (Upload, error)
+	CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error)
+	CreatePollOption(ctx context.Context, arg CreatePollOptionParams) (PollOption, error)
+	CreateScheduledMessage(ctx context.Context, arg CreateScheduledMessageParams) (ScheduledMessage, error)
+	CreateSystemMessage(ctx context.Context, arg CreateSystemMessageParams) (Message, error)
+	CreateUpload(ctx context.Context, arg CreateUploadParams) (Upload, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVoiceUpload(ctx context.Context, arg CreateVoiceUploadParams) (Upload, error)
 	DeleteChat(ctx context.Context, id uuid.UUID) error
 	DeleteImageByUrl(ctx context.Context, url string) error
 	DeleteMessage(ctx context.Context, id uuid.UUID) error
+	DeleteMessageDraft(ctx context.Context, arg DeleteMessageDraftParams) error
 	DeleteMessageImages(ctx context.Context, arg DeleteMessageImagesParams) error
+	DeleteMessageMentions(ctx context.Context, messageID uuid.UUID) error
+	DeleteMessageReminders(ctx context.Context, ids []uuid.UUID) error
+	DeleteMessageRemindersByMessages(ctx context.Context, messageIds []uuid.UUID) error
+	DeleteMessageRemindersInChat(ctx context.Context, arg DeleteMessageRemindersInChatParams) error
+	DeleteMessages(ctx context.Context, ids []uuid.UUID) error
+	DeletePollVotes(ctx context.Context, arg DeletePollVotesParams) error
+	DeleteSavedMessagesInChat(ctx context.Context, arg DeleteSavedMessagesInChatParams) error
+	DeleteUploads(ctx context.Context, ids []uuid.UUID) error
+	DeleteUploadsByKeys(ctx context.Context, keys []string) error
 	EditMessage(ctx context.Context, arg EditMessageParams) error
+	GetAttachmentForMember(ctx context.Context, arg GetAttachmentForMemberParams) (Attachment, error)
+	GetAttachmentKeysInUse(ctx context.Context, keys []string) ([]string, error)
+	GetAvailableUploadUrls(ctx context.Context, arg GetAvailableUploadUrlsParams) ([]string, error)
+	GetChatAttachments(ctx context.Context, chatID uuid.UUID) ([]Attachment, error)
 	GetChatByIdWithMembers(ctx context.Context, id uuid.UUID) ([]GetChatByIdWithMembersRow, error)
 	GetChatByMembers(ctx context.Context, arg GetChatByMembersParams) (GetChatByMembersRow, error)
 	GetChatDeletionStats(ctx context.Context, chatID uuid.UUID) (GetChatDeletionStatsRow, error)
 	GetChatImageUrls(ctx context.Context, chatID uuid.UUID) ([]string, error)
 	GetChatMember(ctx context.Context, arg GetChatMemberParams) (ChatMember, error)
+	GetChatMembersByUsernames(ctx context.Context, arg GetChatMembersByUsernamesParams) ([]GetChatMembersByUsernamesRow, error)
 	GetChatMetadata(ctx context.Context, id uuid.UUID) (GetChatMetadataRow, error)
 	GetChatReadReceipts(ctx context.Context, chatID uuid.UUID) ([]ChatReadReceipt, error)
+	GetChatSettings(ctx context.Context, chatID uuid.UUID) (ChatSetting, error)
 	GetChatsWithMembers(ctx context.Context, userID uuid.UUID) ([]GetChatsWithMembersRow, error)
-	GetLastMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([]GetLastMessageImagesRow, error)
+	GetExpiredMessages(ctx context.Context, limit int32) ([]GetExpiredMessagesRow, error)
+	GetImageUrlsInUse(ctx context.Context, urls []string) ([]string, error)
+	GetLastMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([]Image, error)
+	GetLinkPreview(ctx context.Context, url string) (LinkPreview, error)
+	GetMessageAttachments(ctx context.Context, dollar_1 []uuid.UUID) ([]Attachment, error)
 	GetMessageById(ctx context.Context, id uuid.UUID) (GetMessageByIdRow, error)
+	GetMessageDraft(ctx context.Context, arg GetMessageDraftParams) (MessageDraft, error)
+	GetMessageDraftsByUser(ctx context.Context, userID uuid.UUID) ([]MessageDraft, error)
 	GetMessageImages(ctx context.Context, dollar_1 []uuid.UUID) ([]Image, error)
+	GetMessageLinkPreviews(ctx context.Context, dollar_1 []uuid.UUID) ([]GetMessageLinkPreviewsRow, error)
+	GetMessageMentions(ctx context.Context, dollar_1 []uuid.UUID) ([]GetMessageMentionsRow, error)
+	GetMessageRemindersByUser(ctx context.Context, arg GetMessageRemindersByUserParams) ([]GetMessageRemindersByUserRow, error)
+	GetMessageRevisions(ctx context.Context, messageID uuid.UUID) ([]MessageRevision, error)
 	GetMessagesByChat(ctx context.Context, chatID uuid.UUID) ([]GetMessagesByChatRow, error)
 	GetMessagesByChatPaginated(ctx context.Context, arg GetMessagesByChatPaginatedParams) ([]GetMessagesByChatPaginatedRow, error)
+	GetMessagesByIds(ctx context.Context, ids []uuid.UUID) ([]GetMessagesByIdsRow, error)
+	GetOrphanedUploads(ctx context.Context, arg GetOrphanedUploadsParams) ([]GetOrphanedUploadsRow, error)
+	GetPendingScheduledMessagesBySender(ctx context.Context, arg GetPendingScheduledMessagesBySenderParams) ([]ScheduledMessage, error)
+	GetPendingUpload(ctx context.Context, arg GetPendingUploadParams) (Upload, error)
+	GetPollByMessageId(ctx context.Context, messageID uuid.UUID) (Poll, error)
+	GetPollOptions(ctx context.Context, pollIds []uuid.UUID) ([]PollOption, error)
+	GetPollVotes(ctx context.Context, pollIds []uuid.UUID) ([]GetPollVotesRow, error)
+	GetPollsByMessageIds(ctx context.Context, messageIds []uuid.UUID) ([]Poll, error)
+	GetSavedMessageIds(ctx context.Context, arg GetSavedMessageIdsParams) ([]uuid.UUID, error)
+	GetSavedMessages(ctx context.Context, arg GetSavedMessagesParams) ([]GetSavedMessagesRow, error)
+	GetScheduledMessageById(ctx context.Context, id uuid.UUID) (ScheduledMessage, error)
+	GetUploadByUrl(ctx context.Context, url string) (Upload, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUserStorageBreakdown(ctx context.Context, ownerID uuid.UUID) ([]GetUserStorageBreakdownRow, error)
+	GetUserStorageUsed(ctx context.Context, userID uuid.UUID) (int64, error)
+	HardDeleteMessages(ctx context.Context, ids []uuid.UUID) error
+	HideMessage(ctx context.Context, arg HideMessageParams) error
+	HideMessages(ctx context.Context, arg HideMessagesParams) error
 	IsChatMember(ctx context.Context, arg IsChatMemberParams) (bool, error)
+	IsMessageVisible(ctx context.Context, arg IsMessageVisibleParams) (bool, error)
+	LockPoll(ctx context.Context, id uuid.UUID) error
+	LockUserStorage(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkScheduledMessageFailed(ctx context.Context, arg MarkScheduledMessageFailedParams) ([]string, error)
+	MarkScheduledMessageSent(ctx context.Context, arg MarkScheduledMessageSentParams) error
+	MoveReadReceiptsOffMessages(ctx context.Context, ids []uuid.UUID) error
+	ReleaseUploads(ctx context.Context, arg ReleaseUploadsParams) error
 	RemoveChatMember(ctx context.Context, arg RemoveChatMemberParams) error
+	SaveMessage(ctx context.Context, arg SaveMessageParams) error
 	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	TrackRawUpload(ctx context.Context, arg TrackRawUploadParams) error
+	UnchargeRevisionImages(ctx context.Context, urls []string) error
+	UnsaveMessage(ctx context.Context, arg UnsaveMessageParams) error
 	UpdateChatCreator(ctx context.Context, arg UpdateChatCreatorParams) error
 	UpdateChatMemberClearedAt(ctx context.Context, arg UpdateChatMemberClearedAtParams) error
 	UpdateChatMemberDeletedAt(ctx context.Context, arg UpdateChatMemberDeletedAtParams) error
 	UpdateChatName(ctx context.Context, arg UpdateChatNameParams) error
+	UpdateChatSettings(ctx context.Context, arg UpdateChatSettingsParams) (ChatSetting, error)
 	UpdatePassword(ctx context.Context, arg UpdatePasswordParams) error
+	UpdateScheduledMessage(ctx context.Context, arg UpdateScheduledMessageParams) (ScheduledMessage, error)
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
 	UpsertChatReadReceipt(ctx context.Context, arg UpsertChatReadReceiptParams) error
+	UpsertLinkPreview(ctx context.Context, arg UpsertLinkPreviewParams) (LinkPreview, error)
+	UpsertMessageDraft(ctx context.Context, arg UpsertMessageDraftParams) (MessageDraft, error)
 }
 
 var _ Querier = (*Queries)(nil)
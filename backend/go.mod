@@ -18,6 +18,8 @@ require (
 	github.com/redis/go-redis/v9 v9.17.0
 	github.com/ulule/limiter/v3 v3.11.2
 	golang.org/x/crypto v0.44.0
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.47.0
 )
 
 require (
@@ -64,7 +66,6 @@ require (
 	github.com/ugorji/go/codec v1.3.1 // indirect
 	go.uber.org/mock v0.6.0 // indirect
 	golang.org/x/arch v0.23.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/text v0.31.0 // indirect
 	google.golang.org/protobuf v1.36.10 // indirect
@@ -0,0 +1,46 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestBlurhash(t *testing.T) {
+	solid := image.NewNRGBA(image.Rect(0, 0, 50, 40))
+	draw.Draw(solid, solid.Bounds(), image.NewUniform(color.NRGBA{R: 40, G: 120, B: 200, A: 255}), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name string
+		img  image.Image
+		// Components are encoded in the first character, 4x3 as "L" and 3x4 as "T"
+		wantComponents byte
+	}{
+		{name: "landscape", img: halves(80, 40, 255), wantComponents: 'L'},
+		{name: "portrait", img: halves(40, 80, 255), wantComponents: 'T'},
+		{name: "solid", img: solid, wantComponents: 'L'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Blurhash(tt.img)
+			// Size flag, maximum AC value, four characters for the DC and two for each of the 11 ACs
+			if len(got) != 28 {
+				t.Fatalf("Blurhash() = %q, want 28 characters", got)
+			}
+			if got[0] != tt.wantComponents {
+				t.Errorf("Blurhash() = %q, want components %q", got, tt.wantComponents)
+			}
+		})
+	}
+
+	// The DC component of a solid image is its colour
+	if got, want := Blurhash(solid)[2:6], encode83(40<<16+120<<8+200, 4); got != want {
+		t.Errorf("Blurhash() DC of a solid image = %q, want %q", got, want)
+	}
+
+	if got := Blurhash(image.NewNRGBA(image.Rectangle{})); got != "" {
+		t.Errorf("Blurhash() of an empty image = %q, want none", got)
+	}
+}
@@ -0,0 +1,198 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels caps the decoded size of an upload so a small, highly compressed file cannot
+// expand into gigabytes of memory
+const MaxPixels = 40_000_000
+
+// JPEGQuality is used for re-encoded originals and their variants
+const JPEGQuality = 85
+
+var (
+	// ErrUnsupportedFormat is returned for anything other than JPEG, PNG or WebP
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrMalformed is returned when the file claims to be an image but cannot be decoded
+	ErrMalformed = errors.New("malformed image")
+	// ErrTooLarge is returned when the image dimensions exceed MaxPixels
+	ErrTooLarge = errors.New("image dimensions too large")
+)
+
+// Size describes a variant that is scaled to fit within MaxDimension on its longest side
+type Size struct {
+	Name         string
+	MaxDimension int
+	// Square crops the centre of the image before scaling, for thumbnails
+	Square bool
+}
+
+// DefaultSizes are the variants produced for every upload. Sizes at least as large as the
+// original are skipped, except for the thumbnail which is always generated.
+var DefaultSizes = []Size{
+	{Name: "large", MaxDimension: 1920},
+	{Name: "medium", MaxDimension: 1080},
+	{Name: "small", MaxDimension: 480},
+	{Name: "thumbnail", MaxDimension: 200, Square: true},
+}
+
+// Encoded is a re-encoded image ready to be stored
+type Encoded struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Result is a processed upload: the cleaned original followed by its variants, all in the
+// same format
+type Result struct {
+	ContentType string
+	Ext         string
+	Blurhash    string
+	Original    Encoded
+	Variants    []Encoded
+}
+
+// Process fully decodes an uploaded image, applies its EXIF orientation and re-encodes it
+// without any metadata. PNGs and images with transparency stay PNG, everything else becomes
+// JPEG since there is no WebP encoder in the standard library.
+func Process(data []byte, sizes []Size) (*Result, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupportedFormat
+		}
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	switch format {
+	case "jpeg", "png", "webp":
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrMalformed
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	usePNG := format == "png" || !isOpaque(img)
+
+	result := &Result{
+		ContentType: "image/jpeg",
+		Ext:         ".jpg",
+		Blurhash:    Blurhash(img),
+	}
+	if usePNG {
+		result.ContentType = "image/png"
+		result.Ext = ".png"
+	}
+
+	original, err := encode("original", img, usePNG)
+	if err != nil {
+		return nil, err
+	}
+	result.Original = original
+
+	bounds := img.Bounds()
+	for _, size := range sizes {
+		var variant image.Image
+		if size.Square {
+			variant = thumbnail(img, size.MaxDimension)
+		} else {
+			if bounds.Dx() <= size.MaxDimension && bounds.Dy() <= size.MaxDimension {
+				continue
+			}
+			variant = fit(img, size.MaxDimension)
+		}
+
+		encoded, err := encode(size.Name, variant, usePNG)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, encoded)
+	}
+
+	return result, nil
+}
+
+func encode(name string, img image.Image, usePNG bool) (Encoded, error) {
+	var buf bytes.Buffer
+	var err error
+	if usePNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+	}
+	if err != nil {
+		return Encoded{}, fmt.Errorf("failed to encode %s image: %w", name, err)
+	}
+
+	bounds := img.Bounds()
+	return Encoded{
+		Name:   name,
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Data:   buf.Bytes(),
+	}, nil
+}
+
+// fit scales the image down so its longest side is maxDimension, keeping the aspect ratio
+func fit(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width >= height {
+		height = max(1, height*maxDimension/width)
+		width = maxDimension
+	} else {
+		width = max(1, width*maxDimension/height)
+		height = maxDimension
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// thumbnail crops the largest centred square and scales it to at most maxDimension
+func thumbnail(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	target := min(side, maxDimension)
+	dst := image.NewRGBA(image.Rect(0, 0, target, target))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halves builds an image whose left half is red and right half is blue
+func halves(width, height int, alpha uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{B: 255, A: alpha}
+			if x < width/2 {
+				c = color.NRGBA{R: 255, A: alpha}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an Exif APP1 segment holding the orientation right after the SOI marker
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+// withDimensions rewrites the IHDR chunk of a PNG so it claims the given size
+func withDimensions(data []byte, width, height uint32) []byte {
+	out := append([]byte{}, data...)
+	// Signature (8), chunk length (4), then "IHDR" and its data
+	ihdr := out[12 : 12+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(out[12+4+13:], crc32.ChecksumIEEE(ihdr))
+	return out
+}
+
+func TestProcess(t *testing.T) {
+	type dimensions struct {
+		name          string
+		width, height int
+	}
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantOriginal    dimensions
+		wantVariants    []dimensions
+	}{
+		{
+			name:            "large jpeg gets every variant",
+			data:            encodeJPEG(t, halves(3000, 2000, 255)),
+			wantContentType: "image/jpeg",
+			wantOriginal:    dimensions{"original", 3000, 2000},
+			wantVariants: []dimensions{
+				{"large", 1920, 1280},
+				{"medium", 1080, 720},
+				{"small", 480, 320},
+				{"thumbnail", 200, 200},
+			},
+		},
+		{
+			name:            "small jpeg only gets a thumbnail",
+			data:            encodeJPEG(t, halves(100, 50, 255)),
+			wantContentType: "image/jpeg",
+			wantOriginal:    dimensions{"original", 100, 50},
+			wantVariants:    []dimensions{{"thumbnail", 50, 50}},
+		},
+		{
+			name:            "portrait png stays png",
+			data:            encodePNG(t, halves(600, 1200, 255)),
+			wantContentType: "image/png",
+			wantOriginal:    dimensions{"original", 600, 1200},
+			wantVariants: []dimensions{
+				{"medium", 540, 1080},
+				{"small", 240, 480},
+				{"thumbnail", 200, 200},
+			},
+		},
+		{
+			name:            "exif rotation swaps the axes",
+			data:            withOrientation(encodeJPEG(t, halves(40, 20, 255)), 6),
+			wantContentType: "image/jpeg",
+			wantOriginal:    dimensions{"original", 20, 40},
+			wantVariants:    []dimensions{{"thumbnail", 20, 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data, DefaultSizes)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if result.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", result.ContentType, tt.wantContentType)
+			}
+			if result.Blurhash == "" {
+				t.Error("Blurhash is empty")
+			}
+
+			got := dimensions{result.Original.Name, result.Original.Width, result.Original.Height}
+			if got != tt.wantOriginal {
+				t.Errorf("Original = %+v, want %+v", got, tt.wantOriginal)
+			}
+			if len(result.Variants) != len(tt.wantVariants) {
+				t.Fatalf("%d variants, want %d", len(result.Variants), len(tt.wantVariants))
+			}
+			for i, variant := range result.Variants {
+				got := dimensions{variant.Name, variant.Width, variant.Height}
+				if got != tt.wantVariants[i] {
+					t.Errorf("Variants[%d] = %+v, want %+v", i, got, tt.wantVariants[i])
+				}
+
+				// Every stored file must decode to the size it is recorded with
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(variant.Data))
+				if err != nil {
+					t.Fatalf("decode %s variant: %v", variant.Name, err)
+				}
+				if cfg.Width != variant.Width || cfg.Height != variant.Height {
+					t.Errorf("%s variant decodes as %dx%d, want %dx%d", variant.Name, cfg.Width, cfg.Height, variant.Width, variant.Height)
+				}
+			}
+
+			// Metadata is dropped, so the orientation must not be applied twice by viewers
+			if orientation := jpegOrientation(result.Original.Data); orientation != 1 {
+				t.Errorf("re-encoded orientation = %d, want 1", orientation)
+			}
+		})
+	}
+}
+
+func TestProcessRotatesPixels(t *testing.T) {
+	result, err := Process(withOrientation(encodeJPEG(t, halves(40, 20, 255)), 6), nil)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(result.Original.Data))
+	if err != nil {
+		t.Fatalf("decode original: %v", err)
+	}
+
+	// Rotating clockwise moves the red left half to the top
+	top, bottom := img.At(10, 5), img.At(10, 35)
+	if r, _, b, _ := top.RGBA(); r < b {
+		t.Errorf("top pixel = %v, want red", top)
+	}
+	if r, _, b, _ := bottom.RGBA(); b < r {
+		t.Errorf("bottom pixel = %v, want blue", bottom)
+	}
+}
+
+func TestProcessKeepsTransparency(t *testing.T) {
+	result, err := Process(encodePNG(t, halves(64, 64, 128)), nil)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if result.ContentType != "image/png" || result.Ext != ".png" {
+		t.Errorf("ContentType, Ext = %q, %q, want image/png, .png", result.ContentType, result.Ext)
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, halves(10, 10, 255), nil); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+
+	validJPEG := encodeJPEG(t, halves(100, 100, 255))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "gif", data: gifData.Bytes(), wantErr: ErrUnsupportedFormat},
+		{name: "not an image", data: []byte("hello, world"), wantErr: ErrUnsupportedFormat},
+		{name: "empty", data: nil, wantErr: ErrUnsupportedFormat},
+		{name: "truncated jpeg", data: validJPEG[:len(validJPEG)/2], wantErr: ErrMalformed},
+		{name: "too many pixels", data: withDimensions(encodePNG(t, halves(10, 10, 255)), 10_000, 10_000), wantErr: ErrTooLarge},
+		{name: "zero width", data: withDimensions(encodePNG(t, halves(10, 10, 255)), 0, 10), wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Process(tt.data, DefaultSizes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Process() error = %v, want %v", err, tt.wantErr)
+			}
+			if result != nil {
+				t.Errorf("Process() result = %+v, want nil", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	fetchTimeout   = 5 * time.Second
+	dialTimeout    = 3 * time.Second
+	maxRedirects   = 3
+	maxBodyBytes   = 1 << 20 // 1MB
+	userAgentValue = "BubblesLinkPreview/1.0"
+)
+
+var (
+	ErrUnsupportedURL = errors.New("only http and https urls can be previewed")
+	ErrBlockedAddress = errors.New("url resolves to a non-public address")
+	ErrNotHTML        = errors.New("url did not return an html document")
+)
+
+// Preview is the OpenGraph summary of a page, empty fields were not provided by the page
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher downloads pages for previews while refusing to connect to private, loopback or otherwise
+// internal addresses. The address is checked when the connection is made, so DNS answers that change
+// between lookups and redirects to internal hosts are rejected as well.
+type Fetcher struct {
+	client *http.Client
+}
+
+func NewFetcher() *Fetcher {
+	return newFetcher(isPublicIP, fetchTimeout)
+}
+
+// newFetcher builds a Fetcher that only connects to addresses allowIP accepts and gives up on
+// a page after timeout. Tests use it to reach a local server.
+func newFetcher(allowIP func(net.IP) bool, timeout time.Duration) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || !allowIP(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// via holds the original request as well as every redirect followed so far
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrUnsupportedURL
+			}
+			return nil
+		},
+	}
+
+	return &Fetcher{client: client}
+}
+
+// Fetch downloads the page at rawURL and extracts its preview
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+		return nil, ErrUnsupportedURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgentValue)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return nil, ErrNotHTML
+	}
+
+	preview, err := parseHTML(io.LimitReader(resp.Body, maxBodyBytes), resp.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	preview.URL = rawURL
+
+	return preview, nil
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	for _, block := range reservedBlocks {
+		if block.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Special purpose ranges the net package does not classify. IPv6 ranges that embed an IPv4
+// address are blocked outright, since the embedded address may be an internal one.
+var reservedBlocks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved
+	"::/96",           // IPv4-compatible (deprecated)
+	"64:ff9b::/96",    // IPv4/IPv6 translation
+	"64:ff9b:1::/48",  // local IPv4/IPv6 translation
+	"100::/64",        // discard only
+	"2001::/32",       // Teredo
+	"2001:db8::/32",   // documentation
+	"2002::/16",       // 6to4
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	blocks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			panic(err)
+		}
+		blocks[i] = block
+	}
+	return blocks
+}
@@ -0,0 +1,255 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allowLoopback lets a fetcher reach httptest servers, which listen on 127.0.0.1
+func allowLoopback(ip net.IP) bool {
+	return ip.Equal(net.IPv4(127, 0, 0, 1))
+}
+
+const testPage = `<!doctype html>
+<html><head>
+<title>Fallback title</title>
+<meta property="og:title" content="Open Graph title">
+<meta property="og:description" content="A page about things">
+<meta property="og:image" content="/images/cover.png">
+<meta property="og:site_name" content="Example">
+</head><body><p>Hello</p></body></html>`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, testPage)
+	})
+	// /redirect/N redirects N more times before serving the page
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		remaining, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if remaining == 0 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", remaining-1), http.StatusFound)
+	})
+	mux.HandleFunc("/redirect-internal", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://127.0.0.2:1/admin", http.StatusFound)
+	})
+	mux.HandleFunc("/redirect-file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Large page</title><meta name="filler" content="`)
+		fmt.Fprint(w, strings.Repeat("a", 2*maxBodyBytes))
+		fmt.Fprint(w, `"><meta property="og:title" content="Past the limit"></head></html>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title": "not a page"}`)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetch(t *testing.T) {
+	server := newTestServer(t)
+	fetcher := newFetcher(allowLoopback, time.Second)
+
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := Preview{
+		URL:         server.URL + "/page",
+		Title:       "Open Graph title",
+		Description: "A page about things",
+		ImageURL:    server.URL + "/images/cover.png",
+		SiteName:    "Example",
+	}
+	if *preview != want {
+		t.Errorf("Fetch() = %+v, want %+v", *preview, want)
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	server := newTestServer(t)
+	fetcher := newFetcher(allowLoopback, time.Second)
+
+	// /redirect/N takes N+1 redirects to reach the page
+	preview, err := fetcher.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxRedirects-1))
+	if err != nil {
+		t.Fatalf("Fetch() with %d redirects error = %v", maxRedirects, err)
+	}
+	if preview.Title != "Open Graph title" {
+		t.Errorf("Title = %q after redirects", preview.Title)
+	}
+	if preview.URL != fmt.Sprintf("%s/redirect/%d", server.URL, maxRedirects-1) {
+		t.Errorf("URL = %q, want the URL that was requested", preview.URL)
+	}
+
+	if _, err := fetcher.Fetch(context.Background(), fmt.Sprintf("%s/redirect/%d", server.URL, maxRedirects)); err == nil {
+		t.Errorf("Fetch() with %d redirects succeeded, want an error", maxRedirects+1)
+	}
+}
+
+func TestFetchBlockedAddresses(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		fetcher *Fetcher
+		url     string
+		wantErr error
+	}{
+		{
+			name:    "loopback server",
+			fetcher: NewFetcher(),
+			url:     server.URL + "/page",
+			wantErr: ErrBlockedAddress,
+		},
+		{
+			name:    "redirect to an internal host",
+			fetcher: newFetcher(allowLoopback, time.Second),
+			url:     server.URL + "/redirect-internal",
+			wantErr: ErrBlockedAddress,
+		},
+		{
+			name:    "redirect to a file url",
+			fetcher: newFetcher(allowLoopback, time.Second),
+			url:     server.URL + "/redirect-file",
+			wantErr: ErrUnsupportedURL,
+		},
+		{
+			name:    "file url",
+			fetcher: NewFetcher(),
+			url:     "file:///etc/passwd",
+			wantErr: ErrUnsupportedURL,
+		},
+		{
+			name:    "url without host",
+			fetcher: NewFetcher(),
+			url:     "http:///page",
+			wantErr: ErrUnsupportedURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.fetcher.Fetch(context.Background(), tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Fetch() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchBodyLimit(t *testing.T) {
+	server := newTestServer(t)
+	fetcher := newFetcher(allowLoopback, 5*time.Second)
+
+	preview, err := fetcher.Fetch(context.Background(), server.URL+"/large")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// Only the first megabyte is read, so the tag after the filler is never seen
+	if preview.Title != "Large page" {
+		t.Errorf("Title = %q, want the title before the size limit", preview.Title)
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	server := newTestServer(t)
+	fetcher := newFetcher(allowLoopback, 100*time.Millisecond)
+
+	started := time.Now()
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/slow"); err == nil {
+		t.Fatal("Fetch() of a slow page succeeded, want a timeout")
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Fetch() took %s, want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestFetchRejectsOtherResponses(t *testing.T) {
+	server := newTestServer(t)
+	fetcher := newFetcher(allowLoopback, time.Second)
+
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf("Fetch() of JSON error = %v, want %v", err, ErrNotHTML)
+	}
+	if _, err := fetcher.Fetch(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("Fetch() of a missing page succeeded, want an error")
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"240.0.0.1", false},
+		{"198.18.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b:1::a00:1", false},
+		{"2002:7f00:1::1", false},
+		{"2002:a9fe:a9fe::1", false},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", false},
+		{"2001:db8::1", false},
+		{"100::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
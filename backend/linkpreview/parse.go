@@ -0,0 +1,131 @@
+package linkpreview
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxSiteNameLength    = 100
+)
+
+// parseHTML reads OpenGraph tags from the document head, falling back to <title> and the
+// description meta tag. Parsing stops at <body> since the tags we need live in the head.
+func parseHTML(r io.Reader, pageURL *url.URL) (*Preview, error) {
+	tokenizer := html.NewTokenizer(r)
+
+	var preview Preview
+	var fallbackTitle, fallbackDescription string
+	inTitle := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if tokenizer.Err() == io.EOF {
+				return finishPreview(preview, fallbackTitle, fallbackDescription, pageURL), nil
+			}
+			return nil, tokenizer.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "body":
+				return finishPreview(preview, fallbackTitle, fallbackDescription, pageURL), nil
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "meta":
+				key, content := metaAttributes(token)
+				switch key {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if preview.ImageURL == "" {
+						preview.ImageURL = content
+					}
+				case "og:site_name":
+					preview.SiteName = content
+				case "description":
+					fallbackDescription = content
+				}
+			}
+
+		case html.TextToken:
+			if inTitle && fallbackTitle == "" {
+				fallbackTitle = string(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+func metaAttributes(token html.Token) (string, string) {
+	var key, content string
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(attr.Val))
+			}
+		case "content":
+			content = attr.Val
+		}
+	}
+	return key, content
+}
+
+func finishPreview(preview Preview, fallbackTitle, fallbackDescription string, pageURL *url.URL) *Preview {
+	if preview.Title == "" {
+		preview.Title = fallbackTitle
+	}
+	if preview.Description == "" {
+		preview.Description = fallbackDescription
+	}
+
+	preview.Title = clean(preview.Title, maxTitleLength)
+	preview.Description = clean(preview.Description, maxDescriptionLength)
+	preview.SiteName = clean(preview.SiteName, maxSiteNameLength)
+	preview.ImageURL = resolveImageURL(preview.ImageURL, pageURL)
+
+	return &preview
+}
+
+// resolveImageURL makes relative image paths absolute and drops anything that is not http(s)
+func resolveImageURL(imageURL string, pageURL *url.URL) string {
+	imageURL = strings.TrimSpace(imageURL)
+	if imageURL == "" {
+		return ""
+	}
+
+	ref, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+
+	resolved := pageURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	return resolved.String()
+}
+
+// clean collapses whitespace and truncates to maxLength characters
+func clean(value string, maxLength int) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value
+	}
+	return string([]rune(value)[:maxLength])
+}
@@ -0,0 +1,104 @@
+package linkpreview
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name string
+		html string
+		want Preview
+	}{
+		{
+			name: "open graph tags",
+			html: `<html><head>
+				<meta property="og:title" content="Title">
+				<meta property="og:description" content="Description">
+				<meta property="og:image" content="https://cdn.example.com/a.png">
+				<meta property="og:site_name" content="Example">
+				</head></html>`,
+			want: Preview{Title: "Title", Description: "Description", ImageURL: "https://cdn.example.com/a.png", SiteName: "Example"},
+		},
+		{
+			name: "falls back to title and description",
+			html: `<html><head><title>Page title</title><meta name="description" content="Plain description"></head></html>`,
+			want: Preview{Title: "Page title", Description: "Plain description"},
+		},
+		{
+			name: "open graph wins over fallbacks",
+			html: `<head><title>Page title</title><meta name="description" content="Plain">
+				<meta property="og:title" content="OG title"><meta property="og:description" content="OG"></head>`,
+			want: Preview{Title: "OG title", Description: "OG"},
+		},
+		{
+			name: "attribute names are case insensitive",
+			html: `<head><META PROPERTY="OG:TITLE" CONTENT="Shouting"></head>`,
+			want: Preview{Title: "Shouting"},
+		},
+		{
+			name: "first image is kept",
+			html: `<head><meta property="og:image" content="/first.png"><meta property="og:image" content="/second.png"></head>`,
+			want: Preview{ImageURL: "https://example.com/first.png"},
+		},
+		{
+			name: "relative image resolves against the page",
+			html: `<head><meta property="og:image:secure_url" content="cover.jpg"></head>`,
+			want: Preview{ImageURL: "https://example.com/articles/cover.jpg"},
+		},
+		{
+			name: "non-http image is dropped",
+			html: `<head><meta property="og:image" content="javascript:alert(1)"></head>`,
+			want: Preview{},
+		},
+		{
+			name: "tags in the body are ignored",
+			html: `<head><title>Head title</title></head><body><meta property="og:title" content="Body title"></body>`,
+			want: Preview{Title: "Head title"},
+		},
+		{
+			name: "whitespace is collapsed",
+			html: "<head><title>\n  Spread \t over\n lines  </title></head>",
+			want: Preview{Title: "Spread over lines"},
+		},
+		{
+			name: "entities are decoded",
+			html: `<head><meta property="og:title" content="Fish &amp; Chips"></head>`,
+			want: Preview{Title: "Fish & Chips"},
+		},
+		{
+			name: "empty document",
+			html: ``,
+			want: Preview{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHTML(strings.NewReader(tt.html), pageURL)
+			if err != nil {
+				t.Fatalf("parseHTML() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseHTML() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHTMLTruncatesLongValues(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/")
+	long := strings.Repeat("é", maxTitleLength+50)
+
+	got, err := parseHTML(strings.NewReader(`<head><meta property="og:title" content="`+long+`"></head>`), pageURL)
+	if err != nil {
+		t.Fatalf("parseHTML() error = %v", err)
+	}
+	if got.Title != strings.Repeat("é", maxTitleLength) {
+		t.Errorf("title has %d characters, want %d", len([]rune(got.Title)), maxTitleLength)
+	}
+}
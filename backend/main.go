@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"log"
 	"net/http"
 	"os"
@@ -15,7 +16,9 @@ import (
 
 	"github.com/anmol7470/bubbles/backend/database"
 	"github.com/anmol7470/bubbles/backend/middleware"
+	"github.com/anmol7470/bubbles/backend/notify"
 	"github.com/anmol7470/bubbles/backend/routes"
+	"github.com/anmol7470/bubbles/backend/storage"
 	"github.com/anmol7470/bubbles/backend/utils"
 	ws "github.com/anmol7470/bubbles/backend/websocket"
 )
@@ -62,14 +65,15 @@ func main() {
 	hub := ws.NewHub(dbService)
 	go hub.Run()
 
-	// Initialize upload handler
-	uploadHandler, err := routes.NewUploadHandler()
+	// Initialize file storage and the upload handler on top of it
+	store, err := storage.NewFromEnv(context.Background())
 	if err != nil {
-		log.Printf("Warning: Failed to initialize upload handler: %v", err)
+		log.Fatalf("Failed to initialize file storage: %v", err)
 	}
+	uploadHandler := routes.NewUploadHandler(dbService, store, routes.AttachmentConfigFromEnv(), routes.StorageQuotaConfigFromEnv())
 
 	// Initialize auth handler
-	authHandler := routes.NewAuthHandler(dbService)
+	authHandler := routes.NewAuthHandler(dbService, store)
 
 	// Auth routes (with rate limiting to prevent brute force)
 	auth := router.Group("/auth")
@@ -85,8 +89,8 @@ func main() {
 	}
 
 	// Initialize chat handler
-	chatHandler := routes.NewChatHandler(dbService)
-	chatActionsHandler := routes.NewChatActionsHandler(dbService, uploadHandler)
+	chatHandler := routes.NewChatHandler(dbService, store)
+	chatActionsHandler := routes.NewChatActionsHandler(dbService, hub, uploadHandler)
 
 	// Chat routes (with authentication)
 	chat := router.Group("/chat")
@@ -110,11 +114,15 @@ func main() {
 			chatActions.POST("/members/add", chatActionsHandler.AddChatMember)
 			chatActions.POST("/members/remove", chatActionsHandler.RemoveChatMember)
 			chatActions.POST("/change-admin", chatActionsHandler.ChangeChatAdmin)
+			chatActions.GET("/settings", chatActionsHandler.GetChatSettings)
+			chatActions.POST("/settings", chatActionsHandler.UpdateChatSettings)
+			chatActions.GET("/draft", chatActionsHandler.GetChatDraft)
+			chatActions.POST("/draft", chatActionsHandler.SetChatDraft)
 		}
 	}
 
 	// Initialize message handler
-	messageHandler := routes.NewMessageHandler(dbService, hub)
+	messageHandler := routes.NewMessageHandler(dbService, hub, store)
 
 	// Message routes (with authentication and rate limiting)
 	messages := router.Group("/messages")
@@ -122,40 +130,87 @@ func main() {
 	{
 		if rateLimiter != nil {
 			messages.POST("/send", rateLimiter.MessageLimit(), messageHandler.SendMessage)
+			messages.POST("/polls/create", rateLimiter.MessageLimit(), messageHandler.CreatePoll)
 		} else {
 			messages.POST("/send", messageHandler.SendMessage)
+			messages.POST("/polls/create", messageHandler.CreatePoll)
 		}
 		messages.POST("/get", messageHandler.GetChatMessages)
-
-		if uploadHandler != nil {
-			messages.POST("/edit", func(c *gin.Context) {
-				messageHandler.EditMessage(c, uploadHandler)
-			})
-			messages.POST("/delete", func(c *gin.Context) {
-				messageHandler.DeleteMessage(c, uploadHandler)
-			})
-		}
+		messages.POST("/history", messageHandler.GetMessageHistory)
+		messages.POST("/forward", messageHandler.ForwardMessage)
+		messages.POST("/polls/vote", messageHandler.VotePoll)
+		messages.POST("/polls/retract", messageHandler.RetractPollVote)
+		messages.POST("/scheduled/create", messageHandler.CreateScheduledMessage)
+		messages.POST("/scheduled/list", messageHandler.GetScheduledMessages)
+		messages.POST("/scheduled/edit", messageHandler.EditScheduledMessage)
+		messages.POST("/scheduled/cancel", messageHandler.CancelScheduledMessage)
+		messages.POST("/delete-for-me", messageHandler.DeleteMessageForMe)
+		messages.POST("/delete-for-me/batch", messageHandler.DeleteMessagesForMe)
+		messages.POST("/save", messageHandler.SaveMessage)
+		messages.POST("/unsave", messageHandler.UnsaveMessage)
+		messages.GET("/saved", messageHandler.GetSavedMessages)
+		messages.GET("/images/download", messageHandler.DownloadImage)
+		messages.GET("/attachments/:id/download", messageHandler.DownloadAttachment)
+		messages.POST("/reminders/create", messageHandler.CreateReminder)
+		messages.POST("/reminders/list", messageHandler.GetReminders)
+		messages.POST("/reminders/cancel", messageHandler.CancelReminder)
+		messages.POST("/edit", func(c *gin.Context) {
+			messageHandler.EditMessage(c, uploadHandler)
+		})
+		messages.POST("/delete", func(c *gin.Context) {
+			messageHandler.DeleteMessage(c, uploadHandler)
+		})
+		messages.POST("/delete/batch", func(c *gin.Context) {
+			messageHandler.DeleteMessages(c, uploadHandler)
+		})
 	}
 
+	// Deliver scheduled messages and reminders, remove expired messages, unfurl links and sweep
+	// orphaned uploads in the background until shutdown
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	defer stopDispatcher()
+	go messageHandler.RunScheduledMessageDispatcher(dispatcherCtx)
+	go messageHandler.RunExpiredMessageReaper(dispatcherCtx, uploadHandler)
+	go messageHandler.RunLinkPreviewWorkers(dispatcherCtx)
+	go messageHandler.RunReminderDispatcher(dispatcherCtx, notify.NewFromEnv())
+	go uploadHandler.RunOrphanedUploadSweeper(dispatcherCtx, routes.UploadSweepConfigFromEnv())
+
 	// Upload routes (with authentication)
-	if uploadHandler != nil {
-		upload := router.Group("/upload")
-		upload.Use(middleware.AuthMiddleware())
-		{
-			if rateLimiter != nil {
-				upload.POST("/image", rateLimiter.UploadLimit(), uploadHandler.UploadImage)
-			} else {
-				upload.POST("/image", uploadHandler.UploadImage)
-			}
+	upload := router.Group("/upload")
+	upload.Use(middleware.AuthMiddleware())
+	{
+		if rateLimiter != nil {
+			upload.POST("/image", rateLimiter.UploadLimit(), uploadHandler.UploadImage)
+			upload.POST("/presign", rateLimiter.UploadLimit(), uploadHandler.PresignUpload)
+			upload.POST("/file", rateLimiter.UploadLimit(), uploadHandler.UploadFile)
+			upload.POST("/voice", rateLimiter.UploadLimit(), uploadHandler.UploadVoice)
+		} else {
+			upload.POST("/image", uploadHandler.UploadImage)
+			upload.POST("/presign", uploadHandler.PresignUpload)
+			upload.POST("/file", uploadHandler.UploadFile)
+			upload.POST("/voice", uploadHandler.UploadVoice)
 		}
+		upload.POST("/complete", uploadHandler.CompleteUpload)
+	}
+
+	// Files on the local disk are served by the backend, cloud drivers serve their own
+	if _, ok := store.(*storage.LocalStorage); ok {
+		fileHandler := routes.NewFileHandler(store)
+		router.GET(storage.LocalFilesRoute+"/*key", fileHandler.ServeFile)
 	}
 
 	// User routes (with authentication)
-	userHandler := routes.NewUserHandler(dbService)
+	userHandler := routes.NewUserHandler(dbService, store)
 	user := router.Group("/user")
 	user.Use(middleware.AuthMiddleware())
 	{
 		user.PUT("/profile", userHandler.UpdateProfile)
+		user.GET("/storage", uploadHandler.GetStorageUsage)
+	}
+
+	// Runtime and upload sweeper counters, only exposed when explicitly enabled
+	if os.Getenv("METRICS_ENABLED") == "true" {
+		router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 	}
 
 	// WebSocket endpoint (with authentication)
@@ -206,6 +261,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopDispatcher()
 
 	// Give outstanding requests 10 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,24 +43,45 @@ type MessageSender struct {
 }
 
 type LastMessage struct {
-	ID        uuid.UUID      `json:"id"`
-	Content   *string        `json:"content,omitempty"`
-	Sender    *MessageSender `json:"sender,omitempty"`
-	IsDeleted bool           `json:"is_deleted"`
-	Images    []string       `json:"images"`
-	CreatedAt time.Time      `json:"created_at"`
+	ID          uuid.UUID       `json:"id"`
+	Content     *string         `json:"content,omitempty"`
+	Sender      *MessageSender  `json:"sender,omitempty"`
+	IsDeleted   bool            `json:"is_deleted"`
+	Images      []MessageImage  `json:"images"`
+	MessageType string          `json:"message_type"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
 }
 
 type ChatInfo struct {
-	ID          uuid.UUID    `json:"id"`
-	Name        *string      `json:"name,omitempty"`
-	IsGroup     bool         `json:"is_group"`
-	CreatorID   uuid.UUID    `json:"creator_id"`
-	Members     []ChatMember `json:"members"`
-	UnreadCount int32        `json:"unread_count"`
-	LastMessage *LastMessage `json:"last_message,omitempty"`
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	ID                 uuid.UUID    `json:"id"`
+	Name               *string      `json:"name,omitempty"`
+	IsGroup            bool         `json:"is_group"`
+	CreatorID          uuid.UUID    `json:"creator_id"`
+	Members            []ChatMember `json:"members"`
+	UnreadCount        int32        `json:"unread_count"`
+	UnreadMentionCount int32        `json:"unread_mention_count"`
+	LastMessage        *LastMessage `json:"last_message,omitempty"`
+	Draft              *ChatDraft   `json:"draft,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
+}
+
+type ChatDraft struct {
+	Content          *string    `json:"content,omitempty"`
+	Images           []string   `json:"images"`
+	ReplyToMessageID *uuid.UUID `json:"reply_to_message_id,omitempty"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type SetChatDraftRequest struct {
+	Content          string   `json:"content"`
+	Images           []string `json:"images"`
+	ReplyToMessageID *string  `json:"reply_to_message_id,omitempty"`
+}
+
+type GetChatDraftResponse struct {
+	Draft *ChatDraft `json:"draft"`
 }
 
 type GetChatsResponse struct {
@@ -67,16 +89,57 @@ type GetChatsResponse struct {
 }
 
 type Message struct {
-	ID                    uuid.UUID       `json:"id"`
-	Content               *string         `json:"content,omitempty"`
-	SenderID              uuid.UUID       `json:"sender_id"`
-	SenderUsername        string          `json:"sender_username"`
-	SenderProfileImageUrl *string         `json:"sender_profile_image_url,omitempty"`
-	IsDeleted             bool            `json:"is_deleted"`
-	IsEdited              bool            `json:"is_edited"`
-	Images                []string        `json:"images"`
-	CreatedAt             time.Time       `json:"created_at"`
-	ReplyTo               *ReplyToMessage `json:"reply_to,omitempty"`
+	ID                    uuid.UUID        `json:"id"`
+	Content               *string          `json:"content,omitempty"`
+	SenderID              uuid.UUID        `json:"sender_id"`
+	SenderUsername        string           `json:"sender_username"`
+	SenderProfileImageUrl *string          `json:"sender_profile_image_url,omitempty"`
+	IsDeleted             bool             `json:"is_deleted"`
+	IsEdited              bool             `json:"is_edited"`
+	IsSaved               bool             `json:"is_saved"`
+	Images                []MessageImage   `json:"images"`
+	Attachments           []Attachment     `json:"attachments,omitempty"`
+	CreatedAt             time.Time        `json:"created_at"`
+	ReplyTo               *ReplyToMessage  `json:"reply_to,omitempty"`
+	ForwardedFrom         *ForwardedFrom   `json:"forwarded_from,omitempty"`
+	Poll                  *Poll            `json:"poll,omitempty"`
+	Mentions              []MessageMention `json:"mentions,omitempty"`
+	Entities              []MessageEntity  `json:"entities,omitempty"`
+	LinkPreviews          []LinkPreview    `json:"link_previews,omitempty"`
+	MessageType           string           `json:"message_type"`
+	Metadata              json.RawMessage  `json:"metadata,omitempty"`
+	ExpiresAt             *time.Time       `json:"expires_at,omitempty"`
+}
+
+// MessageImage is an attached image with its processed variants. Width, height and the
+// blurhash placeholder are unknown for images uploaded before they were recorded.
+type MessageImage struct {
+	URL      string         `json:"url"`
+	Width    *int32         `json:"width,omitempty"`
+	Height   *int32         `json:"height,omitempty"`
+	Blurhash *string        `json:"blurhash,omitempty"`
+	Variants []ImageVariant `json:"variants"`
+}
+
+type ImageVariant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+// Attachment is a file or voice message sent with a message. Its contents are only available
+// through the authenticated download route, never from a storage URL.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	Kind        string    `json:"kind"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	// DurationMs and Waveform are only set for voice messages. The waveform has up to 64 bars
+	// from 0 to 100.
+	DurationMs *int32 `json:"duration_ms,omitempty"`
+	Waveform   []int  `json:"waveform,omitempty"`
 }
 
 type ChatReadReceipt struct {
@@ -86,13 +149,68 @@ type ChatReadReceipt struct {
 }
 
 type ReplyToMessage struct {
-	ID                    uuid.UUID `json:"id"`
-	SenderID              uuid.UUID `json:"sender_id"`
-	SenderUsername        string    `json:"sender_username"`
-	SenderProfileImageUrl *string   `json:"sender_profile_image_url,omitempty"`
-	Content               *string   `json:"content,omitempty"`
-	Images                []string  `json:"images"`
-	IsDeleted             bool      `json:"is_deleted"`
+	ID                    uuid.UUID       `json:"id"`
+	SenderID              uuid.UUID       `json:"sender_id"`
+	SenderUsername        string          `json:"sender_username"`
+	SenderProfileImageUrl *string         `json:"sender_profile_image_url,omitempty"`
+	Content               *string         `json:"content,omitempty"`
+	Entities              []MessageEntity `json:"entities,omitempty"`
+	Images                []MessageImage  `json:"images"`
+	IsDeleted             bool            `json:"is_deleted"`
+}
+
+type ForwardedFrom struct {
+	MessageID      *uuid.UUID `json:"message_id,omitempty"`
+	SenderID       *uuid.UUID `json:"sender_id,omitempty"`
+	SenderUsername string     `json:"sender_username"`
+}
+
+// MessageMention marks an @username in the content, with offset and length counted in UTF-16 code units
+type MessageMention struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Offset   int32     `json:"offset"`
+	Length   int32     `json:"length"`
+}
+
+// MessageEntity formats a range of the content, with offset and length counted in UTF-16 code units
+// (JavaScript string indexes).
+// URL is only set for links, Language only for pre blocks and UserID/Username only for mentions.
+type MessageEntity struct {
+	Type     string     `json:"type"`
+	Offset   int32      `json:"offset"`
+	Length   int32      `json:"length"`
+	URL      string     `json:"url,omitempty"`
+	Language string     `json:"language,omitempty"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Username string     `json:"username,omitempty"`
+}
+
+type LinkPreview struct {
+	URL         string  `json:"url"`
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	ImageURL    *string `json:"image_url,omitempty"`
+	SiteName    *string `json:"site_name,omitempty"`
+}
+
+type Poll struct {
+	ID             uuid.UUID    `json:"id"`
+	Question       string       `json:"question"`
+	AllowsMultiple bool         `json:"allows_multiple"`
+	IsAnonymous    bool         `json:"is_anonymous"`
+	ClosesAt       *time.Time   `json:"closes_at,omitempty"`
+	IsClosed       bool         `json:"is_closed"`
+	TotalVoters    int          `json:"total_voters"`
+	Options        []PollOption `json:"options"`
+	MyOptionIDs    []uuid.UUID  `json:"my_option_ids"`
+}
+
+type PollOption struct {
+	ID        uuid.UUID       `json:"id"`
+	Text      string          `json:"text"`
+	VoteCount int             `json:"vote_count"`
+	Voters    []MessageSender `json:"voters,omitempty"`
 }
 
 type GetChatByIdResponse struct {
@@ -124,22 +242,200 @@ type GetChatMessagesResponse struct {
 }
 
 type SendMessageRequest struct {
-	ChatID           string   `json:"chat_id" binding:"required"`
-	Content          string   `json:"content"`
-	Images           []string `json:"images,omitempty"`
-	ReplyToMessageID *string  `json:"reply_to_message_id"`
+	ChatID           string          `json:"chat_id" binding:"required"`
+	Content          string          `json:"content"`
+	Entities         []MessageEntity `json:"entities,omitempty"`
+	Images           []string        `json:"images,omitempty"`
+	Attachments      []string        `json:"attachments,omitempty"`
+	ReplyToMessageID *string         `json:"reply_to_message_id"`
 }
 
 type EditMessageRequest struct {
-	MessageID     string   `json:"message_id" binding:"required"`
-	Content       string   `json:"content"`
-	RemovedImages []string `json:"removed_images,omitempty"`
+	MessageID     string          `json:"message_id" binding:"required"`
+	Content       string          `json:"content"`
+	Entities      []MessageEntity `json:"entities,omitempty"`
+	RemovedImages []string        `json:"removed_images,omitempty"`
 }
 
 type DeleteMessageRequest struct {
 	MessageID string `json:"message_id" binding:"required"`
 }
 
+type BatchMessagesRequest struct {
+	MessageIDs []string `json:"message_ids" binding:"required,min=1"`
+}
+
+type BatchMessageResult struct {
+	MessageID string  `json:"message_id"`
+	Success   bool    `json:"success"`
+	Error     *string `json:"error,omitempty"`
+}
+
+type BatchMessagesResponse struct {
+	Results []BatchMessageResult `json:"results"`
+}
+
+type ForwardMessageRequest struct {
+	MessageID string   `json:"message_id" binding:"required"`
+	ChatIDs   []string `json:"chat_ids" binding:"required,min=1"`
+}
+
+type ForwardMessageResult struct {
+	ChatID    string     `json:"chat_id"`
+	Success   bool       `json:"success"`
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+	Error     *string    `json:"error,omitempty"`
+}
+
+type ForwardMessageResponse struct {
+	Results []ForwardMessageResult `json:"results"`
+}
+
+type CreatePollRequest struct {
+	ChatID         string     `json:"chat_id" binding:"required"`
+	Question       string     `json:"question" binding:"required"`
+	Options        []string   `json:"options" binding:"required"`
+	AllowsMultiple bool       `json:"allows_multiple"`
+	IsAnonymous    bool       `json:"is_anonymous"`
+	ClosesAt       *time.Time `json:"closes_at"`
+}
+
+type VotePollRequest struct {
+	MessageID string   `json:"message_id" binding:"required"`
+	OptionIDs []string `json:"option_ids" binding:"required,min=1"`
+}
+
+type RetractPollVoteRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type CreateScheduledMessageRequest struct {
+	ChatID           string          `json:"chat_id" binding:"required"`
+	Content          string          `json:"content"`
+	Entities         []MessageEntity `json:"entities,omitempty"`
+	Images           []string        `json:"images,omitempty"`
+	ReplyToMessageID *string         `json:"reply_to_message_id"`
+	SendAt           time.Time       `json:"send_at" binding:"required"`
+}
+
+type GetScheduledMessagesRequest struct {
+	ChatID *string `json:"chat_id"`
+}
+
+// EditScheduledMessageRequest only changes the fields that are set. Entities are offsets into the
+// content, so changing the content without sending entities clears them.
+type EditScheduledMessageRequest struct {
+	ID       string           `json:"id" binding:"required"`
+	Content  *string          `json:"content"`
+	Entities *[]MessageEntity `json:"entities"`
+	Images   *[]string        `json:"images"`
+	SendAt   *time.Time       `json:"send_at"`
+}
+
+type CancelScheduledMessageRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+type ScheduledMessage struct {
+	ID               uuid.UUID       `json:"id"`
+	ChatID           uuid.UUID       `json:"chat_id"`
+	Content          *string         `json:"content,omitempty"`
+	Entities         []MessageEntity `json:"entities,omitempty"`
+	Images           []string        `json:"images"`
+	ReplyToMessageID *uuid.UUID      `json:"reply_to_message_id,omitempty"`
+	SendAt           time.Time       `json:"send_at"`
+	Status           string          `json:"status"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+type GetScheduledMessagesResponse struct {
+	Items []ScheduledMessage `json:"items"`
+}
+
+type SaveMessageRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type UnsaveMessageRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type GetSavedMessagesRequest struct {
+	Limit         int    `form:"limit"`
+	CursorSavedAt string `form:"cursor_saved_at"`
+	CursorID      string `form:"cursor_id"`
+}
+
+type DownloadImageRequest struct {
+	URL string `form:"url" binding:"required"`
+}
+
+type SavedMessageChat struct {
+	ID      uuid.UUID `json:"id"`
+	Name    *string   `json:"name,omitempty"`
+	IsGroup bool      `json:"is_group"`
+}
+
+type SavedMessage struct {
+	Message Message          `json:"message"`
+	Chat    SavedMessageChat `json:"chat"`
+	SavedAt time.Time        `json:"saved_at"`
+}
+
+type GetSavedMessagesResponse struct {
+	Items      []SavedMessage `json:"items"`
+	NextCursor *struct {
+		SavedAt time.Time `json:"saved_at"`
+		ID      uuid.UUID `json:"id"`
+	} `json:"next_cursor,omitempty"`
+}
+
+type CreateReminderRequest struct {
+	MessageID string    `json:"message_id" binding:"required"`
+	RemindAt  time.Time `json:"remind_at" binding:"required"`
+}
+
+type GetRemindersRequest struct {
+	ChatID *string `json:"chat_id"`
+}
+
+type CancelReminderRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+type Reminder struct {
+	ID             uuid.UUID `json:"id"`
+	MessageID      uuid.UUID `json:"message_id"`
+	ChatID         uuid.UUID `json:"chat_id"`
+	ChatName       *string   `json:"chat_name,omitempty"`
+	ChatIsGroup    bool      `json:"chat_is_group"`
+	SenderUsername string    `json:"sender_username"`
+	Content        *string   `json:"content,omitempty"`
+	RemindAt       time.Time `json:"remind_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type GetRemindersResponse struct {
+	Items []Reminder `json:"items"`
+}
+
+type GetMessageHistoryRequest struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type MessageRevision struct {
+	ID       uuid.UUID `json:"id"`
+	Content  *string   `json:"content,omitempty"`
+	Images   []string  `json:"images"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type GetMessageHistoryResponse struct {
+	MessageID uuid.UUID         `json:"message_id"`
+	Revisions []MessageRevision `json:"revisions"`
+}
+
 type RenameChatRequest struct {
 	Name string `json:"name" binding:"required"`
 }
@@ -151,3 +447,22 @@ type ModifyChatMemberRequest struct {
 type ChangeChatAdminRequest struct {
 	UserID string `json:"user_id" binding:"required"`
 }
+
+type ChatSettings struct {
+	EditWindowSeconds int32     `json:"edit_window_seconds"`
+	AllowDeletes      bool      `json:"allow_deletes"`
+	MaxImages         int32     `json:"max_images"`
+	MaxMessageLength  int32     `json:"max_message_length"`
+	WhoCanPost        string    `json:"who_can_post"`
+	MessageTTLSeconds int32     `json:"message_ttl_seconds"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type UpdateChatSettingsRequest struct {
+	EditWindowSeconds *int32  `json:"edit_window_seconds"`
+	AllowDeletes      *bool   `json:"allow_deletes"`
+	MaxImages         *int32  `json:"max_images"`
+	MaxMessageLength  *int32  `json:"max_message_length"`
+	WhoCanPost        *string `json:"who_can_post"`
+	MessageTTLSeconds *int32  `json:"message_ttl_seconds"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PresignUploadRequest struct {
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+}
+
+// PresignUploadResponse tells the client how to upload straight to storage. The file must be
+// sent with UploadMethod to UploadURL with exactly UploadHeaders, then confirmed with the upload ID.
+type PresignUploadResponse struct {
+	UploadID      uuid.UUID         `json:"upload_id"`
+	UploadURL     string            `json:"upload_url"`
+	UploadMethod  string            `json:"upload_method"`
+	UploadHeaders map[string]string `json:"upload_headers"`
+	ExpiresAt     time.Time         `json:"expires_at"`
+	// URL is where the image will be served from once the upload is completed
+	URL string `json:"url"`
+}
+
+// UploadFileResponse describes a stored file. The upload ID is what messages reference it by.
+type UploadFileResponse struct {
+	UploadID    uuid.UUID `json:"upload_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+}
+
+// UploadVoiceResponse describes a stored voice message, which is sent like any other attachment
+type UploadVoiceResponse struct {
+	UploadID    uuid.UUID `json:"upload_id"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	DurationMs  int32     `json:"duration_ms"`
+	Waveform    []int     `json:"waveform"`
+}
+
+type CompleteUploadRequest struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
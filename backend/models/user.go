@@ -8,3 +8,19 @@ type UpdateUserProfileRequest struct {
 type UpdateUserProfileResponse struct {
 	User UserInfo `json:"user"`
 }
+
+// StorageUsageResponse describes how much storage the user's uploads take up. Sizes are in bytes
+// and include every resized copy kept for an image.
+type StorageUsageResponse struct {
+	UsedBytes      int64                `json:"used_bytes"`
+	QuotaBytes     int64                `json:"quota_bytes"`
+	RemainingBytes int64                `json:"remaining_bytes"`
+	Breakdown      []StorageUsageByKind `json:"breakdown"`
+}
+
+type StorageUsageByKind struct {
+	// Kind is "image", "file" or "voice"
+	Kind    string `json:"kind"`
+	Uploads int64  `json:"uploads"`
+	Bytes   int64  `json:"bytes"`
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a channel-agnostic message addressed to a single user
+type Notification struct {
+	UserID uuid.UUID         `json:"user_id"`
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+// Notifier delivers notifications outside of the websocket connection, e.g. push or email gateways
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Multi fans a notification out to every channel, attempting all of them even when some fail
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewFromEnv builds the notification channels configured in the environment.
+// With nothing configured it returns an empty Multi, which drops every notification.
+func NewFromEnv() Notifier {
+	var notifiers Multi
+
+	if webhookURL := os.Getenv("NOTIFY_WEBHOOK_URL"); webhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(webhookURL, os.Getenv("NOTIFY_WEBHOOK_SECRET")))
+	}
+
+	return notifiers
+}
@@ -0,0 +1,490 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// Longest file name kept for an attachment, in characters
+const maxAttachmentFileNameLength = 255
+
+// errUnavailableAttachments is returned when a message references files the sender did not upload
+// or has already attached elsewhere
+var errUnavailableAttachments = errors.New("attachments must be unused file uploads owned by the sender")
+
+type attachmentType struct {
+	ContentType string
+	// Sniffed is the prefix http.DetectContentType reports for genuine files of this type, empty
+	// for formats it does not recognise
+	Sniffed string
+}
+
+// attachmentTypes maps the file extensions that can be attached to the type they are stored and
+// served as. The client's own content type is never trusted.
+var attachmentTypes = map[string]attachmentType{
+	".pdf":  {ContentType: "application/pdf", Sniffed: "application/pdf"},
+	".txt":  {ContentType: "text/plain", Sniffed: "text/plain"},
+	".md":   {ContentType: "text/markdown", Sniffed: "text/plain"},
+	".csv":  {ContentType: "text/csv", Sniffed: "text/plain"},
+	".json": {ContentType: "application/json", Sniffed: "text/plain"},
+	".rtf":  {ContentType: "application/rtf", Sniffed: "text/plain"},
+	".doc":  {ContentType: "application/msword"},
+	".xls":  {ContentType: "application/vnd.ms-excel"},
+	".ppt":  {ContentType: "application/vnd.ms-powerpoint"},
+	".docx": {ContentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", Sniffed: "application/zip"},
+	".xlsx": {ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Sniffed: "application/zip"},
+	".pptx": {ContentType: "application/vnd.openxmlformats-officedocument.presentationml.presentation", Sniffed: "application/zip"},
+	".odt":  {ContentType: "application/vnd.oasis.opendocument.text", Sniffed: "application/zip"},
+	".ods":  {ContentType: "application/vnd.oasis.opendocument.spreadsheet", Sniffed: "application/zip"},
+	".odp":  {ContentType: "application/vnd.oasis.opendocument.presentation", Sniffed: "application/zip"},
+	".zip":  {ContentType: "application/zip", Sniffed: "application/zip"},
+	".gz":   {ContentType: "application/gzip", Sniffed: "application/x-gzip"},
+	".tar":  {ContentType: "application/x-tar"},
+	".7z":   {ContentType: "application/x-7z-compressed"},
+	".png":  {ContentType: "image/png", Sniffed: "image/png"},
+	".jpg":  {ContentType: "image/jpeg", Sniffed: "image/jpeg"},
+	".jpeg": {ContentType: "image/jpeg", Sniffed: "image/jpeg"},
+	".gif":  {ContentType: "image/gif", Sniffed: "image/gif"},
+	".webp": {ContentType: "image/webp", Sniffed: "image/webp"},
+}
+
+// AttachmentConfig controls which files can be attached to messages and how large they can be
+type AttachmentConfig struct {
+	// AllowedTypes holds the content types from attachmentTypes that may be uploaded
+	AllowedTypes map[string]bool
+	MaxSize      int64
+}
+
+// AttachmentConfigFromEnv reads ATTACHMENT_ALLOWED_TYPES, a comma-separated list of content
+// types (default: every supported type), and ATTACHMENT_MAX_SIZE_MB (default: 25)
+func AttachmentConfigFromEnv() AttachmentConfig {
+	cfg := AttachmentConfig{
+		AllowedTypes: make(map[string]bool),
+		MaxSize:      constants.DefaultMaxAttachmentSize,
+	}
+
+	supported := make(map[string]bool, len(attachmentTypes))
+	for _, t := range attachmentTypes {
+		supported[t.ContentType] = true
+	}
+
+	if typesEnv := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); typesEnv != "" {
+		for _, contentType := range strings.Split(typesEnv, ",") {
+			contentType = strings.ToLower(strings.TrimSpace(contentType))
+			if contentType == "" {
+				continue
+			}
+			if !supported[contentType] {
+				log.Printf("Ignoring unsupported attachment type %q", contentType)
+				continue
+			}
+			cfg.AllowedTypes[contentType] = true
+		}
+	} else {
+		cfg.AllowedTypes = supported
+	}
+
+	if sizeEnv := os.Getenv("ATTACHMENT_MAX_SIZE_MB"); sizeEnv != "" {
+		if megabytes, err := strconv.Atoi(sizeEnv); err == nil && megabytes > 0 {
+			cfg.MaxSize = int64(megabytes) * 1024 * 1024
+		}
+	}
+
+	return cfg
+}
+
+// allowedType returns how a file with this name is stored, if its extension is allowed
+func (cfg AttachmentConfig) allowedType(fileName string) (string, attachmentType, bool) {
+	ext := strings.ToLower(path.Ext(fileName))
+	t, ok := attachmentTypes[ext]
+	if !ok || !cfg.AllowedTypes[t.ContentType] {
+		return "", attachmentType{}, false
+	}
+	return ext, t, true
+}
+
+func (h *UploadHandler) UploadFile(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	maxSizeMessage := fmt.Sprintf("File too large. Maximum size is %dMB", h.attachments.MaxSize/(1024*1024))
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.attachments.MaxSize+1024*1024)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: maxSizeMessage,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "No file provided",
+		})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.attachments.MaxSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: maxSizeMessage,
+		})
+		return
+	}
+
+	if header.Size == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "File is empty",
+		})
+		return
+	}
+
+	fileName := sanitizeFileName(header.Filename)
+	ext, fileType, ok := h.attachments.allowedType(fileName)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "This file type cannot be attached",
+		})
+		return
+	}
+
+	// The contents must agree with the extension, so e.g. an HTML page cannot pass as a PDF
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read file",
+		})
+		return
+	}
+	if fileType.Sniffed != "" && !strings.HasPrefix(http.DetectContentType(buffer[:n]), fileType.Sniffed) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "File contents do not match its type",
+		})
+		return
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process file",
+		})
+		return
+	}
+
+	if !h.checkStorageQuota(c, userID, header.Size) {
+		return
+	}
+
+	// The stored key never contains the client's file name, which is only kept for display
+	key := fmt.Sprintf("%s/%d/%s%s", userID, time.Now().Unix(), uuid.New().String(), ext)
+
+	if err := h.store.Put(c.Request.Context(), key, file, header.Size, fileType.ContentType); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to upload file",
+		})
+		return
+	}
+
+	var upload database.Upload
+	err = h.recordWithinQuota(c.Request.Context(), userID, header.Size, func(qtx *database.Queries) error {
+		var err error
+		upload, err = qtx.CreateFileUpload(c.Request.Context(), database.CreateFileUploadParams{
+			OwnerID:     userID,
+			Key:         key,
+			Url:         h.store.URL(key),
+			Size:        header.Size,
+			ContentType: fileType.ContentType,
+			FileName:    sql.NullString{String: fileName, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		h.deleteKeys([]string{key})
+		if errors.Is(err, errStorageQuotaExceeded) {
+			h.respondStorageQuotaExceeded(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UploadFileResponse{
+		UploadID:    upload.ID,
+		FileName:    fileName,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+	})
+}
+
+// sanitizeFileName keeps the name a file was uploaded with for display and downloads, without
+// any directories or control characters
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if utf8.RuneCountInString(name) > maxAttachmentFileNameLength {
+		ext := path.Ext(name)
+		if utf8.RuneCountInString(ext) > 16 {
+			ext = ""
+		}
+		runes := []rune(strings.TrimSuffix(name, ext))
+		name = string(runes[:maxAttachmentFileNameLength-utf8.RuneCountInString(ext)]) + ext
+	}
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "file"
+	}
+	return name
+}
+
+// parseAttachmentIDs parses the upload IDs a message refers to its attachments by
+func parseAttachmentIDs(ids []string) ([]uuid.UUID, error) {
+	parsed := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		uploadID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = uploadID
+	}
+	return parsed, nil
+}
+
+// addMessageAttachments claims the sender's file uploads and attaches them to the message,
+// failing with errUnavailableAttachments unless every upload is theirs and unused
+func addMessageAttachments(ctx context.Context, qtx *database.Queries, messageID, ownerID uuid.UUID, uploadIDs []uuid.UUID) ([]models.Attachment, error) {
+	if len(uploadIDs) == 0 {
+		return nil, nil
+	}
+
+	claimed, err := qtx.ClaimFileUploads(ctx, database.ClaimFileUploadsParams{
+		OwnerID: ownerID,
+		Ids:     uploadIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Duplicate IDs claim a single row, so they are rejected here as well
+	if len(claimed) != len(uploadIDs) {
+		return nil, errUnavailableAttachments
+	}
+
+	rows, err := qtx.AddMessageAttachments(ctx, database.AddMessageAttachmentsParams{
+		MessageID: messageID,
+		UploadIds: uploadIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toAttachments(rows), nil
+}
+
+func toAttachments(rows []database.Attachment) []models.Attachment {
+	attachments := make([]models.Attachment, len(rows))
+	for i, row := range rows {
+		attachments[i] = models.Attachment{
+			ID:          row.ID,
+			Kind:        row.Kind,
+			FileName:    row.FileName,
+			ContentType: row.ContentType,
+			Size:        row.Size,
+		}
+		if row.Kind == constants.AttachmentKindVoice {
+			if row.DurationMs.Valid {
+				attachments[i].DurationMs = &row.DurationMs.Int32
+			}
+			if err := json.Unmarshal(row.Waveform, &attachments[i].Waveform); err != nil {
+				log.Printf("Failed to decode waveform for attachment %s: %v", row.ID, err)
+			}
+		}
+	}
+	return attachments
+}
+
+func toAttachmentPayloads(attachments []models.Attachment) []ws.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	payloads := make([]ws.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		payloads[i] = ws.Attachment{
+			ID:          attachment.ID.String(),
+			Kind:        attachment.Kind,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			DurationMs:  attachment.DurationMs,
+			Waveform:    attachment.Waveform,
+		}
+	}
+	return payloads
+}
+
+func loadMessageAttachments(ctx context.Context, queries *database.Queries, messageIDs []uuid.UUID) (map[uuid.UUID][]models.Attachment, error) {
+	attachmentsByMessage := make(map[uuid.UUID][]models.Attachment)
+	if len(messageIDs) == 0 {
+		return attachmentsByMessage, nil
+	}
+
+	rows, err := queries.GetMessageAttachments(ctx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, attachment := range toAttachments(rows) {
+		messageID := rows[i].MessageID
+		attachmentsByMessage[messageID] = append(attachmentsByMessage[messageID], attachment)
+	}
+
+	return attachmentsByMessage, nil
+}
+
+// DeleteAttachmentFiles removes the stored files of attachments whose messages were deleted, along
+// with their upload rows so the space is released from the owner's quota right away instead of on
+// the next sweep. Files that a forwarded copy still attaches are kept.
+func (h *UploadHandler) DeleteAttachmentFiles(ctx context.Context, attachments []database.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.Key)
+	}
+
+	inUse, err := h.dbService.Queries.GetAttachmentKeysInUse(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	skip := make(map[string]struct{}, len(inUse)+len(keys))
+	for _, key := range inUse {
+		skip[key] = struct{}{}
+	}
+
+	unreferenced := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := skip[key]; ok {
+			continue
+		}
+		skip[key] = struct{}{}
+
+		if err := h.store.Delete(ctx, key); err != nil {
+			return err
+		}
+		unreferenced = append(unreferenced, key)
+	}
+
+	if len(unreferenced) == 0 {
+		return nil
+	}
+	return h.dbService.Queries.DeleteUploadsByKeys(ctx, unreferenced)
+}
+
+// DownloadAttachment streams an attachment to a member of the chat it was sent to. Files are
+// always served as downloads so that uploaded HTML or scripts are never rendered by the browser,
+// voice messages are served inline so they can be played back.
+func (h *MessageHandler) DownloadAttachment(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	attachmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid attachment ID",
+		})
+		return
+	}
+
+	// Attachments in chats the user is not in are reported as missing so their existence is not revealed
+	attachment, err := h.dbService.Queries.GetAttachmentForMember(c.Request.Context(), database.GetAttachmentForMemberParams{
+		ID:     attachmentID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Attachment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get attachment",
+		})
+		return
+	}
+
+	object, err := h.store.Open(c.Request.Context(), attachment.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Attachment not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read attachment",
+		})
+		return
+	}
+	defer object.Body.Close()
+
+	// FormatMediaType quotes the name and switches to RFC 2231 encoding for non-ASCII names
+	dispositionType := "attachment"
+	if attachment.Kind == constants.AttachmentKindVoice {
+		dispositionType = "inline"
+	}
+	disposition := mime.FormatMediaType(dispositionType, map[string]string{"filename": attachment.FileName})
+	if disposition == "" {
+		disposition = dispositionType
+	}
+
+	c.Header("Content-Disposition", disposition)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	c.Header("Cache-Control", "private, no-store")
+
+	c.DataFromReader(http.StatusOK, object.Size, attachment.ContentType, object.Body, nil)
+}
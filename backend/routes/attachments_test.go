@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+)
+
+const testPDF = "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n"
+
+// uploadFile posts data as a multipart file upload from the user
+func uploadFile(t *testing.T, handler *UploadHandler, userID uuid.UUID, fileName string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close form: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", userID)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/upload/file", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	handler.UploadFile(c)
+	return recorder
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "report.pdf", want: "report.pdf"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: `C:\Users\alice\notes.txt`, want: "notes.txt"},
+		{name: "  spaced.txt  ", want: "spaced.txt"},
+		{name: "new\nline\x00.txt", want: "newline.txt"},
+		{name: "résumé.pdf", want: "résumé.pdf"},
+		{name: "", want: "file"},
+		{name: "..", want: "file"},
+		{name: strings.Repeat("a", 300) + ".pdf", want: strings.Repeat("a", 251) + ".pdf"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUploadFileRejectsMismatchedContents(t *testing.T) {
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	allowed := map[string]bool{"application/pdf": true, "text/plain": true, "image/png": true, "application/msword": true}
+	// Every case is rejected before anything is stored or recorded
+	handler := NewUploadHandler(nil, store, AttachmentConfig{AllowedTypes: allowed, MaxSize: 1 << 20}, StorageQuotaConfig{MaxBytes: 1 << 20})
+
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		wantErr  string
+	}{
+		{name: "html as pdf", fileName: "invoice.pdf", data: []byte("<html><script>alert(1)</script></html>"), wantErr: "File contents do not match its type"},
+		{name: "text as png", fileName: "photo.png", data: []byte("just some text"), wantErr: "File contents do not match its type"},
+		{name: "pdf as text", fileName: "notes.txt", data: []byte(testPDF), wantErr: "File contents do not match its type"},
+		{name: "type not allowed", fileName: "archive.zip", data: []byte("PK\x03\x04"), wantErr: "This file type cannot be attached"},
+		{name: "no extension", fileName: "README", data: []byte("hello"), wantErr: "This file type cannot be attached"},
+		{name: "empty", fileName: "empty.txt", data: nil, wantErr: "File is empty"},
+		{name: "too large", fileName: "big.txt", data: bytes.Repeat([]byte("a"), 1<<20+1), wantErr: "File too large. Maximum size is 1MB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := uploadFile(t, handler, uuid.New(), tt.fileName, tt.data)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("UploadFile() status = %d, want %d, body %s", recorder.Code, http.StatusBadRequest, recorder.Body)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if response.Error != tt.wantErr {
+				t.Errorf("UploadFile() error = %q, want %q", response.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	messageHandler, uploadHandler := newTestHandlers(t, dbService)
+	uploadHandler.attachments = AttachmentConfig{AllowedTypes: map[string]bool{"application/pdf": true}, MaxSize: 1 << 20}
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob)
+
+	// The name the client sends is kept for the download, but the type comes from the extension
+	fileName := `Q3 "final" résumé.pdf`
+	recorder := uploadFile(t, uploadHandler, alice, fileName, []byte(testPDF))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("UploadFile() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	var uploaded models.UploadFileResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &uploaded); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if uploaded.FileName != fileName || uploaded.ContentType != "application/pdf" || uploaded.Size != int64(len(testPDF)) {
+		t.Errorf("UploadFile() = %+v", uploaded)
+	}
+
+	messageID := createTestMessage(t, db, chatID, alice, "", time.Now(), time.Time{})
+	attachments, err := queries.AddMessageAttachments(ctx, database.AddMessageAttachmentsParams{
+		MessageID: messageID,
+		UploadIds: []uuid.UUID{uploaded.UploadID},
+	})
+	if err != nil || len(attachments) != 1 {
+		t.Fatalf("attach upload: %v", err)
+	}
+
+	download := func(userID uuid.UUID) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Params = gin.Params{{Key: "id", Value: attachments[0].ID.String()}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/messages/attachments/"+attachments[0].ID.String()+"/download", nil)
+
+		messageHandler.DownloadAttachment(c)
+		return recorder
+	}
+
+	recorder = download(bob)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("DownloadAttachment() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	if recorder.Body.String() != testPDF {
+		t.Errorf("DownloadAttachment() body = %q", recorder.Body)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Content-Type = %q, want application/pdf", got)
+	}
+	if got := recorder.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+
+	dispositionType, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Disposition"))
+	if err != nil {
+		t.Fatalf("parse Content-Disposition %q: %v", recorder.Header().Get("Content-Disposition"), err)
+	}
+	if dispositionType != "attachment" || params["filename"] != fileName {
+		t.Errorf("Content-Disposition = %s %v, want an attachment named %q", dispositionType, params, fileName)
+	}
+
+	// Someone outside the chat cannot tell the attachment exists
+	if recorder := download(carol); recorder.Code != http.StatusNotFound {
+		t.Errorf("DownloadAttachment() by a non-member status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
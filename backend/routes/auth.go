@@ -17,16 +17,19 @@ import (
 
 	"github.com/anmol7470/bubbles/backend/database"
 	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
 	"github.com/anmol7470/bubbles/backend/utils"
 )
 
 type AuthHandler struct {
 	dbService *database.Service
+	store     storage.Storage
 }
 
-func NewAuthHandler(dbService *database.Service) *AuthHandler {
+func NewAuthHandler(dbService *database.Service, store storage.Storage) *AuthHandler {
 	return &AuthHandler{
 		dbService: dbService,
+		store:     store,
 	}
 }
 
@@ -173,7 +176,7 @@ func (h *AuthHandler) SignUp(c *gin.Context) {
 			ID:              user.ID,
 			Username:        user.Username,
 			Email:           user.Email,
-			ProfileImageURL: utils.NullableString(user.ProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, user.ProfileImageUrl),
 			CreatedAt:       user.CreatedAt,
 		},
 	})
@@ -255,7 +258,7 @@ func (h *AuthHandler) SignIn(c *gin.Context) {
 			ID:              user.ID,
 			Username:        user.Username,
 			Email:           user.Email,
-			ProfileImageURL: utils.NullableString(user.ProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, user.ProfileImageUrl),
 			CreatedAt:       user.CreatedAt,
 		},
 	})
@@ -288,7 +291,7 @@ func (h *AuthHandler) Verify(c *gin.Context) {
 			ID:              user.ID,
 			Username:        user.Username,
 			Email:           user.Email,
-			ProfileImageURL: utils.NullableString(user.ProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, user.ProfileImageUrl),
 			CreatedAt:       user.CreatedAt,
 		},
 	})
@@ -11,16 +11,19 @@ import (
 
 	"github.com/anmol7470/bubbles/backend/database"
 	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
 	"github.com/anmol7470/bubbles/backend/utils"
 )
 
 type ChatHandler struct {
 	dbService *database.Service
+	store     storage.Storage
 }
 
-func NewChatHandler(dbService *database.Service) *ChatHandler {
+func NewChatHandler(dbService *database.Service, store storage.Storage) *ChatHandler {
 	return &ChatHandler{
 		dbService: dbService,
+		store:     store,
 	}
 }
 
@@ -54,7 +57,7 @@ func (h *ChatHandler) SearchUsers(c *gin.Context) {
 			ID:              user.ID,
 			Username:        user.Username,
 			Email:           user.Email,
-			ProfileImageURL: utils.NullableString(user.ProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, user.ProfileImageUrl),
 		}
 	}
 
@@ -159,6 +162,13 @@ func (h *ChatHandler) CreateChat(c *gin.Context) {
 
 		chatID = chat.ID
 
+		if err := qtx.CreateChatSettings(c.Request.Context(), chatID); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to create chat settings",
+			})
+			return
+		}
+
 		for _, memberID := range memberIds {
 			err := qtx.AddChatMember(c.Request.Context(), database.AddChatMemberParams{
 				ChatID: chatID,
@@ -218,14 +228,15 @@ func (h *ChatHandler) GetUserChats(c *gin.Context) {
 			}
 
 			chatInfo := &models.ChatInfo{
-				ID:          row.ChatID,
-				Name:        name,
-				IsGroup:     row.IsGroup,
-				CreatorID:   row.CreatedBy,
-				Members:     []models.ChatMember{},
-				UnreadCount: int32(row.UnreadCount),
-				CreatedAt:   row.ChatCreatedAt,
-				UpdatedAt:   row.ChatUpdatedAt,
+				ID:                 row.ChatID,
+				Name:               name,
+				IsGroup:            row.IsGroup,
+				CreatorID:          row.CreatedBy,
+				Members:            []models.ChatMember{},
+				UnreadCount:        int32(row.UnreadCount),
+				UnreadMentionCount: int32(row.UnreadMentionCount),
+				CreatedAt:          row.ChatCreatedAt,
+				UpdatedAt:          row.ChatUpdatedAt,
 			}
 
 			// Add last message if exists (check if ID is not zero UUID)
@@ -240,17 +251,19 @@ func (h *ChatHandler) GetUserChats(c *gin.Context) {
 					sender = &models.MessageSender{
 						ID:              row.LastMessageSenderID,
 						Username:        row.LastMessageSenderUsername.String,
-						ProfileImageURL: utils.NullableString(row.LastMessageSenderProfileImageUrl),
+						ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, row.LastMessageSenderProfileImageUrl),
 					}
 				}
 
 				chatInfo.LastMessage = &models.LastMessage{
-					ID:        row.LastMessageID,
-					Content:   content,
-					Sender:    sender,
-					IsDeleted: row.LastMessageIsDeleted,
-					Images:    []string{},
-					CreatedAt: row.LastMessageCreatedAt,
+					ID:          row.LastMessageID,
+					Content:     content,
+					Sender:      sender,
+					IsDeleted:   row.LastMessageIsDeleted,
+					Images:      []models.MessageImage{},
+					MessageType: row.LastMessageType,
+					Metadata:    systemMessageMetadata(row.LastMessageType, row.LastMessageMetadata),
+					CreatedAt:   row.LastMessageCreatedAt,
 				}
 
 				// Collect message ID for fetching images
@@ -268,7 +281,7 @@ func (h *ChatHandler) GetUserChats(c *gin.Context) {
 			ID:              row.MemberID,
 			Username:        row.MemberUsername,
 			Email:           row.MemberEmail,
-			ProfileImageURL: utils.NullableString(row.MemberProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, row.MemberProfileImageUrl),
 		})
 	}
 
@@ -277,9 +290,9 @@ func (h *ChatHandler) GetUserChats(c *gin.Context) {
 		images, err := h.dbService.Queries.GetLastMessageImages(c.Request.Context(), messageIDs)
 		if err == nil {
 			// Group images by message ID
-			imagesByMessage := make(map[uuid.UUID][]string)
+			imagesByMessage := make(map[uuid.UUID][]models.MessageImage)
 			for _, img := range images {
-				imagesByMessage[img.MessageID] = append(imagesByMessage[img.MessageID], img.Url)
+				imagesByMessage[img.MessageID] = append(imagesByMessage[img.MessageID], signMessageImage(c.Request.Context(), h.store, toMessageImage(img)))
 			}
 
 			// Assign images to last messages
@@ -293,6 +306,20 @@ func (h *ChatHandler) GetUserChats(c *gin.Context) {
 		}
 	}
 
+	drafts, err := h.dbService.Queries.GetMessageDraftsByUser(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get drafts",
+		})
+		return
+	}
+
+	for _, draft := range drafts {
+		if chat, ok := chatsMap[draft.ChatID]; ok {
+			chat.Draft = toChatDraft(c.Request.Context(), h.store, draft)
+		}
+	}
+
 	// Convert map to array preserving order
 	result := make([]models.ChatInfo, 0, len(chatOrder))
 	for _, chatID := range chatOrder {
@@ -357,7 +384,7 @@ func (h *ChatHandler) GetChatById(c *gin.Context) {
 			ID:              member.MemberID,
 			Username:        member.MemberUsername,
 			Email:           member.MemberEmail,
-			ProfileImageURL: utils.NullableString(member.MemberProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, member.MemberProfileImageUrl),
 		}
 	}
 
@@ -3,26 +3,30 @@ package routes
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/anmol7470/bubbles/backend/constants"
 	"github.com/anmol7470/bubbles/backend/database"
 	"github.com/anmol7470/bubbles/backend/models"
 	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
 )
 
 type ChatActionsHandler struct {
 	dbService     *database.Service
+	hub           *ws.Hub
 	uploadHandler *UploadHandler
 }
 
-func NewChatActionsHandler(dbService *database.Service, uploadHandler *UploadHandler) *ChatActionsHandler {
+func NewChatActionsHandler(dbService *database.Service, hub *ws.Hub, uploadHandler *UploadHandler) *ChatActionsHandler {
 	return &ChatActionsHandler{
 		dbService:     dbService,
+		hub:           hub,
 		uploadHandler: uploadHandler,
 	}
 }
@@ -225,10 +229,35 @@ func (h *ChatActionsHandler) LeaveChat(c *gin.Context) {
 		return
 	}
 
-	if err := h.dbService.Queries.RemoveChatMember(c.Request.Context(), database.RemoveChatMemberParams{
-		ChatID: chatID,
-		UserID: userID,
-	}); err != nil {
+	username, _ := c.Get("username")
+	actorUsername := username.(string)
+
+	if err := h.recordChatEvent(c.Request.Context(), chatID, userID, actorUsername, constants.SystemEventMemberLeft,
+		fmt.Sprintf("%s left the group", actorUsername), nil,
+		func(qtx *database.Queries) error {
+			if err := qtx.RemoveChatMember(c.Request.Context(), database.RemoveChatMemberParams{
+				ChatID: chatID,
+				UserID: userID,
+			}); err != nil {
+				return err
+			}
+			if err := qtx.DeleteSavedMessagesInChat(c.Request.Context(), database.DeleteSavedMessagesInChatParams{
+				UserID: userID,
+				ChatID: chatID,
+			}); err != nil {
+				return err
+			}
+			if err := qtx.DeleteMessageDraft(c.Request.Context(), database.DeleteMessageDraftParams{
+				UserID: userID,
+				ChatID: chatID,
+			}); err != nil {
+				return err
+			}
+			return qtx.DeleteMessageRemindersInChat(c.Request.Context(), database.DeleteMessageRemindersInChatParams{
+				UserID: userID,
+				ChatID: chatID,
+			})
+		}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to leave chat",
 		})
@@ -295,10 +324,22 @@ func (h *ChatActionsHandler) RenameChat(c *gin.Context) {
 		return
 	}
 
-	if err := h.dbService.Queries.UpdateChatName(c.Request.Context(), database.UpdateChatNameParams{
-		ID:   chatID,
-		Name: sql.NullString{String: name, Valid: true},
-	}); err != nil {
+	username, _ := c.Get("username")
+	actorUsername := username.(string)
+
+	details := map[string]any{
+		"old_name": utils.NullableString(chat.Name),
+		"new_name": name,
+	}
+
+	if err := h.recordChatEvent(c.Request.Context(), chatID, userID, actorUsername, constants.SystemEventChatRenamed,
+		fmt.Sprintf("%s renamed the group to \"%s\"", actorUsername, name), details,
+		func(qtx *database.Queries) error {
+			return qtx.UpdateChatName(c.Request.Context(), database.UpdateChatNameParams{
+				ID:   chatID,
+				Name: sql.NullString{String: name, Valid: true},
+			})
+		}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to rename chat",
 		})
@@ -383,7 +424,8 @@ func (h *ChatActionsHandler) AddChatMember(c *gin.Context) {
 		return
 	}
 
-	if _, err := h.dbService.Queries.GetUserByID(c.Request.Context(), targetID); err != nil {
+	target, err := h.dbService.Queries.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
 				Error: "User not found",
@@ -396,10 +438,22 @@ func (h *ChatActionsHandler) AddChatMember(c *gin.Context) {
 		return
 	}
 
-	if err := h.dbService.Queries.AddChatMember(c.Request.Context(), database.AddChatMemberParams{
-		ChatID: chatID,
-		UserID: targetID,
-	}); err != nil {
+	username, _ := c.Get("username")
+	actorUsername := username.(string)
+
+	details := map[string]any{
+		"target_id":       targetID.String(),
+		"target_username": target.Username,
+	}
+
+	if err := h.recordChatEvent(c.Request.Context(), chatID, userID, actorUsername, constants.SystemEventMemberAdded,
+		fmt.Sprintf("%s added %s", actorUsername, target.Username), details,
+		func(qtx *database.Queries) error {
+			return qtx.AddChatMember(c.Request.Context(), database.AddChatMemberParams{
+				ChatID: chatID,
+				UserID: targetID,
+			})
+		}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to add member",
 		})
@@ -498,10 +552,48 @@ func (h *ChatActionsHandler) RemoveChatMember(c *gin.Context) {
 		return
 	}
 
-	if err := h.dbService.Queries.RemoveChatMember(c.Request.Context(), database.RemoveChatMemberParams{
-		ChatID: chatID,
-		UserID: targetID,
-	}); err != nil {
+	target, err := h.dbService.Queries.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load user",
+		})
+		return
+	}
+
+	username, _ := c.Get("username")
+	actorUsername := username.(string)
+
+	details := map[string]any{
+		"target_id":       targetID.String(),
+		"target_username": target.Username,
+	}
+
+	if err := h.recordChatEvent(c.Request.Context(), chatID, userID, actorUsername, constants.SystemEventMemberRemoved,
+		fmt.Sprintf("%s removed %s", actorUsername, target.Username), details,
+		func(qtx *database.Queries) error {
+			if err := qtx.RemoveChatMember(c.Request.Context(), database.RemoveChatMemberParams{
+				ChatID: chatID,
+				UserID: targetID,
+			}); err != nil {
+				return err
+			}
+			if err := qtx.DeleteSavedMessagesInChat(c.Request.Context(), database.DeleteSavedMessagesInChatParams{
+				UserID: targetID,
+				ChatID: chatID,
+			}); err != nil {
+				return err
+			}
+			if err := qtx.DeleteMessageDraft(c.Request.Context(), database.DeleteMessageDraftParams{
+				UserID: targetID,
+				ChatID: chatID,
+			}); err != nil {
+				return err
+			}
+			return qtx.DeleteMessageRemindersInChat(c.Request.Context(), database.DeleteMessageRemindersInChatParams{
+				UserID: targetID,
+				ChatID: chatID,
+			})
+		}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to remove member",
 		})
@@ -593,10 +685,30 @@ func (h *ChatActionsHandler) ChangeChatAdmin(c *gin.Context) {
 		return
 	}
 
-	if err := h.dbService.Queries.UpdateChatCreator(c.Request.Context(), database.UpdateChatCreatorParams{
-		ID:        chatID,
-		CreatedBy: targetID,
-	}); err != nil {
+	target, err := h.dbService.Queries.GetUserByID(c.Request.Context(), targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load user",
+		})
+		return
+	}
+
+	username, _ := c.Get("username")
+	actorUsername := username.(string)
+
+	details := map[string]any{
+		"target_id":       targetID.String(),
+		"target_username": target.Username,
+	}
+
+	if err := h.recordChatEvent(c.Request.Context(), chatID, userID, actorUsername, constants.SystemEventAdminChanged,
+		fmt.Sprintf("%s made %s the admin", actorUsername, target.Username), details,
+		func(qtx *database.Queries) error {
+			return qtx.UpdateChatCreator(c.Request.Context(), database.UpdateChatCreatorParams{
+				ID:        chatID,
+				CreatedBy: targetID,
+			})
+		}); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to change admin",
 		})
@@ -608,25 +720,58 @@ func (h *ChatActionsHandler) ChangeChatAdmin(c *gin.Context) {
 	})
 }
 
-func (h *ChatActionsHandler) deleteChatWithAssets(ctx context.Context, chatID uuid.UUID) error {
-	if h.uploadHandler == nil {
-		return errors.New("file storage is not configured")
+// recordChatEvent applies a chat change and its system message in one transaction, then broadcasts the message
+func (h *ChatActionsHandler) recordChatEvent(ctx context.Context, chatID, actorID uuid.UUID, actorUsername, event, content string, details map[string]any, apply func(qtx *database.Queries) error) error {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	if err := apply(qtx); err != nil {
+		return err
 	}
 
+	message, err := createSystemMessage(ctx, qtx, chatID, actorID, event, content, details)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	broadcastSystemMessage(h.hub, message, actorUsername)
+	return nil
+}
+
+func (h *ChatActionsHandler) deleteChatWithAssets(ctx context.Context, chatID uuid.UUID) error {
 	imageURLs, err := h.dbService.Queries.GetChatImageUrls(ctx, chatID)
 	if err != nil {
 		return err
 	}
 
+	attachments, err := h.dbService.Queries.GetChatAttachments(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
 	if err := h.dbService.Queries.DeleteChat(ctx, chatID); err != nil {
 		return err
 	}
 
+	imageURLs, err = unreferencedImageURLs(ctx, h.dbService.Queries, imageURLs)
+	if err != nil {
+		return err
+	}
+
 	for _, imageURL := range imageURLs {
 		if err := h.uploadHandler.DeleteImage(imageURL); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return h.uploadHandler.DeleteAttachmentFiles(ctx, attachments)
 }
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func TestChatLifecycleEventsAreRecorded(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), nil)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	dave := createTestUser(t, db, "dave")
+	chatID := createTestChat(t, db, alice, bob, carol)
+
+	gin.SetMode(gin.TestMode)
+	act := func(userID uuid.UUID, username string, handle func(*gin.Context), body string) {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Set("username", username)
+		c.Params = gin.Params{{Key: "id", Value: chatID.String()}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/chats/"+chatID.String(), strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handle(c)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+		}
+	}
+
+	act(alice, "alice", handler.RenameChat, `{"name": "Weekend"}`)
+	act(alice, "alice", handler.AddChatMember, `{"user_id": "`+dave.String()+`"}`)
+	act(alice, "alice", handler.RemoveChatMember, `{"user_id": "`+bob.String()+`"}`)
+	act(carol, "carol", handler.LeaveChat, ``)
+	act(alice, "alice", handler.ChangeChatAdmin, `{"user_id": "`+dave.String()+`"}`)
+
+	rows, err := db.Query(
+		`SELECT sender_id, content, metadata FROM messages WHERE chat_id = $1 AND message_type = 'system' ORDER BY created_at, id`,
+		chatID,
+	)
+	if err != nil {
+		t.Fatalf("get system messages: %v", err)
+	}
+	defer rows.Close()
+
+	type event struct {
+		actor   uuid.UUID
+		name    string
+		content string
+	}
+	var got []event
+	for rows.Next() {
+		var (
+			e        event
+			metadata []byte
+		)
+		if err := rows.Scan(&e.actor, &e.content, &metadata); err != nil {
+			t.Fatalf("scan system message: %v", err)
+		}
+		var decoded struct {
+			Event   string `json:"event"`
+			ActorID string `json:"actor_id"`
+		}
+		if err := json.Unmarshal(metadata, &decoded); err != nil {
+			t.Fatalf("decode metadata: %v", err)
+		}
+		if decoded.ActorID != e.actor.String() {
+			t.Errorf("metadata actor = %s, want the sender %s", decoded.ActorID, e.actor)
+		}
+		e.name = decoded.Event
+		got = append(got, e)
+	}
+
+	want := []event{
+		{actor: alice, name: "chat_renamed", content: `alice renamed the group to "Weekend"`},
+		{actor: alice, name: "member_added", content: "alice added dave"},
+		{actor: alice, name: "member_removed", content: "alice removed bob"},
+		{actor: carol, name: "member_left", content: "carol left the group"},
+		{actor: alice, name: "admin_changed", content: "alice made dave the admin"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d system messages %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("system message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
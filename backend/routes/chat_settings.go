@@ -0,0 +1,324 @@
+package routes
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+func (h *ChatActionsHandler) GetChatSettings(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	chatID, ok := utils.ParseChatIDParam(c)
+	if !ok {
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: chatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toChatSettingsResponse(settings))
+}
+
+func (h *ChatActionsHandler) UpdateChatSettings(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	chatID, ok := utils.ParseChatIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateChatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	chat, err := h.dbService.Queries.GetChatMetadata(c.Request.Context(), chatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Chat not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat",
+		})
+		return
+	}
+
+	if chat.IsGroup {
+		if chat.CreatedBy != userID {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Only the chat creator can change chat settings",
+			})
+			return
+		}
+	} else {
+		// Either participant of a direct message chat may toggle disappearing messages, nothing else
+		if req.EditWindowSeconds != nil || req.AllowDeletes != nil || req.MaxImages != nil || req.MaxMessageLength != nil || req.WhoCanPost != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Direct message chats only support the disappearing messages timer",
+			})
+			return
+		}
+
+		isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+			ChatID: chatID,
+			UserID: userID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to verify chat membership",
+			})
+			return
+		}
+
+		if !isMember {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "You are not a member of this chat",
+			})
+			return
+		}
+	}
+
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	// Only overwrite the fields that were provided
+	params := database.UpdateChatSettingsParams{
+		ChatID:            chatID,
+		EditWindowSeconds: settings.EditWindowSeconds,
+		AllowDeletes:      settings.AllowDeletes,
+		MaxImages:         settings.MaxImages,
+		MaxMessageLength:  settings.MaxMessageLength,
+		WhoCanPost:        settings.WhoCanPost,
+		MessageTtlSeconds: settings.MessageTtlSeconds,
+	}
+
+	if req.EditWindowSeconds != nil {
+		if *req.EditWindowSeconds < 0 || *req.EditWindowSeconds > constants.MaxEditWindowSeconds {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Edit window must be between 0 and %d seconds", constants.MaxEditWindowSeconds),
+			})
+			return
+		}
+		params.EditWindowSeconds = *req.EditWindowSeconds
+	}
+
+	if req.AllowDeletes != nil {
+		params.AllowDeletes = *req.AllowDeletes
+	}
+
+	if req.MaxImages != nil {
+		if *req.MaxImages < 0 || *req.MaxImages > constants.MaxImagesPerMessage {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Max images must be between 0 and %d", constants.MaxImagesPerMessage),
+			})
+			return
+		}
+		params.MaxImages = *req.MaxImages
+	}
+
+	if req.MaxMessageLength != nil {
+		if *req.MaxMessageLength < 1 || *req.MaxMessageLength > constants.MaxMessageLength {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Max message length must be between 1 and %d characters", constants.MaxMessageLength),
+			})
+			return
+		}
+		params.MaxMessageLength = *req.MaxMessageLength
+	}
+
+	if req.WhoCanPost != nil {
+		if *req.WhoCanPost != constants.WhoCanPostEveryone && *req.WhoCanPost != constants.WhoCanPostAdmin {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "who_can_post must be either \"everyone\" or \"admin\"",
+			})
+			return
+		}
+		params.WhoCanPost = *req.WhoCanPost
+	}
+
+	if req.MessageTTLSeconds != nil {
+		if *req.MessageTTLSeconds < 0 || *req.MessageTTLSeconds > constants.MaxMessageTTLSeconds {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Disappearing messages timer must be between 0 and %d seconds", constants.MaxMessageTTLSeconds),
+			})
+			return
+		}
+		params.MessageTtlSeconds = *req.MessageTTLSeconds
+	}
+
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	updated, err := qtx.UpdateChatSettings(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update chat settings",
+		})
+		return
+	}
+
+	username, _ := c.Get("username")
+
+	var systemMessages []database.Message
+
+	// Policy changes and the disappearing messages timer are announced separately in the chat
+	var changed []string
+	if updated.EditWindowSeconds != settings.EditWindowSeconds {
+		changed = append(changed, "edit_window_seconds")
+	}
+	if updated.AllowDeletes != settings.AllowDeletes {
+		changed = append(changed, "allow_deletes")
+	}
+	if updated.MaxImages != settings.MaxImages {
+		changed = append(changed, "max_images")
+	}
+	if updated.MaxMessageLength != settings.MaxMessageLength {
+		changed = append(changed, "max_message_length")
+	}
+	if updated.WhoCanPost != settings.WhoCanPost {
+		changed = append(changed, "who_can_post")
+	}
+
+	if len(changed) > 0 {
+		message, err := createSystemMessage(c.Request.Context(), qtx, chatID, userID, constants.SystemEventSettingsUpdated,
+			fmt.Sprintf("%s updated the chat settings", username.(string)), map[string]any{
+				"changed": changed,
+			})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to create system message",
+			})
+			return
+		}
+		systemMessages = append(systemMessages, message)
+	}
+
+	if updated.MessageTtlSeconds != settings.MessageTtlSeconds {
+		content := fmt.Sprintf("%s turned off disappearing messages", username.(string))
+		if updated.MessageTtlSeconds > 0 {
+			content = fmt.Sprintf("%s set disappearing messages to %s", username.(string), formatDuration(updated.MessageTtlSeconds))
+		}
+
+		message, err := createSystemMessage(c.Request.Context(), qtx, chatID, userID, constants.SystemEventMessageTTLChanged, content, map[string]any{
+			"ttl_seconds": updated.MessageTtlSeconds,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to create system message",
+			})
+			return
+		}
+		systemMessages = append(systemMessages, message)
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	for _, message := range systemMessages {
+		broadcastSystemMessage(h.hub, message, username.(string))
+	}
+
+	c.JSON(http.StatusOK, toChatSettingsResponse(updated))
+}
+
+func toChatSettingsResponse(settings database.ChatSetting) models.ChatSettings {
+	return models.ChatSettings{
+		EditWindowSeconds: settings.EditWindowSeconds,
+		AllowDeletes:      settings.AllowDeletes,
+		MaxImages:         settings.MaxImages,
+		MaxMessageLength:  settings.MaxMessageLength,
+		WhoCanPost:        settings.WhoCanPost,
+		MessageTTLSeconds: settings.MessageTtlSeconds,
+		UpdatedAt:         settings.UpdatedAt,
+	}
+}
+
+// canPostInChat reports whether the user is allowed to post under the chat's posting policy
+func canPostInChat(settings database.ChatSetting, chatCreatorID, userID uuid.UUID) bool {
+	if settings.WhoCanPost == constants.WhoCanPostAdmin {
+		return chatCreatorID == userID
+	}
+	return true
+}
+
+// formatDuration renders a number of seconds as a human readable duration (e.g. "15 minutes")
+func formatDuration(seconds int32) string {
+	window := time.Duration(seconds) * time.Second
+
+	value, unit := int64(seconds), "second"
+	switch {
+	case window%(24*time.Hour) == 0:
+		value, unit = int64(window/(24*time.Hour)), "day"
+	case window%time.Hour == 0:
+		value, unit = int64(window/time.Hour), "hour"
+	case window%time.Minute == 0:
+		value, unit = int64(window/time.Minute), "minute"
+	}
+
+	if value != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
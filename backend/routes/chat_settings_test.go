@@ -0,0 +1,161 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		seconds int32
+		want    string
+	}{
+		{seconds: 1, want: "1 second"},
+		{seconds: 90, want: "90 seconds"},
+		{seconds: 900, want: "15 minutes"},
+		{seconds: 3600, want: "1 hour"},
+		{seconds: 2 * 24 * 60 * 60, want: "2 days"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.seconds); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateChatSettings(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), nil)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	groupID := createTestChat(t, db, alice, bob, carol)
+	directID := createTestChat(t, db, alice, bob)
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		chatID     uuid.UUID
+		body       string
+		wantStatus int
+	}{
+		{name: "creator", userID: alice, chatID: groupID, body: `{"who_can_post": "admin", "edit_window_seconds": 60}`, wantStatus: http.StatusOK},
+		{name: "other member", userID: bob, chatID: groupID, body: `{"allow_deletes": false}`, wantStatus: http.StatusForbidden},
+		{name: "direct message", userID: alice, chatID: directID, body: `{"allow_deletes": false}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown posting policy", userID: alice, chatID: groupID, body: `{"who_can_post": "members"}`, wantStatus: http.StatusBadRequest},
+		{name: "edit window too long", userID: alice, chatID: groupID, body: `{"edit_window_seconds": 999999999}`, wantStatus: http.StatusBadRequest},
+		{name: "no images allowed", userID: alice, chatID: groupID, body: `{"max_images": 0}`, wantStatus: http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", tt.userID)
+			c.Set("username", "tester")
+			c.Params = gin.Params{{Key: "id", Value: tt.chatID.String()}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/chats/"+tt.chatID.String()+"/settings", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.UpdateChatSettings(c)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+
+	// Fields left out of a request keep the values earlier updates set
+	settings, err := dbService.Queries.GetChatSettings(context.Background(), groupID)
+	if err != nil {
+		t.Fatalf("get chat settings: %v", err)
+	}
+	if settings.WhoCanPost != "admin" || settings.EditWindowSeconds != 60 || settings.MaxImages != 0 || !settings.AllowDeletes {
+		t.Errorf("chat settings = %+v, want admin posting, a 60 second edit window and no images", settings)
+	}
+}
+
+func TestUpdateChatSettingsAnnouncesMessageTimer(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), nil)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	update := func(body string) int {
+		t.Helper()
+
+		gin.SetMode(gin.TestMode)
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", bob)
+		c.Set("username", "bob")
+		c.Params = gin.Params{{Key: "id", Value: chatID.String()}}
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/chats/"+chatID.String()+"/settings", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.UpdateChatSettings(c)
+		return recorder.Code
+	}
+
+	systemMessages := func() []string {
+		t.Helper()
+
+		rows, err := db.Query(`SELECT content FROM messages WHERE chat_id = $1 AND message_type = 'system' ORDER BY created_at`, chatID)
+		if err != nil {
+			t.Fatalf("get system messages: %v", err)
+		}
+		defer rows.Close()
+
+		var contents []string
+		for rows.Next() {
+			var content string
+			if err := rows.Scan(&content); err != nil {
+				t.Fatalf("scan system message: %v", err)
+			}
+			contents = append(contents, content)
+		}
+		return contents
+	}
+
+	// Either participant of a direct message chat may set the timer
+	if status := update(`{"message_ttl_seconds": 3600}`); status != http.StatusOK {
+		t.Fatalf("UpdateChatSettings() status = %d, want %d", status, http.StatusOK)
+	}
+	// Setting the same timer again is not announced twice
+	if status := update(`{"message_ttl_seconds": 3600}`); status != http.StatusOK {
+		t.Fatalf("UpdateChatSettings() status = %d, want %d", status, http.StatusOK)
+	}
+	if status := update(`{"message_ttl_seconds": 0}`); status != http.StatusOK {
+		t.Fatalf("UpdateChatSettings() status = %d, want %d", status, http.StatusOK)
+	}
+
+	want := []string{"bob set disappearing messages to 1 hour", "bob turned off disappearing messages"}
+	if got := systemMessages(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("system messages = %q, want %q", got, want)
+	}
+
+	settings, err := queries.GetChatSettings(ctx, chatID)
+	if err != nil {
+		t.Fatalf("get chat settings: %v", err)
+	}
+	if settings.MessageTtlSeconds != 0 {
+		t.Errorf("message TTL = %d, want 0", settings.MessageTtlSeconds)
+	}
+}
@@ -0,0 +1,272 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func (h *ChatActionsHandler) GetChatDraft(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	chatID, ok := utils.ParseChatIDParam(c)
+	if !ok {
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: chatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	draft, err := h.dbService.Queries.GetMessageDraft(c.Request.Context(), database.GetMessageDraftParams{
+		UserID: userID,
+		ChatID: chatID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, models.GetChatDraftResponse{})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load draft",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GetChatDraftResponse{
+		Draft: toChatDraft(c.Request.Context(), h.uploadHandler.store, draft),
+	})
+}
+
+// SetChatDraft replaces the user's draft for a chat, or clears it when the draft is empty.
+// The change is pushed to all of the user's sessions so other devices pick it up.
+func (h *ChatActionsHandler) SetChatDraft(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	chatID, ok := utils.ParseChatIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SetChatDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: chatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	// Drafts are held to the same chat limits as the message they will become
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	if len(req.Content) > int(settings.MaxMessageLength) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Draft content exceeds maximum length of %d characters", settings.MaxMessageLength),
+		})
+		return
+	}
+
+	if len(req.Images) > int(settings.MaxImages) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d images allowed per draft", settings.MaxImages),
+		})
+		return
+	}
+
+	// Clients of a private store send back the signed URLs they were given
+	req.Images = storedImageURLs(h.uploadHandler.store, req.Images)
+
+	// Drafted images keep their uploads from being swept, so only the user's own unsent uploads
+	// can be kept in a draft. They are checked again when the message is sent.
+	available, err := uploadsAvailable(c.Request.Context(), h.dbService.Queries, userID, req.Images)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify images",
+		})
+		return
+	}
+
+	if !available {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Images must be your own uploads and can only be sent once",
+		})
+		return
+	}
+
+	var replyTo uuid.NullUUID
+	if req.ReplyToMessageID != nil && strings.TrimSpace(*req.ReplyToMessageID) != "" {
+		replyID, err := uuid.Parse(strings.TrimSpace(*req.ReplyToMessageID))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid reply message ID",
+			})
+			return
+		}
+
+		replyMessage, err := h.dbService.Queries.GetMessageById(c.Request.Context(), replyID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error: "Reply target message not found",
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load reply target message",
+			})
+			return
+		}
+
+		if replyMessage.ChatID != chatID {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Reply target must be in the same chat",
+			})
+			return
+		}
+
+		if replyMessage.IsDeleted {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Cannot reply to a deleted message",
+			})
+			return
+		}
+
+		replyTo = uuid.NullUUID{UUID: replyID, Valid: true}
+	}
+
+	// An empty draft is the same as no draft
+	if strings.TrimSpace(req.Content) == "" && len(req.Images) == 0 && !replyTo.Valid {
+		if err := h.dbService.Queries.DeleteMessageDraft(c.Request.Context(), database.DeleteMessageDraftParams{
+			UserID: userID,
+			ChatID: chatID,
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to clear draft",
+			})
+			return
+		}
+
+		h.broadcastDraftUpdated(userID, chatID, nil)
+
+		c.JSON(http.StatusOK, models.GetChatDraftResponse{})
+		return
+	}
+
+	images := req.Images
+	if images == nil {
+		images = []string{}
+	}
+
+	draft, err := h.dbService.Queries.UpsertMessageDraft(c.Request.Context(), database.UpsertMessageDraftParams{
+		UserID:           userID,
+		ChatID:           chatID,
+		Content:          sql.NullString{String: req.Content, Valid: req.Content != ""},
+		Images:           images,
+		ReplyToMessageID: replyTo,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to save draft",
+		})
+		return
+	}
+
+	response := toChatDraft(c.Request.Context(), h.uploadHandler.store, draft)
+	h.broadcastDraftUpdated(userID, chatID, response)
+
+	c.JSON(http.StatusOK, models.GetChatDraftResponse{
+		Draft: response,
+	})
+}
+
+func (h *ChatActionsHandler) broadcastDraftUpdated(userID, chatID uuid.UUID, draft *models.ChatDraft) {
+	payload := ws.DraftUpdatedPayload{
+		ChatID: chatID.String(),
+	}
+
+	if draft != nil {
+		payload.Draft = &ws.DraftPayload{
+			Content:   draft.Content,
+			Images:    draft.Images,
+			UpdatedAt: draft.UpdatedAt,
+		}
+		if draft.ReplyToMessageID != nil {
+			replyTo := draft.ReplyToMessageID.String()
+			payload.Draft.ReplyToMessageID = &replyTo
+		}
+	}
+
+	h.hub.SendToUser(userID.String(), ws.WSMessage{
+		Type:    ws.EventDraftUpdated,
+		Payload: payload,
+	})
+}
+
+func toChatDraft(ctx context.Context, store storage.Storage, draft database.MessageDraft) *models.ChatDraft {
+	var replyTo *uuid.UUID
+	if draft.ReplyToMessageID.Valid {
+		replyTo = &draft.ReplyToMessageID.UUID
+	}
+
+	return &models.ChatDraft{
+		Content:          utils.NullableString(draft.Content),
+		Images:           signImageURLs(ctx, store, draft.Images),
+		ReplyToMessageID: replyTo,
+		UpdatedAt:        draft.UpdatedAt,
+	}
+}
@@ -0,0 +1,223 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func TestChatDraftRoundTrip(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), nil)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob)
+	otherChatID := createTestChat(t, db, alice, carol)
+	replyID := createTestMessage(t, db, chatID, bob, "lunch?", time.Now(), time.Time{})
+	otherReplyID := createTestMessage(t, db, otherChatID, carol, "hello", time.Now(), time.Time{})
+
+	gin.SetMode(gin.TestMode)
+	call := func(userID uuid.UUID, method string, request any, handle func(*gin.Context)) (int, models.GetChatDraftResponse) {
+		t.Helper()
+
+		var body string
+		if request != nil {
+			encoded, err := json.Marshal(request)
+			if err != nil {
+				t.Fatalf("encode request: %v", err)
+			}
+			body = string(encoded)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Params = gin.Params{{Key: "id", Value: chatID.String()}}
+		c.Request = httptest.NewRequest(method, "/api/chats/"+chatID.String()+"/draft", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handle(c)
+
+		var response models.GetChatDraftResponse
+		if recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+		}
+		return recorder.Code, response
+	}
+
+	reply := replyID.String()
+	otherReply := otherReplyID.String()
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		request    models.SetChatDraftRequest
+		wantStatus int
+	}{
+		{name: "content and reply", userID: alice, request: models.SetChatDraftRequest{Content: "sure", ReplyToMessageID: &reply}, wantStatus: http.StatusOK},
+		{name: "reply in another chat", userID: alice, request: models.SetChatDraftRequest{Content: "sure", ReplyToMessageID: &otherReply}, wantStatus: http.StatusBadRequest},
+		{name: "content too long", userID: alice, request: models.SetChatDraftRequest{Content: strings.Repeat("a", constants.MaxMessageLength+1)}, wantStatus: http.StatusBadRequest},
+		{name: "not a member", userID: carol, request: models.SetChatDraftRequest{Content: "hi"}, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := call(tt.userID, http.MethodPost, tt.request, handler.SetChatDraft); status != tt.wantStatus {
+				t.Errorf("SetChatDraft() status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	// Rejected updates leave the saved draft alone
+	status, response := call(alice, http.MethodGet, nil, handler.GetChatDraft)
+	if status != http.StatusOK {
+		t.Fatalf("GetChatDraft() status = %d, want %d", status, http.StatusOK)
+	}
+	draft := response.Draft
+	if draft == nil || draft.Content == nil || *draft.Content != "sure" || draft.ReplyToMessageID == nil || *draft.ReplyToMessageID != replyID {
+		t.Fatalf("draft = %+v, want the content and reply alice saved", draft)
+	}
+
+	// Drafts are per user
+	if _, response := call(bob, http.MethodGet, nil, handler.GetChatDraft); response.Draft != nil {
+		t.Errorf("bob's draft = %+v, want none", response.Draft)
+	}
+
+	// An empty draft clears the saved one
+	if status, _ := call(alice, http.MethodPost, models.SetChatDraftRequest{Content: "  "}, handler.SetChatDraft); status != http.StatusOK {
+		t.Fatalf("SetChatDraft() status = %d, want %d", status, http.StatusOK)
+	}
+	if _, response := call(alice, http.MethodGet, nil, handler.GetChatDraft); response.Draft != nil {
+		t.Errorf("draft after clearing = %+v, want none", response.Draft)
+	}
+}
+
+func TestSetChatDraftImages(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	_, uploadHandler := newTestHandlers(t, dbService)
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), uploadHandler)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	uploadImage := func(owner uuid.UUID) string {
+		upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+			OwnerID:     owner,
+			Key:         fmt.Sprintf("%s/1/%s.jpg", owner, uuid.NewString()),
+			Url:         fmt.Sprintf("http://localhost/files/%s/1/%s.jpg", owner, uuid.NewString()),
+			Size:        1000,
+			ContentType: "image/jpeg",
+			Variants:    json.RawMessage("[]"),
+		})
+		if err != nil {
+			t.Fatalf("create upload: %v", err)
+		}
+		return upload.Url
+	}
+
+	own := uploadImage(alice)
+	others := uploadImage(bob)
+
+	tests := []struct {
+		name       string
+		images     []string
+		wantStatus int
+	}{
+		{name: "own upload", images: []string{own}, wantStatus: http.StatusOK},
+		{name: "someone else's upload", images: []string{others}, wantStatus: http.StatusBadRequest},
+		{name: "not an upload", images: []string{"https://example.com/cat.jpg"}, wantStatus: http.StatusBadRequest},
+		{name: "mixed", images: []string{own, others}, wantStatus: http.StatusBadRequest},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{"content": "draft", "images": tt.images})
+			if err != nil {
+				t.Fatalf("encode request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", alice)
+			c.Params = gin.Params{{Key: "id", Value: chatID.String()}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/chats/"+chatID.String()+"/draft", strings.NewReader(string(body)))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.SetChatDraft(c)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestSetChatDraftFollowsChatMessageLength(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+
+	_, uploadHandler := newTestHandlers(t, dbService)
+	handler := NewChatActionsHandler(dbService, ws.NewHub(dbService), uploadHandler)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	if _, err := db.Exec(`UPDATE chat_settings SET max_message_length = 10 WHERE chat_id = $1`, chatID); err != nil {
+		t.Fatalf("update chat settings: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus int
+	}{
+		{name: "within the limit", content: "see you", wantStatus: http.StatusOK},
+		{name: "over the limit", content: "see you tomorrow", wantStatus: http.StatusBadRequest},
+		// Counted in bytes like message content, so these five characters take fifteen
+		{name: "multibyte over the limit", content: "日本語です", wantStatus: http.StatusBadRequest},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{"content": tt.content})
+			if err != nil {
+				t.Fatalf("encode request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", alice)
+			c.Params = gin.Params{{Key: "id", Value: chatID.String()}}
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/chats/"+chatID.String()+"/draft", strings.NewReader(string(body)))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.SetChatDraft(c)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/models"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// Entity types that carry no attributes, so overlapping ranges of the same type can be merged
+var mergeableEntityTypes = map[string]bool{
+	constants.EntityTypeBold:    true,
+	constants.EntityTypeItalic:  true,
+	constants.EntityTypeCode:    true,
+	constants.EntityTypeSpoiler: true,
+}
+
+// prepareMessageContent trims the content and validates its formatting entities against it.
+// Offsets count UTF-16 code units, as JavaScript string indexes do, and may not split a surrogate pair.
+// Entities are shifted to match the trimmed content, overlapping ranges of the same style are merged
+// and the result is sorted. Mention entities are dropped here since they are resolved by the server.
+func prepareMessageContent(content string, entities []models.MessageEntity) (string, []models.MessageEntity, error) {
+	if len(entities) > constants.MaxEntitiesPerMessage {
+		return "", nil, fmt.Errorf("Maximum %d entities allowed per message", constants.MaxEntitiesPerMessage)
+	}
+
+	contentLength := utf16Length(content)
+	boundaries := utf16Boundaries(content)
+	valid := make([]models.MessageEntity, 0, len(entities))
+
+	for _, entity := range entities {
+		if entity.Type == constants.EntityTypeMention {
+			continue
+		}
+
+		if entity.Offset < 0 || entity.Length <= 0 || entity.Offset+entity.Length > contentLength {
+			return "", nil, fmt.Errorf("Entity range %d+%d is outside the message content", entity.Offset, entity.Length)
+		}
+		if !boundaries[entity.Offset] || !boundaries[entity.Offset+entity.Length] {
+			return "", nil, fmt.Errorf("Entity range %d+%d splits a character", entity.Offset, entity.Length)
+		}
+
+		normalized := models.MessageEntity{
+			Type:   entity.Type,
+			Offset: entity.Offset,
+			Length: entity.Length,
+		}
+
+		switch entity.Type {
+		case constants.EntityTypeBold, constants.EntityTypeItalic, constants.EntityTypeCode, constants.EntityTypeSpoiler:
+		case constants.EntityTypeLink:
+			if err := validateEntityURL(entity.URL); err != nil {
+				return "", nil, err
+			}
+			normalized.URL = entity.URL
+		case constants.EntityTypePre:
+			language := strings.TrimSpace(entity.Language)
+			if len(language) > constants.MaxEntityLanguageLength {
+				return "", nil, fmt.Errorf("Code block language must be at most %d characters", constants.MaxEntityLanguageLength)
+			}
+			normalized.Language = language
+		default:
+			return "", nil, fmt.Errorf("Unknown entity type: %s", entity.Type)
+		}
+
+		valid = append(valid, normalized)
+	}
+
+	trimmedLeft := strings.TrimLeftFunc(content, unicode.IsSpace)
+	leading := contentLength - utf16Length(trimmedLeft)
+	trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	trimmedLength := utf16Length(trimmed)
+
+	// Clip the ranges to the trimmed content, dropping any that only covered whitespace
+	clipped := valid[:0]
+	for _, entity := range valid {
+		start := max(entity.Offset-leading, 0)
+		end := min(entity.Offset+entity.Length-leading, trimmedLength)
+		if end <= start {
+			continue
+		}
+
+		entity.Offset = start
+		entity.Length = end - start
+		clipped = append(clipped, entity)
+	}
+
+	normalized := mergeEntities(clipped)
+
+	if err := validateEntityNesting(normalized); err != nil {
+		return "", nil, err
+	}
+
+	return trimmed, normalized, nil
+}
+
+// utf16Length counts the UTF-16 code units in s
+func utf16Length(s string) int32 {
+	var n int32
+	for _, r := range s {
+		n += int32(utf16.RuneLen(r))
+	}
+	return n
+}
+
+// utf16Boundaries reports for each UTF-16 offset into s, up to and including its length,
+// whether it falls between characters rather than inside a surrogate pair
+func utf16Boundaries(s string) []bool {
+	boundaries := make([]bool, 0, len(s)+1)
+	for _, r := range s {
+		boundaries = append(boundaries, true)
+		if utf16.RuneLen(r) == 2 {
+			boundaries = append(boundaries, false)
+		}
+	}
+	return append(boundaries, true)
+}
+
+func validateEntityURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("Link entities require a url")
+	}
+
+	if len(rawURL) > constants.MaxEntityURLLength {
+		return fmt.Errorf("Link url must be at most %d characters", constants.MaxEntityURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("Link url must be an absolute http or https url")
+	}
+
+	return nil
+}
+
+// mergeEntities sorts the entities and merges overlapping or adjacent ranges of the same style
+func mergeEntities(entities []models.MessageEntity) []models.MessageEntity {
+	sortEntities(entities)
+
+	merged := make([]models.MessageEntity, 0, len(entities))
+	for _, entity := range entities {
+		joined := false
+		if mergeableEntityTypes[entity.Type] {
+			for i := range merged {
+				prev := &merged[i]
+				if prev.Type != entity.Type || entity.Offset > prev.Offset+prev.Length {
+					continue
+				}
+
+				prev.Length = max(prev.Offset+prev.Length, entity.Offset+entity.Length) - prev.Offset
+				joined = true
+				break
+			}
+		}
+
+		if !joined {
+			merged = append(merged, entity)
+		}
+	}
+
+	sortEntities(merged)
+	return merged
+}
+
+// validateEntityNesting requires entities to be nested rather than partially overlapping,
+// and keeps code and pre ranges free of other formatting since they are rendered verbatim
+func validateEntityNesting(entities []models.MessageEntity) error {
+	for i, outer := range entities {
+		outerEnd := outer.Offset + outer.Length
+
+		for _, inner := range entities[i+1:] {
+			if inner.Offset >= outerEnd {
+				break
+			}
+
+			if inner.Offset+inner.Length > outerEnd {
+				return errors.New("Entities must not partially overlap")
+			}
+
+			if isVerbatimEntity(outer.Type) || isVerbatimEntity(inner.Type) {
+				return errors.New("Code and pre entities cannot contain other formatting")
+			}
+
+			if outer.Type == inner.Type {
+				return fmt.Errorf("Overlapping %s entities are not allowed", outer.Type)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isVerbatimEntity(entityType string) bool {
+	return entityType == constants.EntityTypeCode || entityType == constants.EntityTypePre
+}
+
+// sortEntities orders by offset with enclosing ranges first, so clients can render them as a tree
+func sortEntities(entities []models.MessageEntity) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		if entities[i].Length != entities[j].Length {
+			return entities[i].Length > entities[j].Length
+		}
+		return entities[i].Type < entities[j].Type
+	})
+}
+
+// withMentionEntities adds the resolved mentions to the formatting entities. Mentions that would not
+// nest cleanly, such as an @username inside a code span, are left as plain text, so the mentions that
+// were kept are returned as well.
+func withMentionEntities(entities []models.MessageEntity, mentions []models.MessageMention) ([]models.MessageEntity, []models.MessageMention) {
+	if len(mentions) == 0 {
+		return entities, nil
+	}
+
+	combined := make([]models.MessageEntity, 0, len(entities)+len(mentions))
+	combined = append(combined, entities...)
+	kept := make([]models.MessageMention, 0, len(mentions))
+	for _, mention := range mentions {
+		userID := mention.UserID
+		candidate := append(slices.Clone(combined), models.MessageEntity{
+			Type:     constants.EntityTypeMention,
+			Offset:   mention.Offset,
+			Length:   mention.Length,
+			UserID:   &userID,
+			Username: mention.Username,
+		})
+		sortEntities(candidate)
+
+		if validateEntityNesting(candidate) != nil {
+			continue
+		}
+		combined = candidate
+		kept = append(kept, mention)
+	}
+
+	return combined, kept
+}
+
+// withoutMentionEntities drops the mention entities, which only hold for the members of the chat
+// they were resolved in
+func withoutMentionEntities(entities []models.MessageEntity) []models.MessageEntity {
+	kept := make([]models.MessageEntity, 0, len(entities))
+	for _, entity := range entities {
+		if entity.Type != constants.EntityTypeMention {
+			kept = append(kept, entity)
+		}
+	}
+	return kept
+}
+
+func encodeEntities(entities []models.MessageEntity) (json.RawMessage, error) {
+	if len(entities) == 0 {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(entities)
+}
+
+// decodeEntities reads stored entities, which are always written by encodeEntities
+func decodeEntities(raw json.RawMessage) []models.MessageEntity {
+	var entities []models.MessageEntity
+	if err := json.Unmarshal(raw, &entities); err != nil || len(entities) == 0 {
+		return nil
+	}
+	return entities
+}
+
+func toEntityPayloads(entities []models.MessageEntity) []ws.MessageEntity {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	payloads := make([]ws.MessageEntity, len(entities))
+	for i, entity := range entities {
+		payloads[i] = ws.MessageEntity{
+			Type:     entity.Type,
+			Offset:   entity.Offset,
+			Length:   entity.Length,
+			URL:      entity.URL,
+			Language: entity.Language,
+			Username: entity.Username,
+		}
+		if entity.UserID != nil {
+			userID := entity.UserID.String()
+			payloads[i].UserID = &userID
+		}
+	}
+	return payloads
+}
@@ -0,0 +1,268 @@
+package routes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func entity(entityType string, offset, length int32) models.MessageEntity {
+	return models.MessageEntity{Type: entityType, Offset: offset, Length: length}
+}
+
+func TestPrepareMessageContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		entities     []models.MessageEntity
+		wantContent  string
+		wantEntities []models.MessageEntity
+		wantErr      bool
+	}{
+		{
+			name:         "plain ranges",
+			content:      "hello world",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeItalic, 6, 5), entity(constants.EntityTypeBold, 0, 5)},
+			wantContent:  "hello world",
+			wantEntities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 5), entity(constants.EntityTypeItalic, 6, 5)},
+		},
+		{
+			name:         "shifted past trimmed whitespace",
+			content:      "  hello  ",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 0, 7)},
+			wantContent:  "hello",
+			wantEntities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 5)},
+		},
+		{
+			name:         "whitespace only range dropped",
+			content:      "hello   ",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 6, 2)},
+			wantContent:  "hello",
+			wantEntities: []models.MessageEntity{},
+		},
+		{
+			name:         "adjacent styles merged",
+			content:      "hello world",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 0, 5), entity(constants.EntityTypeBold, 5, 6)},
+			wantContent:  "hello world",
+			wantEntities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 11)},
+		},
+		{
+			name:         "client mentions ignored",
+			content:      "hi @alice",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeMention, 3, 6)},
+			wantContent:  "hi @alice",
+			wantEntities: []models.MessageEntity{},
+		},
+		{
+			name:         "offsets after an emoji count UTF-16 code units",
+			content:      "😀 bold",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 3, 4)},
+			wantContent:  "😀 bold",
+			wantEntities: []models.MessageEntity{entity(constants.EntityTypeBold, 3, 4)},
+		},
+		{
+			name:     "range splitting a surrogate pair",
+			content:  "😀 bold",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 1, 6)},
+			wantErr:  true,
+		},
+		{
+			name:     "range past the end",
+			content:  "😀 bold",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 3, 5)},
+			wantErr:  true,
+		},
+		{
+			name:     "empty range",
+			content:  "hello",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 1, 0)},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown type",
+			content:  "hello",
+			entities: []models.MessageEntity{entity("underline", 0, 5)},
+			wantErr:  true,
+		},
+		{
+			name:     "link without url",
+			content:  "hello",
+			entities: []models.MessageEntity{entity(constants.EntityTypeLink, 0, 5)},
+			wantErr:  true,
+		},
+		{
+			name:     "link with another scheme",
+			content:  "hello",
+			entities: []models.MessageEntity{{Type: constants.EntityTypeLink, Offset: 0, Length: 5, URL: "javascript:alert(1)"}},
+			wantErr:  true,
+		},
+		{
+			name:     "partial overlap",
+			content:  "hello world",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 7), entity(constants.EntityTypeItalic, 5, 6)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, entities, err := prepareMessageContent(tt.content, tt.entities)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("prepareMessageContent() = %q, %v, want an error", content, entities)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareMessageContent() error = %v", err)
+			}
+			if content != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+			if !reflect.DeepEqual(entities, tt.wantEntities) {
+				t.Errorf("entities = %+v, want %+v", entities, tt.wantEntities)
+			}
+		})
+	}
+}
+
+func TestValidateEntityNesting(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities []models.MessageEntity
+		wantErr  bool
+	}{
+		{
+			name:     "disjoint",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 5), entity(constants.EntityTypeCode, 5, 5)},
+		},
+		{
+			name:     "nested styles",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 10), entity(constants.EntityTypeItalic, 2, 3)},
+		},
+		{
+			name:     "mention inside a link",
+			entities: []models.MessageEntity{entity(constants.EntityTypeLink, 0, 10), entity(constants.EntityTypeMention, 0, 6)},
+		},
+		{
+			name:     "partial overlap",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 5), entity(constants.EntityTypeItalic, 3, 5)},
+			wantErr:  true,
+		},
+		{
+			name:     "formatting inside code",
+			entities: []models.MessageEntity{entity(constants.EntityTypeCode, 0, 10), entity(constants.EntityTypeBold, 2, 3)},
+			wantErr:  true,
+		},
+		{
+			name:     "code inside formatting",
+			entities: []models.MessageEntity{entity(constants.EntityTypeBold, 0, 10), entity(constants.EntityTypeCode, 2, 3)},
+			wantErr:  true,
+		},
+		{
+			name:     "mention inside pre",
+			entities: []models.MessageEntity{entity(constants.EntityTypePre, 0, 20), entity(constants.EntityTypeMention, 4, 6)},
+			wantErr:  true,
+		},
+		{
+			name:     "same type nested",
+			entities: []models.MessageEntity{entity(constants.EntityTypeLink, 0, 10), entity(constants.EntityTypeLink, 2, 3)},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEntityNesting(tt.entities)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateEntityNesting() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithMentionEntities(t *testing.T) {
+	alice := models.MessageMention{UserID: uuid.New(), Username: "alice", Offset: 0, Length: 6}
+	bob := models.MessageMention{UserID: uuid.New(), Username: "bob", Offset: 12, Length: 4}
+
+	tests := []struct {
+		name         string
+		entities     []models.MessageEntity
+		mentions     []models.MessageMention
+		wantTypes    []string
+		wantMentions []models.MessageMention
+	}{
+		{
+			name:         "no mentions",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 0, 3)},
+			wantTypes:    []string{constants.EntityTypeBold},
+			wantMentions: nil,
+		},
+		{
+			name:         "merged in order",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeItalic, 7, 4)},
+			mentions:     []models.MessageMention{alice, bob},
+			wantTypes:    []string{constants.EntityTypeMention, constants.EntityTypeItalic, constants.EntityTypeMention},
+			wantMentions: []models.MessageMention{alice, bob},
+		},
+		{
+			name:         "inside a style",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 0, 16)},
+			mentions:     []models.MessageMention{alice, bob},
+			wantTypes:    []string{constants.EntityTypeBold, constants.EntityTypeMention, constants.EntityTypeMention},
+			wantMentions: []models.MessageMention{alice, bob},
+		},
+		{
+			name:         "inside code",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeCode, 10, 6)},
+			mentions:     []models.MessageMention{alice, bob},
+			wantTypes:    []string{constants.EntityTypeMention, constants.EntityTypeCode},
+			wantMentions: []models.MessageMention{alice},
+		},
+		{
+			name:         "inside pre",
+			entities:     []models.MessageEntity{entity(constants.EntityTypePre, 0, 16)},
+			mentions:     []models.MessageMention{alice, bob},
+			wantTypes:    []string{constants.EntityTypePre},
+			wantMentions: []models.MessageMention{},
+		},
+		{
+			name:         "partially overlapping a style",
+			entities:     []models.MessageEntity{entity(constants.EntityTypeBold, 3, 6)},
+			mentions:     []models.MessageMention{alice},
+			wantTypes:    []string{constants.EntityTypeBold},
+			wantMentions: []models.MessageMention{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities, mentions := withMentionEntities(tt.entities, tt.mentions)
+
+			types := make([]string, len(entities))
+			for i, e := range entities {
+				types[i] = e.Type
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Errorf("entity types = %v, want %v", types, tt.wantTypes)
+			}
+			if !reflect.DeepEqual(mentions, tt.wantMentions) {
+				t.Errorf("mentions = %+v, want %+v", mentions, tt.wantMentions)
+			}
+			if err := validateEntityNesting(entities); err != nil {
+				t.Errorf("merged entities do not nest: %v", err)
+			}
+
+			for _, e := range entities {
+				if e.Type == constants.EntityTypeMention && (e.UserID == nil || e.Username == "") {
+					t.Errorf("mention entity %+v has no user", e)
+				}
+			}
+		})
+	}
+}
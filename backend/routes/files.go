@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+)
+
+// FileHandler serves stored objects for drivers that have no public endpoint of their own
+type FileHandler struct {
+	store storage.Storage
+}
+
+func NewFileHandler(store storage.Storage) *FileHandler {
+	return &FileHandler{
+		store: store,
+	}
+}
+
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	object, err := h.store.Open(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "File not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read file",
+		})
+		return
+	}
+	defer object.Body.Close()
+
+	// Uploaded content is untrusted, so never let browsers sniff or execute it
+	c.Header("Content-Type", object.ContentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; sandbox")
+	// Keys are unique per upload, so objects never change once written
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+
+	if seeker, ok := object.Body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", object.ModTime, seeker)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, object.Body, nil)
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/storage"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// newTestHandlers wires the message and upload handlers to a local store in a temporary directory
+func newTestHandlers(t *testing.T, dbService *database.Service) (*MessageHandler, *UploadHandler) {
+	t.Helper()
+
+	store, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+
+	uploadHandler := NewUploadHandler(dbService, store, AttachmentConfig{}, StorageQuotaConfig{MaxBytes: 1 << 20})
+	messageHandler := NewMessageHandler(dbService, ws.NewHub(dbService), store)
+	return messageHandler, uploadHandler
+}
+
+func createTestUser(t *testing.T, db *sql.DB, username string) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	err := db.QueryRow(
+		`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'hash') RETURNING id`,
+		username, username+"@example.com",
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return id
+}
+
+// createTestChat creates a chat owned by the first member, with default settings
+func createTestChat(t *testing.T, db *sql.DB, members ...uuid.UUID) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	err := db.QueryRow(
+		`INSERT INTO chats (is_group, created_by) VALUES ($1, $2) RETURNING id`,
+		len(members) > 2, members[0],
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("create chat: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO chat_settings (chat_id) VALUES ($1)`, id); err != nil {
+		t.Fatalf("create chat settings: %v", err)
+	}
+
+	for _, member := range members {
+		if _, err := db.Exec(`INSERT INTO chat_members (chat_id, user_id) VALUES ($1, $2)`, id, member); err != nil {
+			t.Fatalf("add chat member: %v", err)
+		}
+	}
+	return id
+}
+
+// createTestMessage inserts a message sent at createdAt, expiring at expiresAt unless it is zero
+func createTestMessage(t *testing.T, db *sql.DB, chatID, senderID uuid.UUID, content string, createdAt, expiresAt time.Time) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	err := db.QueryRow(
+		`INSERT INTO messages (chat_id, sender_id, content, created_at, updated_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $4, $5) RETURNING id`,
+		chatID, senderID, content, createdAt, sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()},
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("create message: %v", err)
+	}
+	return id
+}
+
+// unreadCount is the unread badge the chat list shows the user for a chat
+func unreadCount(t *testing.T, queries *database.Queries, userID, chatID uuid.UUID) int64 {
+	t.Helper()
+
+	rows, err := queries.GetChatsWithMembers(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("get chats: %v", err)
+	}
+	for _, row := range rows {
+		if row.ChatID == chatID {
+			return row.UnreadCount
+		}
+	}
+	t.Fatalf("chat %s not listed for user %s", chatID, userID)
+	return 0
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+)
+
+func TestDeleteMessageForMe(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob)
+	messageID := createTestMessage(t, db, chatID, alice, "hello", time.Now(), time.Time{})
+
+	deleteForMe := func(userID uuid.UUID) int {
+		t.Helper()
+
+		gin.SetMode(gin.TestMode)
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/delete-for-me", strings.NewReader(`{"message_id": "`+messageID.String()+`"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.DeleteMessageForMe(c)
+		return recorder.Code
+	}
+
+	visibleTo := func(userID uuid.UUID) bool {
+		t.Helper()
+
+		messages, err := queries.GetMessagesByChatPaginated(context.Background(), database.GetMessagesByChatPaginatedParams{
+			UserID:    userID,
+			ChatID:    chatID,
+			PageLimit: 50,
+		})
+		if err != nil {
+			t.Fatalf("get messages: %v", err)
+		}
+		for _, message := range messages {
+			if message.ID == messageID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if count := unreadCount(t, queries, bob, chatID); count != 1 {
+		t.Fatalf("unread count = %d, want 1", count)
+	}
+
+	if status := deleteForMe(carol); status != http.StatusForbidden {
+		t.Errorf("DeleteMessageForMe() for a non-member status = %d, want %d", status, http.StatusForbidden)
+	}
+
+	// Hiding twice is not an error
+	for i := 0; i < 2; i++ {
+		if status := deleteForMe(bob); status != http.StatusOK {
+			t.Fatalf("DeleteMessageForMe() status = %d, want %d", status, http.StatusOK)
+		}
+	}
+
+	if visibleTo(bob) {
+		t.Error("hidden message still listed for the user who deleted it")
+	}
+	if !visibleTo(alice) {
+		t.Error("message hidden for the other member too")
+	}
+	if count := unreadCount(t, queries, bob, chatID); count != 0 {
+		t.Errorf("unread count = %d, want the hidden message left out", count)
+	}
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+// DownloadImage redirects to a fresh signed URL for an image the user can see: one sent to a chat
+// they are still a member of, one of their own uploads, or the profile picture of someone they share
+// a chat with. Clients of a private store use it once the signed URL they were given has expired.
+// Public stores redirect to the stored URL.
+func (h *MessageHandler) DownloadImage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.DownloadImageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	imageURL := storedImageURL(h.store, req.URL)
+
+	canAccess, err := h.dbService.Queries.CanAccessImage(c.Request.Context(), database.CanAccessImageParams{
+		UserID: userID,
+		Url:    imageURL,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to check image access",
+		})
+		return
+	}
+
+	// Images in other chats are reported as missing so their existence is not revealed
+	if !canAccess {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, signImageURL(c.Request.Context(), h.store, imageURL))
+}
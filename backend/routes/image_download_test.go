@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+)
+
+func TestDownloadImage(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob)
+
+	// sendImage adds an image with a thumbnail to a new message from bob
+	sendImage := func(name string) (string, string) {
+		t.Helper()
+
+		imageURL := handler.store.URL(bob.String() + "/1/" + name + ".jpg")
+		thumbnailURL := handler.store.URL(bob.String() + "/1/" + name + "_thumbnail.jpg")
+		messageID := createTestMessage(t, db, chatID, bob, "", time.Now(), time.Time{})
+		image, err := queries.AddMessageImage(ctx, database.AddMessageImageParams{MessageID: messageID, Url: imageURL})
+		if err != nil {
+			t.Fatalf("add image: %v", err)
+		}
+
+		variants, err := json.Marshal([]map[string]any{{"name": "thumbnail", "url": thumbnailURL, "width": 10, "height": 10}})
+		if err != nil {
+			t.Fatalf("encode variants: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE images SET variants = $1 WHERE id = $2`, variants, image.ID); err != nil {
+			t.Fatalf("set variants: %v", err)
+		}
+
+		return imageURL, thumbnailURL
+	}
+
+	sent, thumbnail := sendImage("sent")
+	deleted, _ := sendImage("deleted")
+	if _, err := db.Exec(`UPDATE messages SET is_deleted = true WHERE id = (SELECT message_id FROM images WHERE url = $1)`, deleted); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+
+	upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+		OwnerID:     carol,
+		Key:         carol.String() + "/1/unsent.jpg",
+		Url:         handler.store.URL(carol.String() + "/1/unsent.jpg"),
+		Size:        1000,
+		ContentType: "image/jpeg",
+		Variants:    json.RawMessage("[]"),
+	})
+	if err != nil {
+		t.Fatalf("create upload: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		imageURL   string
+		wantStatus int
+	}{
+		{name: "image in the user's chat", userID: alice, imageURL: sent, wantStatus: http.StatusFound},
+		{name: "variant of an image in the user's chat", userID: alice, imageURL: thumbnail, wantStatus: http.StatusFound},
+		{name: "image in another chat", userID: carol, imageURL: sent, wantStatus: http.StatusNotFound},
+		{name: "image of a deleted message", userID: alice, imageURL: deleted, wantStatus: http.StatusNotFound},
+		{name: "own unsent upload", userID: carol, imageURL: upload.Url, wantStatus: http.StatusFound},
+		{name: "someone else's unsent upload", userID: alice, imageURL: upload.Url, wantStatus: http.StatusNotFound},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", tt.userID)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/messages/images/download?url="+url.QueryEscape(tt.imageURL), nil)
+
+			handler.DownloadImage(c)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("DownloadImage() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			// A public store redirects to the stored URL itself
+			if tt.wantStatus == http.StatusFound && recorder.Header().Get("Location") != tt.imageURL {
+				t.Errorf("DownloadImage() redirected to %q, want %q", recorder.Header().Get("Location"), tt.imageURL)
+			}
+		})
+	}
+}
+
+func TestDownloadImageProfilePictures(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	createTestChat(t, db, alice, bob)
+
+	avatar := func(userID uuid.UUID) string {
+		imageURL := handler.store.URL(userID.String() + "/1/avatar.jpg")
+		if _, err := db.Exec(`UPDATE users SET profile_image_url = $1 WHERE id = $2`, imageURL, userID); err != nil {
+			t.Fatalf("set profile picture: %v", err)
+		}
+		return imageURL
+	}
+
+	tests := []struct {
+		name       string
+		imageURL   string
+		wantStatus int
+	}{
+		{name: "chat member's picture", imageURL: avatar(bob), wantStatus: http.StatusFound},
+		{name: "stranger's picture", imageURL: avatar(carol), wantStatus: http.StatusNotFound},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", alice)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/messages/images/download?url="+url.QueryEscape(tt.imageURL), nil)
+
+			handler.DownloadImage(c)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("DownloadImage() status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func toMessageImage(image database.Image) models.MessageImage {
+	return models.MessageImage{
+		URL:      image.Url,
+		Width:    utils.NullableInt32(image.Width),
+		Height:   utils.NullableInt32(image.Height),
+		Blurhash: utils.NullableString(image.Blurhash),
+		Variants: decodeImageVariants(image.Variants),
+	}
+}
+
+func toMessageImages(images []database.Image) []models.MessageImage {
+	result := make([]models.MessageImage, len(images))
+	for i, image := range images {
+		result[i] = toMessageImage(image)
+	}
+	return result
+}
+
+// decodeImageVariants reads the variants stored with an image. The stored entries also carry
+// the storage key and size, which are internal and dropped here.
+func decodeImageVariants(raw json.RawMessage) []models.ImageVariant {
+	variants := []models.ImageVariant{}
+	if len(raw) == 0 {
+		return variants
+	}
+	if err := json.Unmarshal(raw, &variants); err != nil || variants == nil {
+		return []models.ImageVariant{}
+	}
+	return variants
+}
+
+func toImagePayloads(images []models.MessageImage) []ws.MessageImage {
+	payloads := make([]ws.MessageImage, len(images))
+	for i, image := range images {
+		variants := make([]ws.ImageVariant, len(image.Variants))
+		for j, variant := range image.Variants {
+			variants[j] = ws.ImageVariant{
+				Name:   variant.Name,
+				URL:    variant.URL,
+				Width:  variant.Width,
+				Height: variant.Height,
+			}
+		}
+
+		payloads[i] = ws.MessageImage{
+			URL:      image.URL,
+			Width:    image.Width,
+			Height:   image.Height,
+			Blurhash: image.Blurhash,
+			Variants: variants,
+		}
+	}
+	return payloads
+}
+
+// signImageURL turns a stored image URL into a short-lived signed URL when the store is private.
+// Public stores, external URLs and signing failures keep the stored URL, so one bad image cannot
+// fail a whole response.
+func signImageURL(ctx context.Context, store storage.Storage, imageURL string) string {
+	signer, ok := store.(storage.URLSigner)
+	if !ok || !signer.Private() {
+		return imageURL
+	}
+
+	key, ok := store.KeyForURL(imageURL)
+	if !ok {
+		return imageURL
+	}
+
+	signed, err := signer.SignURL(ctx, key, constants.SignedURLTTLSeconds*time.Second)
+	if err != nil {
+		log.Printf("Failed to sign image URL %s: %v", key, err)
+		return imageURL
+	}
+	return signed
+}
+
+// signProfileImageURL signs a user's stored profile picture for a response
+func signProfileImageURL(ctx context.Context, store storage.Storage, imageURL sql.NullString) *string {
+	if !imageURL.Valid {
+		return nil
+	}
+	signed := signImageURL(ctx, store, imageURL.String)
+	return &signed
+}
+
+// signMessageImage signs the URLs of an image and its variants for a response
+func signMessageImage(ctx context.Context, store storage.Storage, image models.MessageImage) models.MessageImage {
+	image.URL = signImageURL(ctx, store, image.URL)
+
+	variants := make([]models.ImageVariant, len(image.Variants))
+	for i, variant := range image.Variants {
+		variant.URL = signImageURL(ctx, store, variant.URL)
+		variants[i] = variant
+	}
+	image.Variants = variants
+
+	return image
+}
+
+func signMessageImages(ctx context.Context, store storage.Storage, images []models.MessageImage) []models.MessageImage {
+	signed := make([]models.MessageImage, len(images))
+	for i, image := range images {
+		signed[i] = signMessageImage(ctx, store, image)
+	}
+	return signed
+}
+
+// signImageURLs signs a list of stored image URLs, such as a draft's images, for a response
+func signImageURLs(ctx context.Context, store storage.Storage, imageURLs []string) []string {
+	signed := make([]string, len(imageURLs))
+	for i, imageURL := range imageURLs {
+		signed[i] = signImageURL(ctx, store, imageURL)
+	}
+	return signed
+}
+
+// storedImageURL maps a URL sent back by a client, which may be a signed one, to the URL stored
+// for the image
+func storedImageURL(store storage.Storage, imageURL string) string {
+	if key, ok := store.KeyForURL(imageURL); ok {
+		return store.URL(key)
+	}
+	return imageURL
+}
+
+// storedImageURLs maps the image URLs of a request to the stored ones
+func storedImageURLs(store storage.Storage, imageURLs []string) []string {
+	if imageURLs == nil {
+		return nil
+	}
+
+	stored := make([]string, len(imageURLs))
+	for i, imageURL := range imageURLs {
+		stored[i] = storedImageURL(store, imageURL)
+	}
+	return stored
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+)
+
+// privateStore serves a local store through signed URLs, the way a private bucket does
+
+type privateStore struct {
+	*storage.LocalStorage
+}
+
+func (p privateStore) Private() bool {
+	return true
+}
+
+func (p privateStore) SignURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return p.URL(key) + "?signature=test", nil
+}
+
+func (p privateStore) KeyForURL(url string) (string, bool) {
+	url, _ = strings.CutSuffix(url, "?signature=test")
+	return p.LocalStorage.KeyForURL(url)
+}
+
+func TestPrivateStoreImageURLs(t *testing.T) {
+	local, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	if err != nil {
+		t.Fatalf("create local storage: %v", err)
+	}
+	store := privateStore{local}
+	ctx := context.Background()
+
+	stored := "http://localhost/files/user/1/photo.jpg"
+	signed := stored + "?signature=test"
+	external := "https://example.com/cat.jpg"
+
+	profileTests := []struct {
+		name  string
+		image sql.NullString
+		want  *string
+	}{
+		{name: "no picture", image: sql.NullString{}, want: nil},
+		{name: "stored picture", image: sql.NullString{String: stored, Valid: true}, want: &signed},
+		{name: "external picture", image: sql.NullString{String: external, Valid: true}, want: &external},
+	}
+	for _, tt := range profileTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := signProfileImageURL(ctx, store, tt.image)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil || *got != *tt.want:
+				t.Errorf("signProfileImageURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := signImageURLs(ctx, store, nil); got == nil || len(got) != 0 {
+		t.Errorf("signImageURLs(nil) = %#v, want an empty list", got)
+	}
+	if got := signImageURLs(ctx, store, []string{stored, external}); got[0] != signed || got[1] != external {
+		t.Errorf("signImageURLs() = %v, want [%s %s]", got, signed, external)
+	}
+
+	// Clients send back what they were given, which must map to what is stored
+	if got := storedImageURLs(store, []string{signed, stored, external}); got[0] != stored || got[1] != stored || got[2] != external {
+		t.Errorf("storedImageURLs() = %v, want [%s %s %s]", got, stored, stored, external)
+	}
+	if got := storedImageURLs(store, nil); got != nil {
+		t.Errorf("storedImageURLs(nil) = %v, want nil", got)
+	}
+}
+
+func TestSignMessageImage(t *testing.T) {
+	local, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	if err != nil {
+		t.Fatalf("create local storage: %v", err)
+	}
+	ctx := context.Background()
+
+	stored := "http://localhost/files/user/1/photo.jpg"
+	storedThumbnail := "http://localhost/files/user/1/photo_thumbnail.jpg"
+	external := "https://example.com/cat.jpg"
+	image := models.MessageImage{
+		URL:      stored,
+		Variants: []models.ImageVariant{{Name: "thumbnail", URL: storedThumbnail}, {Name: "small", URL: external}},
+	}
+
+	tests := []struct {
+		name  string
+		store storage.Storage
+		want  []string
+	}{
+		{name: "public store", store: local, want: []string{stored, storedThumbnail, external}},
+		{name: "private store", store: privateStore{local}, want: []string{stored + "?signature=test", storedThumbnail + "?signature=test", external}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := signMessageImage(ctx, tt.store, image)
+			got := []string{signed.URL, signed.Variants[0].URL, signed.Variants[1].URL}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("signMessageImage() URLs = %v, want %v", got, tt.want)
+			}
+
+			// Clients send back what they were given, which must map to what is stored
+			if got := storedImageURL(tt.store, signed.URL); got != stored {
+				t.Errorf("storedImageURL(%q) = %q, want %q", signed.URL, got, stored)
+			}
+		})
+	}
+
+	if image.Variants[0].URL != storedThumbnail {
+		t.Error("signMessageImage() changed the variants of the image it was given")
+	}
+}
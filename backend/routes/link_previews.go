@@ -0,0 +1,215 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+const (
+	// Pending preview jobs beyond this are dropped rather than slowing down sending
+	linkPreviewQueueSize = 256
+	linkPreviewWorkers   = 4
+	// How long a fetched preview is reused before the page is fetched again
+	linkPreviewCacheTTL = 24 * time.Hour
+	// Pages that could not be previewed are retried sooner
+	linkPreviewFailureTTL = time.Hour
+	linkPreviewJobTimeout = 15 * time.Second
+)
+
+var bareURLPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+type linkPreviewJob struct {
+	MessageID uuid.UUID
+	ChatID    uuid.UUID
+	URLs      []string
+}
+
+// extractPreviewURLs collects the links worth previewing, explicit link entities first
+func extractPreviewURLs(content sql.NullString, entities []models.MessageEntity) []string {
+	if !content.Valid {
+		return nil
+	}
+
+	var candidates []string
+	for _, entity := range entities {
+		if entity.Type == constants.EntityTypeLink {
+			candidates = append(candidates, entity.URL)
+		}
+	}
+	for _, match := range bareURLPattern.FindAllString(content.String, -1) {
+		candidates = append(candidates, strings.TrimRight(match, ".,;:!?)]}'"))
+	}
+
+	seen := make(map[string]struct{}, len(candidates))
+	urls := make([]string, 0, constants.MaxLinkPreviewsPerMessage)
+	for _, candidate := range candidates {
+		if _, dup := seen[candidate]; dup {
+			continue
+		}
+		seen[candidate] = struct{}{}
+
+		urls = append(urls, candidate)
+		if len(urls) == constants.MaxLinkPreviewsPerMessage {
+			break
+		}
+	}
+
+	return urls
+}
+
+// enqueueLinkPreviews schedules previews for a committed message without blocking the request
+func (h *MessageHandler) enqueueLinkPreviews(message database.Message, entities []models.MessageEntity) {
+	urls := extractPreviewURLs(message.Content, entities)
+	if len(urls) == 0 {
+		return
+	}
+
+	select {
+	case h.linkPreviewJobs <- linkPreviewJob{MessageID: message.ID, ChatID: message.ChatID, URLs: urls}:
+	default:
+		log.Printf("Link preview queue full, skipping message %s", message.ID)
+	}
+}
+
+// RunLinkPreviewWorkers unfurls queued links until the context is cancelled
+func (h *MessageHandler) RunLinkPreviewWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range linkPreviewWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-h.linkPreviewJobs:
+					h.processLinkPreviewJob(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (h *MessageHandler) processLinkPreviewJob(ctx context.Context, job linkPreviewJob) {
+	ctx, cancel := context.WithTimeout(ctx, linkPreviewJobTimeout)
+	defer cancel()
+
+	var previews []ws.LinkPreviewPayload
+	for i, url := range job.URLs {
+		preview, err := h.loadLinkPreview(ctx, url)
+		if err != nil {
+			log.Printf("Failed to load link preview for %s: %v", url, err)
+			continue
+		}
+		if preview.FetchFailed {
+			continue
+		}
+
+		// The message may have been deleted while the page was being fetched
+		if err := h.dbService.Queries.AddMessageLinkPreview(ctx, database.AddMessageLinkPreviewParams{
+			MessageID: job.MessageID,
+			Url:       url,
+			Position:  int32(i),
+		}); err != nil {
+			log.Printf("Failed to attach link preview to message %s: %v", job.MessageID, err)
+			return
+		}
+
+		previews = append(previews, ws.LinkPreviewPayload{
+			URL:         preview.Url,
+			Title:       utils.NullableString(preview.Title),
+			Description: utils.NullableString(preview.Description),
+			ImageURL:    utils.NullableString(preview.ImageUrl),
+			SiteName:    utils.NullableString(preview.SiteName),
+		})
+	}
+
+	if len(previews) == 0 {
+		return
+	}
+
+	h.hub.BroadcastToChat(job.ChatID.String(), ws.WSMessage{
+		Type: ws.EventMessagePreviewReady,
+		Payload: ws.MessagePreviewReadyPayload{
+			MessageID: job.MessageID.String(),
+			ChatID:    job.ChatID.String(),
+			Previews:  previews,
+		},
+	})
+}
+
+// loadLinkPreview returns the cached preview for url, fetching the page when the cache is stale.
+// Failed fetches are cached too so a broken link is not fetched for every message that repeats it.
+func (h *MessageHandler) loadLinkPreview(ctx context.Context, url string) (database.LinkPreview, error) {
+	cached, err := h.dbService.Queries.GetLinkPreview(ctx, url)
+	if err != nil && err != sql.ErrNoRows {
+		return database.LinkPreview{}, err
+	}
+
+	if err == nil {
+		ttl := linkPreviewCacheTTL
+		if cached.FetchFailed {
+			ttl = linkPreviewFailureTTL
+		}
+		if time.Since(cached.FetchedAt) < ttl {
+			return cached, nil
+		}
+	}
+
+	params := database.UpsertLinkPreviewParams{Url: url}
+
+	fetched, err := h.previewFetcher.Fetch(ctx, url)
+	if err != nil || (fetched.Title == "" && fetched.Description == "" && fetched.ImageURL == "") {
+		params.FetchFailed = true
+	} else {
+		params.Title = nullString(fetched.Title)
+		params.Description = nullString(fetched.Description)
+		params.ImageUrl = nullString(fetched.ImageURL)
+		params.SiteName = nullString(fetched.SiteName)
+	}
+
+	return h.dbService.Queries.UpsertLinkPreview(ctx, params)
+}
+
+// loadMessageLinkPreviews groups the ready previews of the given messages by message ID
+func loadMessageLinkPreviews(ctx context.Context, queries *database.Queries, messageIDs []uuid.UUID) (map[uuid.UUID][]models.LinkPreview, error) {
+	previewsByMessage := make(map[uuid.UUID][]models.LinkPreview)
+	if len(messageIDs) == 0 {
+		return previewsByMessage, nil
+	}
+
+	rows, err := queries.GetMessageLinkPreviews(ctx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		previewsByMessage[row.MessageID] = append(previewsByMessage[row.MessageID], models.LinkPreview{
+			URL:         row.Url,
+			Title:       utils.NullableString(row.Title),
+			Description: utils.NullableString(row.Description),
+			ImageURL:    utils.NullableString(row.ImageUrl),
+			SiteName:    utils.NullableString(row.SiteName),
+		})
+	}
+
+	return previewsByMessage, nil
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// Usernames are alphanumeric and between 3 and 50 characters, see models.SignUpRequest
+const (
+	minMentionUsernameLength = 3
+	maxMentionUsernameLength = 50
+)
+
+type mentionCandidate struct {
+	Username string
+	Offset   int32
+	Length   int32
+}
+
+// parseMentions finds @username tokens in the content. Offsets and lengths count UTF-16 code units like
+// entity ranges, and an @ directly after a letter or digit is ignored so email addresses are not treated
+// as mentions.
+func parseMentions(content string) []mentionCandidate {
+	runes := []rune(content)
+	var candidates []mentionCandidate
+
+	// positions[i] is where the i-th rune starts in UTF-16 code units
+	positions := make([]int32, len(runes)+1)
+	for i, r := range runes {
+		positions[i+1] = positions[i] + int32(utf16.RuneLen(r))
+	}
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' {
+			continue
+		}
+		if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isUsernameRune(runes[end]) {
+			end++
+		}
+
+		nameLength := end - i - 1
+		if nameLength >= minMentionUsernameLength && nameLength <= maxMentionUsernameLength {
+			candidates = append(candidates, mentionCandidate{
+				Username: string(runes[i+1 : end]),
+				Offset:   positions[i],
+				Length:   positions[end] - positions[i],
+			})
+		}
+
+		i = end - 1
+	}
+
+	return candidates
+}
+
+func isUsernameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// resolveMentions matches the content's mentions against the chat's members.
+// Usernames that are not in the chat are left as plain text.
+func resolveMentions(ctx context.Context, qtx *database.Queries, chatID uuid.UUID, content sql.NullString) ([]models.MessageMention, error) {
+	if !content.Valid {
+		return nil, nil
+	}
+
+	candidates := parseMentions(content.String)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]struct{}, len(candidates))
+	usernames := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if _, dup := seen[candidate.Username]; dup {
+			continue
+		}
+		seen[candidate.Username] = struct{}{}
+		usernames = append(usernames, candidate.Username)
+	}
+
+	members, err := qtx.GetChatMembersByUsernames(ctx, database.GetChatMembersByUsernamesParams{
+		ChatID:    chatID,
+		Usernames: usernames,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs := make(map[string]uuid.UUID, len(members))
+	for _, member := range members {
+		memberIDs[member.Username] = member.ID
+	}
+
+	var mentions []models.MessageMention
+	for _, candidate := range candidates {
+		userID, ok := memberIDs[candidate.Username]
+		if !ok {
+			continue
+		}
+
+		mentions = append(mentions, models.MessageMention{
+			UserID:   userID,
+			Username: candidate.Username,
+			Offset:   candidate.Offset,
+			Length:   candidate.Length,
+		})
+	}
+
+	return mentions, nil
+}
+
+// saveMessageMentions stores resolved mentions, leaving the transaction to the caller
+func saveMessageMentions(ctx context.Context, qtx *database.Queries, messageID uuid.UUID, mentions []models.MessageMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	params := database.AddMessageMentionsParams{MessageID: messageID}
+	for _, mention := range mentions {
+		params.UserIds = append(params.UserIds, mention.UserID)
+		params.Positions = append(params.Positions, mention.Offset)
+		params.Lengths = append(params.Lengths, mention.Length)
+	}
+
+	return qtx.AddMessageMentions(ctx, params)
+}
+
+// loadMessageMentions groups the stored mentions of the given messages by message ID
+func loadMessageMentions(ctx context.Context, queries *database.Queries, messageIDs []uuid.UUID) (map[uuid.UUID][]models.MessageMention, error) {
+	mentionsByMessage := make(map[uuid.UUID][]models.MessageMention)
+	if len(messageIDs) == 0 {
+		return mentionsByMessage, nil
+	}
+
+	rows, err := queries.GetMessageMentions(ctx, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		mentionsByMessage[row.MessageID] = append(mentionsByMessage[row.MessageID], models.MessageMention{
+			UserID:   row.UserID,
+			Username: row.Username,
+			Offset:   row.Position,
+			Length:   row.Length,
+		})
+	}
+
+	return mentionsByMessage, nil
+}
+
+func toMentionEntities(mentions []models.MessageMention) []ws.MentionEntity {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	entities := make([]ws.MentionEntity, len(mentions))
+	for i, mention := range mentions {
+		entities[i] = ws.MentionEntity{
+			UserID:   mention.UserID.String(),
+			Username: mention.Username,
+			Offset:   mention.Offset,
+			Length:   mention.Length,
+		}
+	}
+	return entities
+}
@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []mentionCandidate
+	}{
+		{
+			name:    "single",
+			content: "hey @alice",
+			want:    []mentionCandidate{{Username: "alice", Offset: 4, Length: 6}},
+		},
+		{
+			name:    "several with punctuation",
+			content: "@alice, @bob1!",
+			want: []mentionCandidate{
+				{Username: "alice", Offset: 0, Length: 6},
+				{Username: "bob1", Offset: 8, Length: 5},
+			},
+		},
+		{
+			name:    "repeated",
+			content: "@alice @alice",
+			want: []mentionCandidate{
+				{Username: "alice", Offset: 0, Length: 6},
+				{Username: "alice", Offset: 7, Length: 6},
+			},
+		},
+		{
+			name:    "after an emoji",
+			content: "😀 @alice",
+			want:    []mentionCandidate{{Username: "alice", Offset: 3, Length: 6}},
+		},
+		{
+			name:    "after accented text",
+			content: "café @alice",
+			want:    []mentionCandidate{{Username: "alice", Offset: 5, Length: 6}},
+		},
+		{
+			name:    "email address",
+			content: "mail me at alice@example.com",
+			want:    nil,
+		},
+		{
+			name:    "directly after a letter outside ASCII",
+			content: "é@alice",
+			want:    nil,
+		},
+		{
+			name:    "too short",
+			content: "@al",
+			want:    nil,
+		},
+		{
+			name:    "longest username",
+			content: "@" + strings.Repeat("a", maxMentionUsernameLength),
+			want:    []mentionCandidate{{Username: strings.Repeat("a", maxMentionUsernameLength), Offset: 0, Length: maxMentionUsernameLength + 1}},
+		},
+		{
+			name:    "too long",
+			content: "@" + strings.Repeat("a", maxMentionUsernameLength+1),
+			want:    nil,
+		},
+		{
+			name:    "stops at the first other character",
+			content: "@alice_smith",
+			want:    []mentionCandidate{{Username: "alice", Offset: 0, Length: 6}},
+		},
+		{
+			name:    "double at",
+			content: "@@alice",
+			want:    []mentionCandidate{{Username: "alice", Offset: 1, Length: 6}},
+		},
+		{
+			name:    "bare at",
+			content: "@ @",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMentions(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions(%q) = %+v, want %+v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateMessageSavesMentionsOfMembers(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	createTestUser(t, db, "dave")
+	chatID := createTestChat(t, db, alice, bob, carol)
+
+	// dave is not in the chat, so his name stays plain text
+	created, err := createMessage(ctx, queries, outgoingMessage{
+		ChatID:   chatID,
+		SenderID: alice,
+		Content:  sql.NullString{String: "@bob @carol @bob @dave lunch?", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("createMessage() error = %v", err)
+	}
+
+	mentioned := make(map[string]bool)
+	for _, mention := range created.Mentions {
+		mentioned[mention.Username] = true
+	}
+	if len(mentioned) != 2 || !mentioned["bob"] || !mentioned["carol"] {
+		t.Errorf("mentions = %+v, want bob and carol", created.Mentions)
+	}
+
+	if _, err := createMessage(ctx, queries, outgoingMessage{
+		ChatID:   chatID,
+		SenderID: alice,
+		Content:  sql.NullString{String: "anyone else?", Valid: true},
+	}); err != nil {
+		t.Fatalf("createMessage() error = %v", err)
+	}
+
+	rows, err := queries.GetChatsWithMembers(ctx, bob)
+	if err != nil {
+		t.Fatalf("get chats: %v", err)
+	}
+	for _, row := range rows {
+		if row.ChatID == chatID && (row.UnreadCount != 2 || row.UnreadMentionCount != 1) {
+			t.Errorf("unread = %d with %d mentions, want 2 with 1 mention", row.UnreadCount, row.UnreadMentionCount)
+		}
+	}
+}
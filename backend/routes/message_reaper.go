@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+const (
+	// How often the reaper looks for messages past their disappearing timer
+	expiredMessageReapInterval = 30 * time.Second
+	// Maximum messages removed per reaper transaction
+	expiredMessageReapBatchSize = 100
+)
+
+// RunExpiredMessageReaper permanently removes expired messages and their stored images
+// until the context is cancelled.
+func (h *MessageHandler) RunExpiredMessageReaper(ctx context.Context, uploadHandler *UploadHandler) {
+	ticker := time.NewTicker(expiredMessageReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				reaped, err := h.reapExpiredMessages(ctx, uploadHandler)
+				if err != nil {
+					log.Printf("Failed to reap expired messages: %v", err)
+					break
+				}
+				if reaped < expiredMessageReapBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// reapExpiredMessages hard deletes one batch of expired messages, returning how many were removed
+func (h *MessageHandler) reapExpiredMessages(ctx context.Context, uploadHandler *UploadHandler) (int, error) {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	expired, err := qtx.GetExpiredMessages(ctx, expiredMessageReapBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	messageIDs := make([]uuid.UUID, len(expired))
+	for i, msg := range expired {
+		messageIDs[i] = msg.ID
+	}
+
+	imageRows, err := qtx.GetMessageImages(ctx, messageIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	attachments, err := qtx.GetMessageAttachments(ctx, messageIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	// Read receipts cascade with the message they point at, which would make every surviving
+	// message unread again, so they are moved to the newest message the member had already read
+	if err := qtx.MoveReadReceiptsOffMessages(ctx, messageIDs); err != nil {
+		return 0, err
+	}
+
+	// Image and attachment rows are removed along with their messages by the foreign key cascade
+	if err := qtx.HardDeleteMessages(ctx, messageIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	if len(imageRows) > 0 {
+		imageURLs := make([]string, len(imageRows))
+		for i, img := range imageRows {
+			imageURLs[i] = img.Url
+		}
+
+		// Forwarded copies may still reference the same objects
+		unreferenced, err := unreferencedImageURLs(ctx, h.dbService.Queries, imageURLs)
+		if err != nil {
+			log.Printf("Failed to check image references for expired messages: %v", err)
+		} else {
+			for _, imageURL := range unreferenced {
+				if err := uploadHandler.DeleteImage(imageURL); err != nil {
+					log.Printf("Failed to delete image %s: %v", imageURL, err)
+				}
+			}
+		}
+	}
+
+	if err := uploadHandler.DeleteAttachmentFiles(ctx, attachments); err != nil {
+		log.Printf("Failed to delete attachments of expired messages: %v", err)
+	}
+
+	deletedByChat := make(map[uuid.UUID][]string)
+	var chatOrder []uuid.UUID
+	for _, msg := range expired {
+		if _, ok := deletedByChat[msg.ChatID]; !ok {
+			chatOrder = append(chatOrder, msg.ChatID)
+		}
+		deletedByChat[msg.ChatID] = append(deletedByChat[msg.ChatID], msg.ID.String())
+	}
+
+	for _, chatID := range chatOrder {
+		h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+			Type: ws.EventMessagesDeleted,
+			Payload: ws.MessagesDeletedPayload{
+				ChatID:     chatID.String(),
+				MessageIDs: deletedByChat[chatID],
+				IsDeleted:  true,
+			},
+		})
+	}
+
+	return len(expired), nil
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+)
+
+func TestReapExpiredMessages(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	messageHandler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	// Far enough from now that the session time zone cannot move them across CURRENT_TIMESTAMP
+	now := time.Now().UTC()
+	expired := createTestMessage(t, db, chatID, alice, "gone", now.Add(-72*time.Hour), now.Add(-48*time.Hour))
+	expiring := createTestMessage(t, db, chatID, alice, "still here", now, now.Add(48*time.Hour))
+	permanent := createTestMessage(t, db, chatID, bob, "forever", now.Add(-72*time.Hour), time.Time{})
+
+	reaped, err := messageHandler.reapExpiredMessages(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("reapExpiredMessages() error = %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("reaped %d messages, want 1", reaped)
+	}
+
+	for _, tt := range []struct {
+		messageID uuid.UUID
+		content   string
+		wantExist bool
+	}{
+		{messageID: expired, content: "gone", wantExist: false},
+		{messageID: expiring, content: "still here", wantExist: true},
+		{messageID: permanent, content: "forever", wantExist: true},
+	} {
+		var exists bool
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1)`, tt.messageID).Scan(&exists); err != nil {
+			t.Fatalf("check message: %v", err)
+		}
+		if exists != tt.wantExist {
+			t.Errorf("message %q exists = %t, want %t", tt.content, exists, tt.wantExist)
+		}
+	}
+
+	if reaped, err := messageHandler.reapExpiredMessages(context.Background(), nil); err != nil || reaped != 0 {
+		t.Errorf("second reapExpiredMessages() = %d, %v, want nothing left to reap", reaped, err)
+	}
+}
+
+func TestReapExpiredMessagesKeepsReadReceipts(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	messageHandler, uploadHandler := newTestHandlers(t, dbService)
+
+	reader := createTestUser(t, db, "reader")
+	sender := createTestUser(t, db, "sender")
+	chatID := createTestChat(t, db, reader, sender)
+
+	// Far enough in the past that the session time zone cannot move them past CURRENT_TIMESTAMP
+	base := time.Now().UTC().Add(-72 * time.Hour)
+	expired := base.Add(time.Hour)
+
+	kept := createTestMessage(t, db, chatID, sender, "kept", base, time.Time{})
+	lastRead := createTestMessage(t, db, chatID, sender, "read, then expired", base.Add(time.Minute), expired)
+	createTestMessage(t, db, chatID, sender, "unread", base.Add(2*time.Minute), time.Time{})
+
+	if _, err := db.Exec(
+		`INSERT INTO chat_read_receipts (chat_id, user_id, last_read_message_id, last_read_at) VALUES ($1, $2, $3, $4)`,
+		chatID, reader, lastRead, base.Add(time.Minute),
+	); err != nil {
+		t.Fatalf("create read receipt: %v", err)
+	}
+
+	if got := unreadCount(t, dbService.Queries, reader, chatID); got != 1 {
+		t.Fatalf("unread before reaping = %d, want 1", got)
+	}
+
+	reaped, err := messageHandler.reapExpiredMessages(context.Background(), uploadHandler)
+	if err != nil {
+		t.Fatalf("reap: %v", err)
+	}
+	if reaped != 1 {
+		t.Fatalf("reaped %d messages, want 1", reaped)
+	}
+
+	var messageID uuid.UUID
+	var readAt time.Time
+	err = db.QueryRow(
+		`SELECT last_read_message_id, last_read_at FROM chat_read_receipts WHERE chat_id = $1 AND user_id = $2`,
+		chatID, reader,
+	).Scan(&messageID, &readAt)
+	if err == sql.ErrNoRows {
+		t.Fatal("read receipt was deleted with the expired message")
+	}
+	if err != nil {
+		t.Fatalf("get read receipt: %v", err)
+	}
+	if messageID != kept {
+		t.Errorf("receipt points at %s, want the newest surviving read message %s", messageID, kept)
+	}
+	if !readAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("last_read_at = %s, want it unchanged at %s", readAt, base.Add(time.Minute))
+	}
+
+	if got := unreadCount(t, dbService.Queries, reader, chatID); got != 1 {
+		t.Errorf("unread after reaping = %d, want 1", got)
+	}
+}
+
+func TestReapExpiredMessagesWithNothingReadBefore(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	messageHandler, uploadHandler := newTestHandlers(t, dbService)
+
+	reader := createTestUser(t, db, "reader")
+	sender := createTestUser(t, db, "sender")
+	chatID := createTestChat(t, db, reader, sender)
+
+	base := time.Now().UTC().Add(-72 * time.Hour)
+	lastRead := createTestMessage(t, db, chatID, sender, "only read message", base, base.Add(time.Hour))
+	createTestMessage(t, db, chatID, sender, "unread", base.Add(time.Minute), time.Time{})
+
+	if _, err := db.Exec(
+		`INSERT INTO chat_read_receipts (chat_id, user_id, last_read_message_id, last_read_at) VALUES ($1, $2, $3, $4)`,
+		chatID, reader, lastRead, base,
+	); err != nil {
+		t.Fatalf("create read receipt: %v", err)
+	}
+
+	if _, err := messageHandler.reapExpiredMessages(context.Background(), uploadHandler); err != nil {
+		t.Fatalf("reap: %v", err)
+	}
+
+	// With no earlier message to move to, the receipt goes, and only the later message is unread
+	if got := unreadCount(t, dbService.Queries, reader, chatID); got != 1 {
+		t.Errorf("unread after reaping = %d, want 1", got)
+	}
+}
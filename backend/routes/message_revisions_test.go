@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestEditMessageKeepsRevisions(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob)
+	messageID := createTestMessage(t, db, chatID, alice, "first", time.Now(), time.Time{})
+
+	gin.SetMode(gin.TestMode)
+	call := func(userID uuid.UUID, path string, request any, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Request = httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handle(c)
+		return recorder
+	}
+
+	edit := func(c *gin.Context) { handler.EditMessage(c, &UploadHandler{}) }
+	for _, content := range []string{"second", "third"} {
+		recorder := call(alice, "/api/messages/edit", models.EditMessageRequest{MessageID: messageID.String(), Content: content}, edit)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("EditMessage() status = %d, body %s", recorder.Code, recorder.Body)
+		}
+	}
+
+	history := models.GetMessageHistoryRequest{MessageID: messageID.String()}
+	recorder := call(bob, "/api/messages/history", history, handler.GetMessageHistory)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GetMessageHistory() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var response models.GetMessageHistoryResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// Newest revision first, each holding the content the edit replaced
+	var contents []string
+	for _, revision := range response.Revisions {
+		if revision.Content == nil {
+			t.Fatalf("revision %s has no content", revision.ID)
+		}
+		contents = append(contents, *revision.Content)
+	}
+	if strings.Join(contents, ",") != "second,first" {
+		t.Errorf("revision contents = %v, want [second first]", contents)
+	}
+
+	recorder = call(carol, "/api/messages/history", history, handler.GetMessageHistory)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("GetMessageHistory() for a non-member status = %d, want %d", recorder.Code, http.StatusForbidden)
+	}
+}
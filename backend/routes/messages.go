@@ -1,7 +1,10 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -11,19 +14,28 @@ import (
 
 	"github.com/anmol7470/bubbles/backend/constants"
 	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/linkpreview"
 	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
 	ws "github.com/anmol7470/bubbles/backend/websocket"
 )
 
 type MessageHandler struct {
-	dbService *database.Service
-	hub       *ws.Hub
+	dbService       *database.Service
+	hub             *ws.Hub
+	previewFetcher  *linkpreview.Fetcher
+	linkPreviewJobs chan linkPreviewJob
+	store           storage.Storage
 }
 
-func NewMessageHandler(dbService *database.Service, hub *ws.Hub) *MessageHandler {
+func NewMessageHandler(dbService *database.Service, hub *ws.Hub, store storage.Storage) *MessageHandler {
 	return &MessageHandler{
-		dbService: dbService,
-		hub:       hub,
+		dbService:       dbService,
+		hub:             hub,
+		store:           store,
+		previewFetcher:  linkpreview.NewFetcher(),
+		linkPreviewJobs: make(chan linkPreviewJob, linkPreviewQueueSize),
 	}
 }
 
@@ -137,9 +149,9 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 	}
 
 	// Group images by message ID
-	imagesByMessage := make(map[uuid.UUID][]string)
+	imagesByMessage := make(map[uuid.UUID][]models.MessageImage)
 	for _, img := range imagesData {
-		imagesByMessage[img.MessageID] = append(imagesByMessage[img.MessageID], img.Url)
+		imagesByMessage[img.MessageID] = append(imagesByMessage[img.MessageID], signMessageImage(c.Request.Context(), h.store, toMessageImage(img)))
 	}
 
 	// Build messages response
@@ -152,24 +164,23 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 
 		images := imagesByMessage[msg.ID]
 		if images == nil {
-			images = []string{}
+			images = []models.MessageImage{}
 		}
 
-		var senderProfileImageUrl *string
-		if msg.SenderProfileImageUrl.Valid {
-			senderProfileImageUrl = &msg.SenderProfileImageUrl.String
-		}
+		senderProfileImageUrl := signProfileImageURL(c.Request.Context(), h.store, msg.SenderProfileImageUrl)
 
 		var replyTo *models.ReplyToMessage
 		if msg.ReplyToMessageID.Valid && msg.ReplySenderID.Valid {
 			replyImages := imagesByMessage[msg.ReplyToMessageID.UUID]
 			if replyImages == nil {
-				replyImages = []string{}
+				replyImages = []models.MessageImage{}
 			}
 
 			var replyContent *string
+			var replyEntities []models.MessageEntity
 			if msg.ReplyContent.Valid && !(msg.ReplyIsDeleted.Valid && msg.ReplyIsDeleted.Bool) {
 				replyContent = &msg.ReplyContent.String
+				replyEntities = decodeEntities(msg.ReplyEntities)
 			}
 
 			replyUsername := "Unknown"
@@ -177,10 +188,7 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 				replyUsername = msg.ReplySenderUsername.String
 			}
 
-			var replySenderProfileImageUrl *string
-			if msg.ReplySenderProfileImageUrl.Valid {
-				replySenderProfileImageUrl = &msg.ReplySenderProfileImageUrl.String
-			}
+			replySenderProfileImageUrl := signProfileImageURL(c.Request.Context(), h.store, msg.ReplySenderProfileImageUrl)
 
 			replyTo = &models.ReplyToMessage{
 				ID:                    msg.ReplyToMessageID.UUID,
@@ -188,14 +196,34 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 				SenderUsername:        replyUsername,
 				SenderProfileImageUrl: replySenderProfileImageUrl,
 				Content:               replyContent,
+				Entities:              replyEntities,
 				Images:                replyImages,
 				IsDeleted:             msg.ReplyIsDeleted.Valid && msg.ReplyIsDeleted.Bool,
 			}
 		}
 
+		var forwardedFrom *models.ForwardedFrom
+		if msg.ForwardedFromMessageID.Valid || msg.ForwardedFromSenderID.Valid {
+			forwardedUsername := "Unknown"
+			if msg.ForwardedFromSenderUsername.Valid {
+				forwardedUsername = msg.ForwardedFromSenderUsername.String
+			}
+
+			forwardedFrom = &models.ForwardedFrom{
+				SenderUsername: forwardedUsername,
+			}
+			if msg.ForwardedFromMessageID.Valid {
+				forwardedFrom.MessageID = &msg.ForwardedFromMessageID.UUID
+			}
+			if msg.ForwardedFromSenderID.Valid {
+				forwardedFrom.SenderID = &msg.ForwardedFromSenderID.UUID
+			}
+		}
+
 		messages[i] = models.Message{
 			ID:                    msg.ID,
 			Content:               content,
+			Entities:              decodeEntities(msg.Entities),
 			SenderID:              msg.SenderID,
 			SenderUsername:        msg.SenderUsername,
 			SenderProfileImageUrl: senderProfileImageUrl,
@@ -204,9 +232,92 @@ func (h *MessageHandler) GetChatMessages(c *gin.Context) {
 			Images:                images,
 			CreatedAt:             msg.CreatedAt,
 			ReplyTo:               replyTo,
+			ForwardedFrom:         forwardedFrom,
+			MessageType:           msg.MessageType,
+			Metadata:              systemMessageMetadata(msg.MessageType, msg.Metadata),
+			ExpiresAt:             utils.NullableTime(msg.ExpiresAt),
+		}
+	}
+
+	// Polls, mentions, link previews and attachments are only attached to messages that have not been deleted
+	liveMessageIDs := make([]uuid.UUID, 0, len(filteredMessages))
+	for _, msg := range filteredMessages {
+		if !msg.IsDeleted {
+			liveMessageIDs = append(liveMessageIDs, msg.ID)
+		}
+	}
+
+	if len(liveMessageIDs) > 0 {
+		pollsData, err := h.dbService.Queries.GetPollsByMessageIds(c.Request.Context(), liveMessageIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get polls",
+			})
+			return
+		}
+
+		polls, err := h.buildPolls(c.Request.Context(), pollsData, userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get polls",
+			})
+			return
+		}
+
+		for i := range messages {
+			messages[i].Poll = polls[messages[i].ID]
+		}
+	}
+
+	mentionsByMessage, err := loadMessageMentions(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message mentions",
+		})
+		return
+	}
+
+	previewsByMessage, err := loadMessageLinkPreviews(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get link previews",
+		})
+		return
+	}
+
+	attachmentsByMessage, err := loadMessageAttachments(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message attachments",
+		})
+		return
+	}
+
+	savedIDs := make(map[uuid.UUID]bool)
+	if len(liveMessageIDs) > 0 {
+		savedData, err := h.dbService.Queries.GetSavedMessageIds(c.Request.Context(), database.GetSavedMessageIdsParams{
+			UserID:     userID.(uuid.UUID),
+			MessageIds: liveMessageIDs,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get saved messages",
+			})
+			return
+		}
+
+		for _, id := range savedData {
+			savedIDs[id] = true
 		}
 	}
 
+	for i := range messages {
+		messages[i].Mentions = mentionsByMessage[messages[i].ID]
+		messages[i].LinkPreviews = previewsByMessage[messages[i].ID]
+		messages[i].Attachments = attachmentsByMessage[messages[i].ID]
+		messages[i].IsSaved = savedIDs[messages[i].ID]
+	}
+
 	// Fetch read receipts for the chat
 	readReceiptsData, err := h.dbService.Queries.GetChatReadReceipts(c.Request.Context(), chatID)
 	if err != nil {
@@ -263,21 +374,14 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	}
 
 	// Validate input
-	req.Content = strings.TrimSpace(req.Content)
-
-	if len(req.Content) > constants.MaxMessageLength {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Message content exceeds maximum length of 5000 characters",
-		})
-		return
-	}
-
-	if len(req.Images) > constants.MaxImagesPerMessage {
+	trimmedContent, entities, err := prepareMessageContent(req.Content, req.Entities)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Maximum 5 images allowed per message",
+			Error: err.Error(),
 		})
 		return
 	}
+	req.Content = trimmedContent
 
 	chatID, err := uuid.Parse(req.ChatID)
 	if err != nil {
@@ -306,10 +410,68 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// Validate that message has content or images
-	if req.Content == "" && len(req.Images) == 0 {
+	// Enforce the chat's message policies
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	if settings.WhoCanPost != constants.WhoCanPostEveryone {
+		chat, err := h.dbService.Queries.GetChatMetadata(c.Request.Context(), chatID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load chat",
+			})
+			return
+		}
+
+		if !canPostInChat(settings, chat.CreatedBy, userID.(uuid.UUID)) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Only the chat admin can post in this chat",
+			})
+			return
+		}
+	}
+
+	if len(req.Content) > int(settings.MaxMessageLength) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Message content exceeds maximum length of %d characters", settings.MaxMessageLength),
+		})
+		return
+	}
+
+	if len(req.Images) > int(settings.MaxImages) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d images allowed per message", settings.MaxImages),
+		})
+		return
+	}
+
+	// Clients of a private store send back the signed URLs they were given
+	req.Images = storedImageURLs(h.store, req.Images)
+
+	if len(req.Attachments) > constants.MaxAttachmentsPerMessage {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d attachments allowed per message", constants.MaxAttachmentsPerMessage),
+		})
+		return
+	}
+
+	attachmentIDs, err := parseAttachmentIDs(req.Attachments)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid attachment ID",
+		})
+		return
+	}
+
+	// Validate that message has content, images or attachments
+	if req.Content == "" && len(req.Images) == 0 && len(attachmentIDs) == 0 {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Message must have content or images",
+			Error: "Message must have content, images or attachments",
 		})
 		return
 	}
@@ -349,42 +511,35 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 				return
 			}
 
-			user, err := h.dbService.Queries.GetUserByID(c.Request.Context(), replyMessage.SenderID)
+			replyPayload, err = h.buildReplyPayload(c.Request.Context(), replyMessage)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-					Error: "Failed to get replied message sender",
+					Error: "Failed to fetch replied message",
 				})
 				return
 			}
 
-			replyImageRows, err := h.dbService.Queries.GetMessageImages(c.Request.Context(), []uuid.UUID{replyUUID})
-			if err != nil && err != sql.ErrNoRows {
-				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-					Error: "Failed to get replied message images",
-				})
-				return
-			}
+			replyTo = uuid.NullUUID{UUID: replyUUID, Valid: true}
+		}
+	}
 
-			replyImages := make([]string, 0, len(replyImageRows))
-			for _, img := range replyImageRows {
-				replyImages = append(replyImages, img.Url)
-			}
+	var content sql.NullString
+	if req.Content != "" {
+		content = sql.NullString{String: req.Content, Valid: true}
+	}
 
-			var replyContent *string
-			if replyMessage.Content.Valid && !replyMessage.IsDeleted {
-				replyContent = &replyMessage.Content.String
-			}
+	username, _ := c.Get("username")
 
-			replyTo = uuid.NullUUID{UUID: replyUUID, Valid: true}
-			replyPayload = &ws.ReplyMessage{
-				ID:             replyUUID.String(),
-				SenderID:       replyMessage.SenderID.String(),
-				SenderUsername: user.Username,
-				Content:        replyContent,
-				Images:         replyImages,
-				IsDeleted:      replyMessage.IsDeleted,
-			}
-		}
+	outgoing := outgoingMessage{
+		ChatID:           chatID,
+		SenderID:         userID.(uuid.UUID),
+		SenderUsername:   username.(string),
+		Content:          content,
+		Entities:         entities,
+		Images:           req.Images,
+		FileUploadIDs:    attachmentIDs,
+		ReplyToMessageID: replyTo,
+		ReplyTo:          replyPayload,
 	}
 
 	// Use transaction to ensure message creation and image addition are atomic
@@ -397,21 +552,19 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	qtx := h.dbService.Queries.WithTx(tx)
-
-	// Create message
-	var content sql.NullString
-	if req.Content != "" {
-		content = sql.NullString{String: req.Content, Valid: true}
+	message, err := createMessage(c.Request.Context(), h.dbService.Queries.WithTx(tx), outgoing)
+	if errors.Is(err, errUnavailableImages) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Images must be your own uploads and can only be sent once",
+		})
+		return
+	}
+	if errors.Is(err, errUnavailableAttachments) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Attachments must be your own uploads and can only be sent once",
+		})
+		return
 	}
-
-	message, err := qtx.CreateMessage(c.Request.Context(), database.CreateMessageParams{
-		ChatID:           chatID,
-		SenderID:         userID.(uuid.UUID),
-		Content:          content,
-		ReplyToMessageID: replyTo,
-	})
-
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to create message",
@@ -419,57 +572,161 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// Add images if any
-	for _, imageUrl := range req.Images {
-		err := qtx.AddMessageImage(c.Request.Context(), database.AddMessageImageParams{
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	h.broadcastMessageSent(outgoing, message)
+	h.enqueueLinkPreviews(message.Message, outgoing.Entities)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message_id": message.ID,
+	})
+}
+
+// outgoingMessage is a validated message ready to be stored and broadcast
+type outgoingMessage struct {
+	ChatID           uuid.UUID
+	SenderID         uuid.UUID
+	SenderUsername   string
+	Content          sql.NullString
+	Entities         []models.MessageEntity
+	Images           []string
+	FileUploadIDs    []uuid.UUID
+	ReplyToMessageID uuid.NullUUID
+	ReplyTo          *ws.ReplyMessage
+	// ImagesReserved is set when the images were already claimed, as scheduled messages do when scheduling
+	ImagesReserved bool
+}
+
+// createdMessage is what createMessage stored, for broadcasting once the transaction commits
+type createdMessage struct {
+	database.Message
+	Mentions    []models.MessageMention
+	Images      []models.MessageImage
+	Attachments []models.Attachment
+}
+
+// createMessage stores a message with its images, attachments and mentions, leaving the transaction to
+// the caller. The files are claimed as the sender's uploads, so forwarded copies must not go through here.
+func createMessage(ctx context.Context, qtx *database.Queries, msg outgoingMessage) (createdMessage, error) {
+	mentions, err := resolveMentions(ctx, qtx, msg.ChatID, msg.Content)
+	if err != nil {
+		return createdMessage{}, err
+	}
+
+	merged, mentions := withMentionEntities(msg.Entities, mentions)
+	entities, err := encodeEntities(merged)
+	if err != nil {
+		return createdMessage{}, err
+	}
+
+	message, err := qtx.CreateMessage(ctx, database.CreateMessageParams{
+		ChatID:           msg.ChatID,
+		SenderID:         msg.SenderID,
+		Content:          msg.Content,
+		ReplyToMessageID: msg.ReplyToMessageID,
+		Entities:         entities,
+	})
+	if err != nil {
+		return createdMessage{}, err
+	}
+
+	if !msg.ImagesReserved {
+		if err := claimUploads(ctx, qtx, msg.SenderID, msg.Images); err != nil {
+			return createdMessage{}, err
+		}
+	}
+
+	images := make([]models.MessageImage, 0, len(msg.Images))
+	for _, imageUrl := range msg.Images {
+		image, err := qtx.AddMessageImage(ctx, database.AddMessageImageParams{
 			MessageID: message.ID,
 			Url:       imageUrl,
 		})
-
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error: "Failed to add message image",
-			})
-			return
+			return createdMessage{}, err
 		}
+		images = append(images, toMessageImage(image))
 	}
 
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to commit transaction",
-		})
-		return
+	attachments, err := addMessageAttachments(ctx, qtx, message.ID, msg.SenderID, msg.FileUploadIDs)
+	if err != nil {
+		return createdMessage{}, err
 	}
 
-	// Get username for WebSocket broadcast
-	username, _ := c.Get("username")
+	if err := saveMessageMentions(ctx, qtx, message.ID, mentions); err != nil {
+		return createdMessage{}, err
+	}
 
-	// Prepare content for broadcast
+	return createdMessage{
+		Message:     message,
+		Mentions:    mentions,
+		Images:      images,
+		Attachments: attachments,
+	}, nil
+}
+
+// broadcastMessageSent announces a newly created message to everyone in the chat
+func (h *MessageHandler) broadcastMessageSent(msg outgoingMessage, message createdMessage) {
 	var broadcastContent *string
-	if content.Valid {
-		broadcastContent = &content.String
+	if msg.Content.Valid {
+		broadcastContent = &msg.Content.String
 	}
 
-	// Broadcast message to WebSocket clients
-	h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+	h.hub.BroadcastToChat(msg.ChatID.String(), ws.WSMessage{
 		Type: ws.EventMessageSent,
 		Payload: ws.MessageSentPayload{
 			ID:             message.ID.String(),
-			ChatID:         chatID.String(),
-			SenderID:       userID.(uuid.UUID).String(),
-			SenderUsername: username.(string),
+			ChatID:         msg.ChatID.String(),
+			SenderID:       msg.SenderID.String(),
+			SenderUsername: msg.SenderUsername,
 			Content:        broadcastContent,
-			Images:         req.Images,
+			Images:         toImagePayloads(signMessageImages(context.Background(), h.store, message.Images)),
+			Attachments:    toAttachmentPayloads(message.Attachments),
 			IsDeleted:      false,
 			IsEdited:       false,
 			CreatedAt:      message.CreatedAt,
-			ReplyTo:        replyPayload,
+			ReplyTo:        msg.ReplyTo,
+			Mentions:       toMentionEntities(message.Mentions),
+			Entities:       toEntityPayloads(decodeEntities(message.Entities)),
+			MessageType:    message.MessageType,
+			ExpiresAt:      utils.NullableTime(message.ExpiresAt),
 		},
 	})
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message_id": message.ID,
-	})
+// buildReplyPayload loads the sender and images clients need to render a reply preview
+func (h *MessageHandler) buildReplyPayload(ctx context.Context, replyMessage database.GetMessageByIdRow) (*ws.ReplyMessage, error) {
+	user, err := h.dbService.Queries.GetUserByID(ctx, replyMessage.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	replyImageRows, err := h.dbService.Queries.GetMessageImages(ctx, []uuid.UUID{replyMessage.ID})
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var replyContent *string
+	var replyEntities []ws.MessageEntity
+	if replyMessage.Content.Valid && !replyMessage.IsDeleted {
+		replyContent = &replyMessage.Content.String
+		replyEntities = toEntityPayloads(decodeEntities(replyMessage.Entities))
+	}
+
+	return &ws.ReplyMessage{
+		ID:             replyMessage.ID.String(),
+		SenderID:       replyMessage.SenderID.String(),
+		SenderUsername: user.Username,
+		Content:        replyContent,
+		Entities:       replyEntities,
+		Images:         toImagePayloads(signMessageImages(ctx, h.store, toMessageImages(replyImageRows))),
+		IsDeleted:      replyMessage.IsDeleted,
+	}, nil
 }
 
 func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandler) {
@@ -497,14 +754,14 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 		return
 	}
 
-	req.Content = strings.TrimSpace(req.Content)
-
-	if len(req.Content) > constants.MaxMessageLength {
+	trimmedContent, entities, err := prepareMessageContent(req.Content, req.Entities)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Message content exceeds maximum length of 5000 characters",
+			Error: err.Error(),
 		})
 		return
 	}
+	req.Content = trimmedContent
 
 	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
 	if err != nil {
@@ -534,9 +791,53 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 		return
 	}
 
-	if time.Since(message.CreatedAt) > 15*time.Minute {
+	if message.MessageType == constants.MessageTypeSystem {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "System messages cannot be edited",
+		})
+		return
+	}
+
+	isPoll, err := h.isPollMessage(c.Request.Context(), messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get poll",
+		})
+		return
+	}
+
+	if isPoll {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Polls cannot be edited",
+		})
+		return
+	}
+
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), message.ChatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	if settings.EditWindowSeconds == 0 {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "Editing messages is disabled in this chat",
+		})
+		return
+	}
+
+	if time.Since(message.CreatedAt) > time.Duration(settings.EditWindowSeconds)*time.Second {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Messages can only be edited within %s of sending", formatDuration(settings.EditWindowSeconds)),
+		})
+		return
+	}
+
+	if len(req.Content) > int(settings.MaxMessageLength) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Messages can only be edited within 15 minutes of sending",
+			Error: fmt.Sprintf("Message content exceeds maximum length of %d characters", settings.MaxMessageLength),
 		})
 		return
 	}
@@ -552,14 +853,41 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 
 	qtx := h.dbService.Queries.WithTx(tx)
 
+	// Snapshot the current content and images before overwriting them
+	if err := qtx.CreateMessageRevision(c.Request.Context(), messageID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to save message revision",
+		})
+		return
+	}
+
 	var content sql.NullString
 	if req.Content != "" {
 		content = sql.NullString{String: req.Content, Valid: true}
 	}
 
+	// Re-resolve mentions against the edited content
+	mentions, err := resolveMentions(c.Request.Context(), qtx, message.ChatID, content)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to resolve message mentions",
+		})
+		return
+	}
+
+	entities, mentions = withMentionEntities(entities, mentions)
+	encodedEntities, err := encodeEntities(entities)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to encode message entities",
+		})
+		return
+	}
+
 	err = qtx.EditMessage(c.Request.Context(), database.EditMessageParams{
-		ID:      messageID,
-		Content: content,
+		ID:       messageID,
+		Content:  content,
+		Entities: encodedEntities,
 	})
 
 	if err != nil {
@@ -569,7 +897,26 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 		return
 	}
 
+	if err := qtx.DeleteMessageMentions(c.Request.Context(), messageID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update message mentions",
+		})
+		return
+	}
+
+	if err := saveMessageMentions(c.Request.Context(), qtx, messageID, mentions); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update message mentions",
+		})
+		return
+	}
+
 	if len(req.RemovedImages) > 0 {
+		// Clients of a private store send back the signed URLs they were given
+		for i, imageURL := range req.RemovedImages {
+			req.RemovedImages[i] = storedImageURL(uploadHandler.store, imageURL)
+		}
+
 		err = qtx.DeleteMessageImages(c.Request.Context(), database.DeleteMessageImagesParams{
 			MessageID: messageID,
 			Column2:   req.RemovedImages,
@@ -582,7 +929,15 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 			return
 		}
 
-		for _, imageURL := range req.RemovedImages {
+		removedImages, err := unreferencedImageURLs(c.Request.Context(), qtx, req.RemovedImages)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to check image references",
+			})
+			return
+		}
+
+		for _, imageURL := range removedImages {
 			if err := uploadHandler.DeleteImage(imageURL); err != nil {
 				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 					Error: "Failed to delete image from storage",
@@ -608,11 +963,6 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 		return
 	}
 
-	imageUrls := make([]string, 0, len(remainingImages))
-	for _, img := range remainingImages {
-		imageUrls = append(imageUrls, img.Url)
-	}
-
 	// Prepare content for broadcast
 	var broadcastContent *string
 	if content.Valid {
@@ -626,7 +976,9 @@ func (h *MessageHandler) EditMessage(c *gin.Context, uploadHandler *UploadHandle
 			ID:        messageID.String(),
 			ChatID:    message.ChatID.String(),
 			Content:   broadcastContent,
-			Images:    imageUrls,
+			Images:    toImagePayloads(signMessageImages(c.Request.Context(), h.store, toMessageImages(remainingImages))),
+			Mentions:  toMentionEntities(mentions),
+			Entities:  toEntityPayloads(entities),
 			IsEdited:  true,
 			UpdatedAt: time.Now(),
 		},
@@ -683,6 +1035,28 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		return
 	}
 
+	if message.MessageType == constants.MessageTypeSystem {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "System messages cannot be deleted",
+		})
+		return
+	}
+
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), message.ChatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	if !settings.AllowDeletes {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "Deleting messages is disabled in this chat",
+		})
+		return
+	}
+
 	images, err := h.dbService.Queries.GetMessageImages(c.Request.Context(), []uuid.UUID{messageID})
 	if err != nil && err != sql.ErrNoRows {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -691,6 +1065,14 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		return
 	}
 
+	attachments, err := h.dbService.Queries.GetMessageAttachments(c.Request.Context(), []uuid.UUID{messageID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message attachments",
+		})
+		return
+	}
+
 	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -710,6 +1092,13 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		return
 	}
 
+	if err := qtx.DeleteMessageRemindersByMessages(c.Request.Context(), []uuid.UUID{messageID}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to delete message reminders",
+		})
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to commit transaction",
@@ -717,8 +1106,21 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		return
 	}
 
+	imageURLs := make([]string, 0, len(images))
 	for _, image := range images {
-		if err := uploadHandler.DeleteImage(image.Url); err != nil {
+		imageURLs = append(imageURLs, image.Url)
+	}
+
+	imageURLs, err = unreferencedImageURLs(c.Request.Context(), h.dbService.Queries, imageURLs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to check image references",
+		})
+		return
+	}
+
+	for _, imageURL := range imageURLs {
+		if err := uploadHandler.DeleteImage(imageURL); err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error: "Failed to delete image from storage",
 			})
@@ -726,6 +1128,13 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		}
 	}
 
+	if err := uploadHandler.DeleteAttachmentFiles(c.Request.Context(), attachments); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to delete attachments from storage",
+		})
+		return
+	}
+
 	// Broadcast delete to WebSocket clients
 	h.hub.BroadcastToChat(message.ChatID.String(), ws.WSMessage{
 		Type: ws.EventMessageDeleted,
@@ -740,3 +1149,168 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context, uploadHandler *UploadHand
 		"success": true,
 	})
 }
+
+func (h *MessageHandler) GetMessageHistory(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.GetMessageHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: message.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	if message.IsDeleted {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Cannot view history of a deleted message",
+		})
+		return
+	}
+
+	revisionsData, err := h.dbService.Queries.GetMessageRevisions(c.Request.Context(), messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message history",
+		})
+		return
+	}
+
+	revisions := make([]models.MessageRevision, len(revisionsData))
+	for i, revision := range revisionsData {
+		images := signImageURLs(c.Request.Context(), h.store, revision.Images)
+
+		revisions[i] = models.MessageRevision{
+			ID:       revision.ID,
+			Content:  utils.NullableString(revision.Content),
+			Images:   images,
+			EditedAt: revision.EditedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, models.GetMessageHistoryResponse{
+		MessageID: messageID,
+		Revisions: revisions,
+	})
+}
+
+func (h *MessageHandler) DeleteMessageForMe(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.DeleteMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: message.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	if err := h.dbService.Queries.HideMessage(c.Request.Context(), database.HideMessageParams{
+		UserID:    userID,
+		MessageID: messageID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to delete message",
+		})
+		return
+	}
+
+	// Only the user's own sessions need to drop the message
+	h.hub.SendToUser(userID.String(), ws.WSMessage{
+		Type: ws.EventMessageHidden,
+		Payload: ws.MessageHiddenPayload{
+			ID:     messageID.String(),
+			ChatID: message.ChatID.String(),
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
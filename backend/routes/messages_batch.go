@@ -0,0 +1,377 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// Maximum number of concurrent storage deletions per batch request
+const batchImageDeleteConcurrency = 8
+
+func (h *MessageHandler) DeleteMessages(c *gin.Context, uploadHandler *UploadHandler) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	messageIDs, ok := parseBatchMessageIDs(c)
+	if !ok {
+		return
+	}
+
+	messagesData, err := h.dbService.Queries.GetMessagesByIds(c.Request.Context(), messageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get messages",
+		})
+		return
+	}
+
+	messagesByID := make(map[uuid.UUID]database.GetMessagesByIdsRow, len(messagesData))
+	for _, msg := range messagesData {
+		messagesByID[msg.ID] = msg
+	}
+
+	results := make(map[uuid.UUID]*models.BatchMessageResult, len(messageIDs))
+	settingsByChat := make(map[uuid.UUID]database.ChatSetting)
+	var deletableIDs []uuid.UUID
+
+	for _, messageID := range messageIDs {
+		msg, found := messagesByID[messageID]
+		if !found {
+			results[messageID] = batchFailure(messageID, "Message not found")
+			continue
+		}
+
+		if msg.SenderID != userID {
+			results[messageID] = batchFailure(messageID, "You can only delete your own messages")
+			continue
+		}
+
+		if msg.MessageType == constants.MessageTypeSystem {
+			results[messageID] = batchFailure(messageID, "System messages cannot be deleted")
+			continue
+		}
+
+		settings, loaded := settingsByChat[msg.ChatID]
+		if !loaded {
+			settings, err = h.dbService.Queries.GetChatSettings(c.Request.Context(), msg.ChatID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to load chat settings",
+				})
+				return
+			}
+			settingsByChat[msg.ChatID] = settings
+		}
+
+		if !settings.AllowDeletes {
+			results[messageID] = batchFailure(messageID, "Deleting messages is disabled in this chat")
+			continue
+		}
+
+		results[messageID] = &models.BatchMessageResult{
+			MessageID: messageID.String(),
+			Success:   true,
+		}
+
+		// Already deleted messages are reported as successful without being touched again
+		if !msg.IsDeleted {
+			deletableIDs = append(deletableIDs, messageID)
+		}
+	}
+
+	if len(deletableIDs) > 0 {
+		images, err := h.dbService.Queries.GetMessageImages(c.Request.Context(), deletableIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get message images",
+			})
+			return
+		}
+
+		attachments, err := h.dbService.Queries.GetMessageAttachments(c.Request.Context(), deletableIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get message attachments",
+			})
+			return
+		}
+
+		tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to begin transaction",
+			})
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.dbService.Queries.WithTx(tx)
+
+		if err := qtx.DeleteMessages(c.Request.Context(), deletableIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to delete messages",
+			})
+			return
+		}
+
+		if err := qtx.DeleteMessageRemindersByMessages(c.Request.Context(), deletableIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to delete message reminders",
+			})
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to commit transaction",
+			})
+			return
+		}
+
+		imageURLs := make([]string, 0, len(images))
+		for _, image := range images {
+			imageURLs = append(imageURLs, image.Url)
+		}
+
+		unreferenced, err := unreferencedImageURLs(c.Request.Context(), h.dbService.Queries, imageURLs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to check image references",
+			})
+			return
+		}
+
+		unreferencedSet := make(map[string]struct{}, len(unreferenced))
+		for _, url := range unreferenced {
+			unreferencedSet[url] = struct{}{}
+		}
+
+		// Remove images from storage in parallel, recording failures against their message
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		sem := make(chan struct{}, batchImageDeleteConcurrency)
+		for _, image := range images {
+			if _, ok := unreferencedSet[image.Url]; !ok {
+				continue
+			}
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(image database.Image) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if err := uploadHandler.DeleteImage(image.Url); err != nil {
+					mu.Lock()
+					results[image.MessageID] = batchFailure(image.MessageID, "Message deleted but failed to delete image from storage")
+					mu.Unlock()
+				}
+			}(image)
+		}
+		wg.Wait()
+
+		if err := uploadHandler.DeleteAttachmentFiles(c.Request.Context(), attachments); err != nil {
+			for _, attachment := range attachments {
+				results[attachment.MessageID] = batchFailure(attachment.MessageID, "Message deleted but failed to delete attachments from storage")
+			}
+		}
+
+		// Broadcast one combined event per chat
+		deletedByChat := make(map[uuid.UUID][]string)
+		for _, messageID := range deletableIDs {
+			chatID := messagesByID[messageID].ChatID
+			deletedByChat[chatID] = append(deletedByChat[chatID], messageID.String())
+		}
+
+		for chatID, ids := range deletedByChat {
+			h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+				Type: ws.EventMessagesDeleted,
+				Payload: ws.MessagesDeletedPayload{
+					ChatID:     chatID.String(),
+					MessageIDs: ids,
+					IsDeleted:  true,
+				},
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, buildBatchResponse(messageIDs, results))
+}
+
+func (h *MessageHandler) DeleteMessagesForMe(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	messageIDs, ok := parseBatchMessageIDs(c)
+	if !ok {
+		return
+	}
+
+	messagesData, err := h.dbService.Queries.GetMessagesByIds(c.Request.Context(), messageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get messages",
+		})
+		return
+	}
+
+	messagesByID := make(map[uuid.UUID]database.GetMessagesByIdsRow, len(messagesData))
+	for _, msg := range messagesData {
+		messagesByID[msg.ID] = msg
+	}
+
+	results := make(map[uuid.UUID]*models.BatchMessageResult, len(messageIDs))
+	membershipByChat := make(map[uuid.UUID]bool)
+	var hideableIDs []uuid.UUID
+
+	for _, messageID := range messageIDs {
+		msg, found := messagesByID[messageID]
+		if !found {
+			results[messageID] = batchFailure(messageID, "Message not found")
+			continue
+		}
+
+		isMember, checked := membershipByChat[msg.ChatID]
+		if !checked {
+			isMember, err = h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+				ChatID: msg.ChatID,
+				UserID: userID,
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to verify chat membership",
+				})
+				return
+			}
+			membershipByChat[msg.ChatID] = isMember
+		}
+
+		if !isMember {
+			results[messageID] = batchFailure(messageID, "You are not a member of this chat")
+			continue
+		}
+
+		results[messageID] = &models.BatchMessageResult{
+			MessageID: messageID.String(),
+			Success:   true,
+		}
+		hideableIDs = append(hideableIDs, messageID)
+	}
+
+	if len(hideableIDs) > 0 {
+		tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to begin transaction",
+			})
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.dbService.Queries.WithTx(tx)
+
+		if err := qtx.HideMessages(c.Request.Context(), database.HideMessagesParams{
+			UserID:     userID,
+			MessageIds: hideableIDs,
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to delete messages",
+			})
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to commit transaction",
+			})
+			return
+		}
+
+		hidden := make([]ws.MessageHiddenPayload, len(hideableIDs))
+		for i, messageID := range hideableIDs {
+			hidden[i] = ws.MessageHiddenPayload{
+				ID:     messageID.String(),
+				ChatID: messagesByID[messageID].ChatID.String(),
+			}
+		}
+
+		h.hub.SendToUser(userID.String(), ws.WSMessage{
+			Type: ws.EventMessagesHidden,
+			Payload: ws.MessagesHiddenPayload{
+				Messages: hidden,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, buildBatchResponse(messageIDs, results))
+}
+
+// parseBatchMessageIDs binds a batch request and returns its de-duplicated message IDs in request order
+func parseBatchMessageIDs(c *gin.Context) ([]uuid.UUID, bool) {
+	var req models.BatchMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return nil, false
+	}
+
+	if len(req.MessageIDs) > constants.MaxBatchMessageIDs {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d messages allowed per batch", constants.MaxBatchMessageIDs),
+		})
+		return nil, false
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(req.MessageIDs))
+	messageIDs := make([]uuid.UUID, 0, len(req.MessageIDs))
+	for _, rawID := range req.MessageIDs {
+		messageID, err := uuid.Parse(rawID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Invalid message ID: %s", rawID),
+			})
+			return nil, false
+		}
+
+		if _, dup := seen[messageID]; dup {
+			continue
+		}
+		seen[messageID] = struct{}{}
+		messageIDs = append(messageIDs, messageID)
+	}
+
+	return messageIDs, true
+}
+
+func batchFailure(messageID uuid.UUID, reason string) *models.BatchMessageResult {
+	return &models.BatchMessageResult{
+		MessageID: messageID.String(),
+		Success:   false,
+		Error:     &reason,
+	}
+}
+
+func buildBatchResponse(messageIDs []uuid.UUID, results map[uuid.UUID]*models.BatchMessageResult) models.BatchMessagesResponse {
+	response := models.BatchMessagesResponse{
+		Results: make([]models.BatchMessageResult, len(messageIDs)),
+	}
+	for i, messageID := range messageIDs {
+		response.Results[i] = *results[messageID]
+	}
+	return response
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestDeleteMessagesReportsEachMessage(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	lockedChatID := createTestChat(t, db, alice, bob)
+	if _, err := db.Exec(`UPDATE chat_settings SET allow_deletes = false WHERE chat_id = $1`, lockedChatID); err != nil {
+		t.Fatalf("update chat settings: %v", err)
+	}
+
+	own := createTestMessage(t, db, chatID, alice, "mine", time.Now(), time.Time{})
+	others := createTestMessage(t, db, chatID, bob, "theirs", time.Now(), time.Time{})
+	locked := createTestMessage(t, db, lockedChatID, alice, "kept", time.Now(), time.Time{})
+	missing := uuid.New()
+
+	requested := []uuid.UUID{own, others, locked, missing, own}
+	messageIDs := make([]string, len(requested))
+	for i, messageID := range requested {
+		messageIDs[i] = messageID.String()
+	}
+	body, err := json.Marshal(models.BatchMessagesRequest{MessageIDs: messageIDs})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", alice)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/delete", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.DeleteMessages(c, &UploadHandler{})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("DeleteMessages() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var response models.BatchMessagesResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	// Results follow the request order with the repeated ID reported once
+	want := []struct {
+		messageID uuid.UUID
+		success   bool
+	}{
+		{messageID: own, success: true},
+		{messageID: others, success: false},
+		{messageID: locked, success: false},
+		{messageID: missing, success: false},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(response.Results), len(want))
+	}
+	for i, result := range response.Results {
+		if result.MessageID != want[i].messageID.String() || result.Success != want[i].success {
+			t.Errorf("result %d = %+v, want message %s with success %t", i, result, want[i].messageID, want[i].success)
+		}
+		if !result.Success && result.Error == nil {
+			t.Errorf("result %d failed without a reason", i)
+		}
+	}
+
+	for _, tt := range []struct {
+		messageID   uuid.UUID
+		wantDeleted bool
+	}{
+		{messageID: own, wantDeleted: true},
+		{messageID: others, wantDeleted: false},
+		{messageID: locked, wantDeleted: false},
+	} {
+		message, err := dbService.Queries.GetMessageById(context.Background(), tt.messageID)
+		if err != nil {
+			t.Fatalf("get message: %v", err)
+		}
+		if message.IsDeleted != tt.wantDeleted {
+			t.Errorf("message %s deleted = %t, want %t", tt.messageID, message.IsDeleted, tt.wantDeleted)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package routes
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func (h *MessageHandler) ForwardMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.ForwardMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	if len(req.ChatIDs) > constants.MaxForwardTargets {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d chats allowed per forward", constants.MaxForwardTargets),
+		})
+		return
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(req.ChatIDs))
+	targetChatIDs := make([]uuid.UUID, 0, len(req.ChatIDs))
+	for _, rawID := range req.ChatIDs {
+		chatID, err := uuid.Parse(rawID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Invalid chat ID: %s", rawID),
+			})
+			return
+		}
+
+		if _, dup := seen[chatID]; dup {
+			continue
+		}
+		seen[chatID] = struct{}{}
+		targetChatIDs = append(targetChatIDs, chatID)
+	}
+
+	source, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	// Verify user is member of the source chat
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: source.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	if source.IsDeleted {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Cannot forward a deleted message",
+		})
+		return
+	}
+
+	if source.MessageType == constants.MessageTypeSystem {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "System messages cannot be forwarded",
+		})
+		return
+	}
+
+	isPoll, err := h.isPollMessage(c.Request.Context(), source.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get poll",
+		})
+		return
+	}
+
+	if isPoll {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Polls cannot be forwarded",
+		})
+		return
+	}
+
+	imageRows, err := h.dbService.Queries.GetMessageImages(c.Request.Context(), []uuid.UUID{source.ID})
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message images",
+		})
+		return
+	}
+
+	images := make([]string, 0, len(imageRows))
+	for _, img := range imageRows {
+		images = append(images, img.Url)
+	}
+
+	// Forwarding a forwarded message keeps the original attribution
+	forwardedMessageID := uuid.NullUUID{UUID: source.ID, Valid: true}
+	forwardedSenderID := uuid.NullUUID{UUID: source.SenderID, Valid: true}
+	if source.ForwardedFromMessageID.Valid || source.ForwardedFromSenderID.Valid {
+		forwardedMessageID = source.ForwardedFromMessageID
+		forwardedSenderID = source.ForwardedFromSenderID
+	}
+
+	forwardedUsername := "Unknown"
+	if forwardedSenderID.Valid {
+		sender, err := h.dbService.Queries.GetUserByID(c.Request.Context(), forwardedSenderID.UUID)
+		if err != nil && err != sql.ErrNoRows {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get original message sender",
+			})
+			return
+		}
+		if err == nil {
+			forwardedUsername = sender.Username
+		}
+	}
+
+	// Mentions are resolved again against each target chat's members
+	sourceEntities := withoutMentionEntities(decodeEntities(source.Entities))
+
+	contentLength := 0
+	if source.Content.Valid {
+		contentLength = len(source.Content.String)
+	}
+
+	results := make(map[uuid.UUID]*models.ForwardMessageResult, len(targetChatIDs))
+	var eligibleChatIDs []uuid.UUID
+
+	for _, chatID := range targetChatIDs {
+		isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+			ChatID: chatID,
+			UserID: userID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to verify chat membership",
+			})
+			return
+		}
+
+		if !isMember {
+			results[chatID] = forwardFailure(chatID, "You are not a member of this chat")
+			continue
+		}
+
+		// Enforce the target chat's message policies
+		settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load chat settings",
+			})
+			return
+		}
+
+		if settings.WhoCanPost != constants.WhoCanPostEveryone {
+			chat, err := h.dbService.Queries.GetChatMetadata(c.Request.Context(), chatID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to load chat",
+				})
+				return
+			}
+
+			if !canPostInChat(settings, chat.CreatedBy, userID) {
+				results[chatID] = forwardFailure(chatID, "Only the chat admin can post in this chat")
+				continue
+			}
+		}
+
+		if contentLength > int(settings.MaxMessageLength) {
+			results[chatID] = forwardFailure(chatID, fmt.Sprintf("Message content exceeds maximum length of %d characters", settings.MaxMessageLength))
+			continue
+		}
+
+		if len(images) > int(settings.MaxImages) {
+			results[chatID] = forwardFailure(chatID, fmt.Sprintf("Maximum %d images allowed per message", settings.MaxImages))
+			continue
+		}
+
+		eligibleChatIDs = append(eligibleChatIDs, chatID)
+	}
+
+	forwarded := make(map[uuid.UUID]database.Message, len(eligibleChatIDs))
+	forwardedAttachments := make(map[uuid.UUID][]models.Attachment, len(eligibleChatIDs))
+	forwardedMentions := make(map[uuid.UUID][]models.MessageMention, len(eligibleChatIDs))
+	if len(eligibleChatIDs) > 0 {
+		// Use transaction so either every eligible chat receives the message or none do
+		tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to begin transaction",
+			})
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.dbService.Queries.WithTx(tx)
+
+		for _, chatID := range eligibleChatIDs {
+			mentions, err := resolveMentions(c.Request.Context(), qtx, chatID, source.Content)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to add message mentions",
+				})
+				return
+			}
+
+			merged, mentions := withMentionEntities(sourceEntities, mentions)
+			entities, err := encodeEntities(merged)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to forward message",
+				})
+				return
+			}
+
+			message, err := qtx.CreateForwardedMessage(c.Request.Context(), database.CreateForwardedMessageParams{
+				ChatID:                 chatID,
+				SenderID:               userID,
+				Content:                source.Content,
+				ForwardedFromMessageID: forwardedMessageID,
+				ForwardedFromSenderID:  forwardedSenderID,
+				Entities:               entities,
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to forward message",
+				})
+				return
+			}
+
+			if err := saveMessageMentions(c.Request.Context(), qtx, message.ID, mentions); err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to add message mentions",
+				})
+				return
+			}
+
+			// Forwarded messages share the stored images of the original
+			for _, imageUrl := range images {
+				if _, err := qtx.AddMessageImage(c.Request.Context(), database.AddMessageImageParams{
+					MessageID: message.ID,
+					Url:       imageUrl,
+				}); err != nil {
+					c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+						Error: "Failed to add message image",
+					})
+					return
+				}
+			}
+
+			// Attachments share the stored file too, but get their own IDs so downloads are
+			// checked against the chat they were forwarded to
+			attachmentRows, err := qtx.CopyMessageAttachments(c.Request.Context(), database.CopyMessageAttachmentsParams{
+				TargetMessageID: message.ID,
+				SourceMessageID: source.ID,
+			})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to add message attachments",
+				})
+				return
+			}
+
+			forwarded[chatID] = message
+			forwardedAttachments[chatID] = toAttachments(attachmentRows)
+			forwardedMentions[chatID] = mentions
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to commit transaction",
+			})
+			return
+		}
+	}
+
+	username, _ := c.Get("username")
+
+	var broadcastContent *string
+	if source.Content.Valid {
+		broadcastContent = &source.Content.String
+	}
+
+	forwardedPayload := &ws.ForwardedFrom{
+		SenderUsername: forwardedUsername,
+	}
+	if forwardedMessageID.Valid {
+		id := forwardedMessageID.UUID.String()
+		forwardedPayload.MessageID = &id
+	}
+	if forwardedSenderID.Valid {
+		id := forwardedSenderID.UUID.String()
+		forwardedPayload.SenderID = &id
+	}
+
+	imagePayloads := toImagePayloads(signMessageImages(c.Request.Context(), h.store, toMessageImages(imageRows)))
+
+	for _, chatID := range eligibleChatIDs {
+		message := forwarded[chatID]
+		entities := decodeEntities(message.Entities)
+
+		h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+			Type: ws.EventMessageSent,
+			Payload: ws.MessageSentPayload{
+				ID:             message.ID.String(),
+				ChatID:         chatID.String(),
+				SenderID:       userID.String(),
+				SenderUsername: username.(string),
+				Content:        broadcastContent,
+				Mentions:       toMentionEntities(forwardedMentions[chatID]),
+				Entities:       toEntityPayloads(entities),
+				Images:         imagePayloads,
+				Attachments:    toAttachmentPayloads(forwardedAttachments[chatID]),
+				IsDeleted:      false,
+				IsEdited:       false,
+				CreatedAt:      message.CreatedAt,
+				ForwardedFrom:  forwardedPayload,
+				MessageType:    message.MessageType,
+				ExpiresAt:      utils.NullableTime(message.ExpiresAt),
+			},
+		})
+
+		// Previews are stored per message, and the worker reuses the cached unfurl of each link
+		h.enqueueLinkPreviews(message, entities)
+
+		results[chatID] = &models.ForwardMessageResult{
+			ChatID:    chatID.String(),
+			Success:   true,
+			MessageID: &message.ID,
+		}
+	}
+
+	response := models.ForwardMessageResponse{
+		Results: make([]models.ForwardMessageResult, len(targetChatIDs)),
+	}
+	for i, chatID := range targetChatIDs {
+		response.Results[i] = *results[chatID]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func forwardFailure(chatID uuid.UUID, reason string) *models.ForwardMessageResult {
+	return &models.ForwardMessageResult{
+		ChatID:  chatID.String(),
+		Success: false,
+		Error:   &reason,
+	}
+}
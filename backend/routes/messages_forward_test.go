@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestForwardMessageKeepsOriginalSender(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	sourceChat := createTestChat(t, db, alice, bob)
+	aliceAndCarol := createTestChat(t, db, alice, carol)
+	bobAndCarol := createTestChat(t, db, bob, carol)
+	sourceID := createTestMessage(t, db, sourceChat, bob, "hello", time.Now(), time.Time{})
+
+	forward := func(userID uuid.UUID, username string, messageID uuid.UUID, chatIDs ...uuid.UUID) []models.ForwardMessageResult {
+		t.Helper()
+
+		request := models.ForwardMessageRequest{MessageID: messageID.String()}
+		for _, chatID := range chatIDs {
+			request.ChatIDs = append(request.ChatIDs, chatID.String())
+		}
+		body, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Set("username", username)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/forward", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.ForwardMessage(c)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("ForwardMessage() status = %d, body %s", recorder.Code, recorder.Body)
+		}
+
+		var response models.ForwardMessageResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return response.Results
+	}
+
+	checkCopy := func(result models.ForwardMessageResult) {
+		t.Helper()
+
+		if !result.Success || result.MessageID == nil {
+			t.Fatalf("forward to %s = %+v, want a success", result.ChatID, result)
+		}
+		copied, err := queries.GetMessageById(ctx, *result.MessageID)
+		if err != nil {
+			t.Fatalf("get forwarded message: %v", err)
+		}
+		if copied.ForwardedFromMessageID.UUID != sourceID || copied.ForwardedFromSenderID.UUID != bob {
+			t.Errorf("forwarded from message %v sent by %v, want message %s sent by bob", copied.ForwardedFromMessageID, copied.ForwardedFromSenderID, sourceID)
+		}
+	}
+
+	results := forward(alice, "alice", sourceID, aliceAndCarol, bobAndCarol)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	checkCopy(results[0])
+	if results[1].Success {
+		t.Errorf("forwarded into a chat alice is not in: %+v", results[1])
+	}
+
+	// Forwarding the copy again still credits the original message and sender
+	results = forward(carol, "carol", *results[0].MessageID, bobAndCarol)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	checkCopy(results[0])
+}
+
+func TestForwardMessageResolvesMentionsInTargetChat(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	sourceChat := createTestChat(t, db, alice, bob)
+	targetChat := createTestChat(t, db, alice, carol)
+
+	content := "hi @bob and @carol, see https://example.com"
+	source, err := createMessage(ctx, queries, outgoingMessage{
+		ChatID:   sourceChat,
+		SenderID: alice,
+		Content:  sql.NullString{String: content, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("create source message: %v", err)
+	}
+	if len(source.Mentions) != 1 || source.Mentions[0].UserID != bob {
+		t.Fatalf("source mentions = %+v, want only bob", source.Mentions)
+	}
+
+	body, err := json.Marshal(models.ForwardMessageRequest{
+		MessageID: source.ID.String(),
+		ChatIDs:   []string{targetChat.String()},
+	})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", alice)
+	c.Set("username", "alice")
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/forward", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.ForwardMessage(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var response models.ForwardMessageResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(response.Results) != 1 || !response.Results[0].Success {
+		t.Fatalf("results = %+v, want one success", response.Results)
+	}
+	forwardedID := *response.Results[0].MessageID
+
+	mentions, err := loadMessageMentions(ctx, queries, []uuid.UUID{forwardedID})
+	if err != nil {
+		t.Fatalf("load mentions: %v", err)
+	}
+	if got := mentions[forwardedID]; len(got) != 1 || got[0].UserID != carol {
+		t.Errorf("forwarded mentions = %+v, want only carol", got)
+	}
+
+	forwarded, err := queries.GetMessageById(ctx, forwardedID)
+	if err != nil {
+		t.Fatalf("get forwarded message: %v", err)
+	}
+	var mentionEntities []models.MessageEntity
+	for _, entity := range decodeEntities(forwarded.Entities) {
+		if entity.Type == constants.EntityTypeMention {
+			mentionEntities = append(mentionEntities, entity)
+		}
+	}
+	if len(mentionEntities) != 1 || mentionEntities[0].UserID == nil || *mentionEntities[0].UserID != carol {
+		t.Errorf("forwarded mention entities = %+v, want only carol", mentionEntities)
+	}
+
+	select {
+	case job := <-handler.linkPreviewJobs:
+		if job.MessageID != forwardedID || len(job.URLs) != 1 || job.URLs[0] != "https://example.com" {
+			t.Errorf("link preview job = %+v, want https://example.com for the forwarded message", job)
+		}
+	default:
+		t.Error("no link preview queued for the forwarded message")
+	}
+}
@@ -0,0 +1,612 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func (h *MessageHandler) CreatePoll(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	question := strings.TrimSpace(req.Question)
+	if question == "" || len(question) > constants.MaxPollQuestionLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Poll question must be between 1 and %d characters", constants.MaxPollQuestionLength),
+		})
+		return
+	}
+
+	if len(req.Options) < constants.MinPollOptions || len(req.Options) > constants.MaxPollOptions {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Polls must have between %d and %d options", constants.MinPollOptions, constants.MaxPollOptions),
+		})
+		return
+	}
+
+	options := make([]string, len(req.Options))
+	seenOptions := make(map[string]struct{}, len(req.Options))
+	for i, option := range req.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || len(option) > constants.MaxPollOptionLength {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Poll options must be between 1 and %d characters", constants.MaxPollOptionLength),
+			})
+			return
+		}
+
+		key := strings.ToLower(option)
+		if _, dup := seenOptions[key]; dup {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Poll options must be unique",
+			})
+			return
+		}
+		seenOptions[key] = struct{}{}
+		options[i] = option
+	}
+
+	var closesAt sql.NullTime
+	if req.ClosesAt != nil {
+		if !req.ClosesAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Poll close time must be in the future",
+			})
+			return
+		}
+		closesAt = sql.NullTime{Time: req.ClosesAt.UTC(), Valid: true}
+	}
+
+	chatID, err := uuid.Parse(req.ChatID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid chat ID",
+		})
+		return
+	}
+
+	// Verify user is member of chat
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: chatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return
+	}
+
+	if settings.WhoCanPost != constants.WhoCanPostEveryone {
+		chat, err := h.dbService.Queries.GetChatMetadata(c.Request.Context(), chatID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load chat",
+			})
+			return
+		}
+
+		if !canPostInChat(settings, chat.CreatedBy, userID) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Only the chat admin can post in this chat",
+			})
+			return
+		}
+	}
+
+	// The question is stored as the message content, so it is held to the chat's limit as well
+	if len(question) > int(settings.MaxMessageLength) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Poll question exceeds maximum length of %d characters", settings.MaxMessageLength),
+		})
+		return
+	}
+
+	// Use transaction so the message, poll and options are created together
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	// The question doubles as the message content so chat previews stay meaningful
+	message, err := qtx.CreateMessage(c.Request.Context(), database.CreateMessageParams{
+		ChatID:   chatID,
+		SenderID: userID,
+		Content:  sql.NullString{String: question, Valid: true},
+		Entities: json.RawMessage("[]"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to create message",
+		})
+		return
+	}
+
+	poll, err := qtx.CreatePoll(c.Request.Context(), database.CreatePollParams{
+		MessageID:      message.ID,
+		Question:       question,
+		AllowsMultiple: req.AllowsMultiple,
+		IsAnonymous:    req.IsAnonymous,
+		ClosesAt:       closesAt,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to create poll",
+		})
+		return
+	}
+
+	for i, option := range options {
+		if _, err := qtx.CreatePollOption(c.Request.Context(), database.CreatePollOptionParams{
+			PollID:   poll.ID,
+			Text:     option,
+			Position: int32(i),
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to create poll option",
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	polls, err := h.buildPolls(c.Request.Context(), []database.Poll{poll}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load poll",
+		})
+		return
+	}
+
+	username, _ := c.Get("username")
+	pollPayload := toPollPayload(chatID, message.ID, polls[message.ID])
+
+	h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+		Type: ws.EventMessageSent,
+		Payload: ws.MessageSentPayload{
+			ID:             message.ID.String(),
+			ChatID:         chatID.String(),
+			SenderID:       userID.String(),
+			SenderUsername: username.(string),
+			Content:        &question,
+			Images:         []ws.MessageImage{},
+			IsDeleted:      false,
+			IsEdited:       false,
+			CreatedAt:      message.CreatedAt,
+			Poll:           &pollPayload,
+			MessageType:    message.MessageType,
+			ExpiresAt:      utils.NullableTime(message.ExpiresAt),
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message_id": message.ID,
+		"poll_id":    poll.ID,
+	})
+}
+
+func (h *MessageHandler) VotePoll(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.VotePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	message, poll, ok := h.loadOpenPoll(c, req.MessageID, userID)
+	if !ok {
+		return
+	}
+
+	pollOptions, err := h.dbService.Queries.GetPollOptions(c.Request.Context(), []uuid.UUID{poll.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get poll options",
+		})
+		return
+	}
+
+	validOptions := make(map[uuid.UUID]struct{}, len(pollOptions))
+	for _, option := range pollOptions {
+		validOptions[option.ID] = struct{}{}
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(req.OptionIDs))
+	optionIDs := make([]uuid.UUID, 0, len(req.OptionIDs))
+	for _, rawID := range req.OptionIDs {
+		optionID, err := uuid.Parse(rawID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Invalid option ID: %s", rawID),
+			})
+			return
+		}
+
+		if _, valid := validOptions[optionID]; !valid {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: fmt.Sprintf("Option %s does not belong to this poll", rawID),
+			})
+			return
+		}
+
+		if _, dup := seen[optionID]; dup {
+			continue
+		}
+		seen[optionID] = struct{}{}
+		optionIDs = append(optionIDs, optionID)
+	}
+
+	if !poll.AllowsMultiple && len(optionIDs) > 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "This poll only allows a single choice",
+		})
+		return
+	}
+
+	// Voting replaces the user's previous selection
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	// Concurrent votes would otherwise both delete before either inserts, leaving a single choice
+	// poll with two of the user's votes
+	if err := qtx.LockPoll(c.Request.Context(), poll.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record vote",
+		})
+		return
+	}
+
+	if err := qtx.DeletePollVotes(c.Request.Context(), database.DeletePollVotesParams{
+		PollID: poll.ID,
+		UserID: userID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record vote",
+		})
+		return
+	}
+
+	if err := qtx.AddPollVotes(c.Request.Context(), database.AddPollVotesParams{
+		PollID:    poll.ID,
+		OptionIds: optionIDs,
+		UserID:    userID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record vote",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	h.respondWithPollUpdate(c, message.ChatID, poll, userID)
+}
+
+func (h *MessageHandler) RetractPollVote(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.RetractPollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	message, poll, ok := h.loadOpenPoll(c, req.MessageID, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.Queries.DeletePollVotes(c.Request.Context(), database.DeletePollVotesParams{
+		PollID: poll.ID,
+		UserID: userID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to retract vote",
+		})
+		return
+	}
+
+	h.respondWithPollUpdate(c, message.ChatID, poll, userID)
+}
+
+// loadOpenPoll resolves the poll attached to a message and checks the user can still vote on it
+func (h *MessageHandler) loadOpenPoll(c *gin.Context, rawMessageID string, userID uuid.UUID) (database.GetMessageByIdRow, database.Poll, bool) {
+	messageID, err := uuid.Parse(rawMessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return database.GetMessageByIdRow{}, database.Poll{}, false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: message.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	if message.IsDeleted {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "This poll has been deleted",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	poll, err := h.dbService.Queries.GetPollByMessageId(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Poll not found",
+			})
+			return database.GetMessageByIdRow{}, database.Poll{}, false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get poll",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	if isPollClosed(poll) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "This poll is closed",
+		})
+		return database.GetMessageByIdRow{}, database.Poll{}, false
+	}
+
+	return message, poll, true
+}
+
+// respondWithPollUpdate broadcasts the new tallies to the chat and returns the poll as seen by the voter
+func (h *MessageHandler) respondWithPollUpdate(c *gin.Context, chatID uuid.UUID, poll database.Poll, userID uuid.UUID) {
+	polls, err := h.buildPolls(c.Request.Context(), []database.Poll{poll}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load poll",
+		})
+		return
+	}
+
+	updated := polls[poll.MessageID]
+
+	h.hub.BroadcastToChat(chatID.String(), ws.WSMessage{
+		Type:    ws.EventPollUpdated,
+		Payload: toPollPayload(chatID, poll.MessageID, updated),
+	})
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// buildPolls assembles polls with their options, tallies and the user's own votes, keyed by message ID
+func (h *MessageHandler) buildPolls(ctx context.Context, polls []database.Poll, userID uuid.UUID) (map[uuid.UUID]*models.Poll, error) {
+	result := make(map[uuid.UUID]*models.Poll, len(polls))
+	if len(polls) == 0 {
+		return result, nil
+	}
+
+	pollIDs := make([]uuid.UUID, len(polls))
+	for i, poll := range polls {
+		pollIDs[i] = poll.ID
+	}
+
+	optionsData, err := h.dbService.Queries.GetPollOptions(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	votesData, err := h.dbService.Queries.GetPollVotes(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	optionsByPoll := make(map[uuid.UUID][]database.PollOption)
+	for _, option := range optionsData {
+		optionsByPoll[option.PollID] = append(optionsByPoll[option.PollID], option)
+	}
+
+	votesByOption := make(map[uuid.UUID][]database.GetPollVotesRow)
+	votersByPoll := make(map[uuid.UUID]map[uuid.UUID]struct{})
+	for _, vote := range votesData {
+		votesByOption[vote.OptionID] = append(votesByOption[vote.OptionID], vote)
+		if votersByPoll[vote.PollID] == nil {
+			votersByPoll[vote.PollID] = make(map[uuid.UUID]struct{})
+		}
+		votersByPoll[vote.PollID][vote.UserID] = struct{}{}
+	}
+
+	for _, poll := range polls {
+		var closesAt *time.Time
+		if poll.ClosesAt.Valid {
+			closesAt = &poll.ClosesAt.Time
+		}
+
+		built := &models.Poll{
+			ID:             poll.ID,
+			Question:       poll.Question,
+			AllowsMultiple: poll.AllowsMultiple,
+			IsAnonymous:    poll.IsAnonymous,
+			ClosesAt:       closesAt,
+			IsClosed:       isPollClosed(poll),
+			TotalVoters:    len(votersByPoll[poll.ID]),
+			Options:        make([]models.PollOption, 0, len(optionsByPoll[poll.ID])),
+			MyOptionIDs:    []uuid.UUID{},
+		}
+
+		for _, option := range optionsByPoll[poll.ID] {
+			votes := votesByOption[option.ID]
+
+			pollOption := models.PollOption{
+				ID:        option.ID,
+				Text:      option.Text,
+				VoteCount: len(votes),
+			}
+
+			for _, vote := range votes {
+				if vote.UserID == userID {
+					built.MyOptionIDs = append(built.MyOptionIDs, option.ID)
+				}
+
+				// Voter identities are never exposed for anonymous polls
+				if !poll.IsAnonymous {
+					pollOption.Voters = append(pollOption.Voters, models.MessageSender{
+						ID:              vote.UserID,
+						Username:        vote.Username,
+						ProfileImageURL: signProfileImageURL(ctx, h.store, vote.ProfileImageUrl),
+					})
+				}
+			}
+
+			built.Options = append(built.Options, pollOption)
+		}
+
+		result[poll.MessageID] = built
+	}
+
+	return result, nil
+}
+
+// isPollMessage reports whether a poll is attached to the message
+func (h *MessageHandler) isPollMessage(ctx context.Context, messageID uuid.UUID) (bool, error) {
+	_, err := h.dbService.Queries.GetPollByMessageId(ctx, messageID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func isPollClosed(poll database.Poll) bool {
+	return poll.ClosesAt.Valid && !time.Now().Before(poll.ClosesAt.Time)
+}
+
+func toPollPayload(chatID, messageID uuid.UUID, poll *models.Poll) ws.PollPayload {
+	options := make([]ws.PollOptionPayload, len(poll.Options))
+	for i, option := range poll.Options {
+		var voterIDs []string
+		for _, voter := range option.Voters {
+			voterIDs = append(voterIDs, voter.ID.String())
+		}
+
+		options[i] = ws.PollOptionPayload{
+			ID:        option.ID.String(),
+			Text:      option.Text,
+			VoteCount: option.VoteCount,
+			VoterIDs:  voterIDs,
+		}
+	}
+
+	return ws.PollPayload{
+		ID:             poll.ID.String(),
+		MessageID:      messageID.String(),
+		ChatID:         chatID.String(),
+		Question:       poll.Question,
+		AllowsMultiple: poll.AllowsMultiple,
+		IsAnonymous:    poll.IsAnonymous,
+		ClosesAt:       poll.ClosesAt,
+		IsClosed:       poll.IsClosed,
+		TotalVoters:    poll.TotalVoters,
+		Options:        options,
+	}
+}
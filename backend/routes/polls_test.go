@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestPollVotingTallies(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob, carol)
+
+	gin.SetMode(gin.TestMode)
+	call := func(userID uuid.UUID, request any, handle func(*gin.Context)) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Set("username", "tester")
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/polls", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handle(c)
+		return recorder
+	}
+
+	recorder := call(alice, models.CreatePollRequest{
+		ChatID:   chatID.String(),
+		Question: "Lunch?",
+		Options:  []string{"Pizza", "Sushi", "Tacos"},
+	}, handler.CreatePoll)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("CreatePoll() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	var created struct {
+		MessageID uuid.UUID `json:"message_id"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	var optionIDs []string
+	rows, err := db.Query(`SELECT o.id FROM poll_options o JOIN polls p ON p.id = o.poll_id WHERE p.message_id = $1 ORDER BY o.position`, created.MessageID)
+	if err != nil {
+		t.Fatalf("get poll options: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("scan poll option: %v", err)
+		}
+		optionIDs = append(optionIDs, id)
+	}
+	rows.Close()
+	if len(optionIDs) != 3 {
+		t.Fatalf("poll has %d options, want 3", len(optionIDs))
+	}
+
+	vote := func(userID uuid.UUID, optionIDs ...string) *models.Poll {
+		t.Helper()
+
+		recorder := call(userID, models.VotePollRequest{MessageID: created.MessageID.String(), OptionIDs: optionIDs}, handler.VotePoll)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("VotePoll() status = %d, body %s", recorder.Code, recorder.Body)
+		}
+		var poll models.Poll
+		if err := json.Unmarshal(recorder.Body.Bytes(), &poll); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return &poll
+	}
+
+	checkTally := func(poll *models.Poll, wantVoters int, wantCounts ...int) {
+		t.Helper()
+
+		if poll.TotalVoters != wantVoters {
+			t.Errorf("total voters = %d, want %d", poll.TotalVoters, wantVoters)
+		}
+		for i, option := range poll.Options {
+			if option.VoteCount != wantCounts[i] {
+				t.Errorf("option %q has %d votes, want %d", option.Text, option.VoteCount, wantCounts[i])
+			}
+		}
+	}
+
+	vote(bob, optionIDs[0])
+	// A new vote replaces the user's previous choice
+	poll := vote(bob, optionIDs[1])
+	checkTally(poll, 1, 0, 1, 0)
+	if len(poll.MyOptionIDs) != 1 || poll.MyOptionIDs[0].String() != optionIDs[1] {
+		t.Errorf("my option IDs = %v, want [%s]", poll.MyOptionIDs, optionIDs[1])
+	}
+
+	checkTally(vote(carol, optionIDs[1]), 2, 0, 2, 0)
+
+	recorder = call(alice, models.VotePollRequest{MessageID: created.MessageID.String(), OptionIDs: optionIDs[:2]}, handler.VotePoll)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("VotePoll() with two choices on a single choice poll status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+
+	recorder = call(bob, models.RetractPollVoteRequest{MessageID: created.MessageID.String()}, handler.RetractPollVote)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("RetractPollVote() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	var retracted models.Poll
+	if err := json.Unmarshal(recorder.Body.Bytes(), &retracted); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	checkTally(&retracted, 1, 0, 1, 0)
+}
+
+func TestVotePollConcurrentVotesKeepSingleChoice(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	messageID := createTestMessage(t, db, chatID, alice, "lunch?", time.Now(), time.Time{})
+
+	var pollID uuid.UUID
+	if err := db.QueryRow(
+		`INSERT INTO polls (message_id, question, allows_multiple, is_anonymous) VALUES ($1, 'Lunch?', false, false) RETURNING id`,
+		messageID,
+	).Scan(&pollID); err != nil {
+		t.Fatalf("create poll: %v", err)
+	}
+
+	optionIDs := make([]uuid.UUID, 2)
+	for i := range optionIDs {
+		if err := db.QueryRow(
+			`INSERT INTO poll_options (poll_id, text, position) VALUES ($1, $2, $3) RETURNING id`,
+			pollID, fmt.Sprintf("option %d", i), i,
+		).Scan(&optionIDs[i]); err != nil {
+			t.Fatalf("create poll option: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(optionID uuid.UUID) {
+			defer wg.Done()
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", bob)
+			body := fmt.Sprintf(`{"message_id":%q,"option_ids":[%q]}`, messageID, optionID)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/polls/vote", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.VotePoll(c)
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status = %d, body %s", recorder.Code, recorder.Body)
+			}
+		}(optionIDs[i%2])
+	}
+	wg.Wait()
+
+	var votes int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM poll_votes WHERE poll_id = $1 AND user_id = $2`, pollID, bob).Scan(&votes); err != nil {
+		t.Fatalf("count votes: %v", err)
+	}
+	if votes != 1 {
+		t.Errorf("user has %d votes on a single choice poll, want 1", votes)
+	}
+}
+
+func TestCreatePollQuestionFollowsChatMessageLength(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	if _, err := db.Exec(`UPDATE chat_settings SET max_message_length = 10 WHERE chat_id = $1`, chatID); err != nil {
+		t.Fatalf("update chat settings: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		question   string
+		wantStatus int
+	}{
+		{name: "within the limit", question: "Lunch?", wantStatus: http.StatusOK},
+		{name: "over the limit", question: "Where should we go for lunch?", wantStatus: http.StatusBadRequest},
+		// Counted in bytes like message content, so these five characters take fifteen
+		{name: "multibyte over the limit", question: "日本語です", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Set("user_id", alice)
+			c.Set("username", "alice")
+			body := fmt.Sprintf(`{"chat_id":%q,"question":%q,"options":["yes","no"]}`, chatID, tt.question)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/polls/create", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.CreatePoll(c)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("CreatePoll() status = %d, want %d, body %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/notify"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+const (
+	// How often the dispatcher looks for reminders that are due
+	reminderDispatchInterval = 15 * time.Second
+	// Maximum reminders fired per dispatcher transaction
+	reminderDispatchBatchSize = 100
+	// Longest message excerpt included in notifications
+	reminderExcerptLength = 100
+	reminderNotifyTimeout = 10 * time.Second
+)
+
+// RunReminderDispatcher fires due reminders until the context is cancelled. Each reminder is sent to the
+// user's websocket sessions and to the configured notification channels, then removed.
+func (h *MessageHandler) RunReminderDispatcher(ctx context.Context, notifier notify.Notifier) {
+	ticker := time.NewTicker(reminderDispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				fired, err := h.fireDueReminders(ctx, notifier)
+				if err != nil {
+					log.Printf("Failed to fire reminders: %v", err)
+					break
+				}
+				if fired < reminderDispatchBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// fireDueReminders claims one batch of due reminders, returning how many were claimed.
+// Reminders whose message the user can no longer see are removed without being delivered.
+func (h *MessageHandler) fireDueReminders(ctx context.Context, notifier notify.Notifier) (int, error) {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	due, err := qtx.ClaimDueMessageReminders(ctx, reminderDispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	reminderIDs := make([]uuid.UUID, len(due))
+	for i, reminder := range due {
+		reminderIDs[i] = reminder.ID
+	}
+
+	if err := qtx.DeleteMessageReminders(ctx, reminderIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, reminder := range due {
+		if reminder.IsDeliverable {
+			h.deliverReminder(ctx, notifier, reminder)
+		}
+	}
+
+	return len(due), nil
+}
+
+func (h *MessageHandler) deliverReminder(ctx context.Context, notifier notify.Notifier, reminder database.ClaimDueMessageRemindersRow) {
+	content := utils.NullableString(reminder.Content)
+
+	h.hub.SendToUser(reminder.UserID.String(), ws.WSMessage{
+		Type: ws.EventReminderFired,
+		Payload: ws.ReminderFiredPayload{
+			ID:             reminder.ID.String(),
+			MessageID:      reminder.MessageID.String(),
+			ChatID:         reminder.ChatID.String(),
+			ChatName:       utils.NullableString(reminder.ChatName),
+			SenderUsername: reminder.SenderUsername,
+			Content:        content,
+			RemindAt:       reminder.RemindAt,
+		},
+	})
+
+	if notifier == nil {
+		return
+	}
+
+	title := reminder.SenderUsername
+	if reminder.ChatIsGroup && reminder.ChatName.Valid {
+		title = reminder.ChatName.String
+	}
+
+	body := fmt.Sprintf("Reminder: message from %s", reminder.SenderUsername)
+	if content != nil {
+		body = fmt.Sprintf("Reminder: %s: %s", reminder.SenderUsername, truncateRunes(*content, reminderExcerptLength))
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, reminderNotifyTimeout)
+	defer cancel()
+
+	if err := notifier.Notify(notifyCtx, notify.Notification{
+		UserID: reminder.UserID,
+		Type:   constants.NotificationTypeReminder,
+		Title:  title,
+		Body:   body,
+		Data: map[string]string{
+			"reminder_id": reminder.ID.String(),
+			"message_id":  reminder.MessageID.String(),
+			"chat_id":     reminder.ChatID.String(),
+		},
+	}); err != nil {
+		log.Printf("Failed to send reminder %s notification: %v", reminder.ID, err)
+	}
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit]) + "…"
+}
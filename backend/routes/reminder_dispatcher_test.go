@@ -0,0 +1,119 @@
+package routes
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/notify"
+)
+
+// recordingNotifier keeps every notification it is asked to deliver
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []notify.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func TestFireDueReminders(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	visible := createTestMessage(t, db, chatID, bob, "hello there", time.Now(), time.Time{})
+	hidden := createTestMessage(t, db, chatID, bob, "never mind", time.Now(), time.Time{})
+	if err := queries.HideMessage(ctx, database.HideMessageParams{UserID: alice, MessageID: hidden}); err != nil {
+		t.Fatalf("hide message: %v", err)
+	}
+
+	remind := func(messageID uuid.UUID, remindAt time.Time) uuid.UUID {
+		reminder, err := queries.CreateMessageReminder(ctx, database.CreateMessageReminderParams{
+			UserID:    alice,
+			MessageID: messageID,
+			ChatID:    chatID,
+			RemindAt:  remindAt,
+		})
+		if err != nil {
+			t.Fatalf("create reminder: %v", err)
+		}
+		return reminder.ID
+	}
+
+	due := remind(visible, time.Now().UTC().Add(-48*time.Hour))
+	remind(hidden, time.Now().UTC().Add(-48*time.Hour))
+	upcoming := remind(visible, time.Now().UTC().Add(48*time.Hour))
+
+	notifier := &recordingNotifier{}
+	fired, err := handler.fireDueReminders(ctx, notifier)
+	if err != nil {
+		t.Fatalf("fireDueReminders() error = %v", err)
+	}
+	if fired != 2 {
+		t.Errorf("fireDueReminders() = %d, want both due reminders claimed", fired)
+	}
+
+	// The reminder on the hidden message is dropped without being delivered
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("%d notifications sent, want 1", len(notifier.notifications))
+	}
+	notification := notifier.notifications[0]
+	if notification.UserID != alice || notification.Type != constants.NotificationTypeReminder {
+		t.Errorf("notification = %+v, want a reminder for alice", notification)
+	}
+	if notification.Body != "Reminder: bob: hello there" {
+		t.Errorf("notification body = %q", notification.Body)
+	}
+	if notification.Data["reminder_id"] != due.String() || notification.Data["message_id"] != visible.String() {
+		t.Errorf("notification data = %v, want reminder %s on message %s", notification.Data, due, visible)
+	}
+
+	remaining, err := queries.GetMessageRemindersByUser(ctx, database.GetMessageRemindersByUserParams{UserID: alice})
+	if err != nil {
+		t.Fatalf("get reminders: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != upcoming {
+		t.Errorf("remaining reminders = %+v, want only the upcoming one", remaining)
+	}
+
+	if fired, err := handler.fireDueReminders(ctx, notifier); err != nil || fired != 0 {
+		t.Errorf("second fireDueReminders() = %d, %v, want nothing left to fire", fired, err)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		value string
+		limit int
+		want  string
+	}{
+		{value: "hello", limit: 5, want: "hello"},
+		{value: "hello world", limit: 5, want: "hello…"},
+		{value: "日本語のテキスト", limit: 3, want: "日本語…"},
+		{value: "", limit: 3, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncateRunes(tt.value, tt.limit); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.value, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+func (h *MessageHandler) CreateReminder(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	if !req.RemindAt.After(now) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "remind_at must be in the future",
+		})
+		return
+	}
+
+	if req.RemindAt.After(now.Add(constants.MaxScheduleAheadSeconds * time.Second)) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Reminders can only be set up to one year ahead",
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: message.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	if message.IsDeleted {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Cannot set a reminder for a deleted message",
+		})
+		return
+	}
+
+	reminder, err := h.dbService.Queries.CreateMessageReminder(c.Request.Context(), database.CreateMessageReminderParams{
+		UserID:    userID,
+		MessageID: messageID,
+		ChatID:    message.ChatID,
+		RemindAt:  req.RemindAt.UTC(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to create reminder",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         reminder.ID,
+		"message_id": reminder.MessageID,
+		"chat_id":    reminder.ChatID,
+		"remind_at":  reminder.RemindAt,
+		"created_at": reminder.CreatedAt,
+	})
+}
+
+func (h *MessageHandler) GetReminders(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.GetRemindersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var chatID uuid.NullUUID
+	if req.ChatID != nil {
+		parsed, err := uuid.Parse(*req.ChatID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid chat ID",
+			})
+			return
+		}
+		chatID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	remindersData, err := h.dbService.Queries.GetMessageRemindersByUser(c.Request.Context(), database.GetMessageRemindersByUserParams{
+		UserID: userID,
+		ChatID: chatID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get reminders",
+		})
+		return
+	}
+
+	items := make([]models.Reminder, len(remindersData))
+	for i, reminder := range remindersData {
+		items[i] = models.Reminder{
+			ID:             reminder.ID,
+			MessageID:      reminder.MessageID,
+			ChatID:         reminder.ChatID,
+			ChatName:       utils.NullableString(reminder.ChatName),
+			ChatIsGroup:    reminder.ChatIsGroup,
+			SenderUsername: reminder.SenderUsername,
+			Content:        utils.NullableString(reminder.Content),
+			RemindAt:       reminder.RemindAt,
+			CreatedAt:      reminder.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, models.GetRemindersResponse{
+		Items: items,
+	})
+}
+
+func (h *MessageHandler) CancelReminder(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	reminderID, err := uuid.Parse(req.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid reminder ID",
+		})
+		return
+	}
+
+	cancelled, err := h.dbService.Queries.CancelMessageReminder(c.Request.Context(), database.CancelMessageReminderParams{
+		ID:     reminderID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to cancel reminder",
+		})
+		return
+	}
+
+	// Fired reminders are removed, so this also covers one the dispatcher just delivered
+	if cancelled == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Reminder not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,319 @@
+package routes
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+func (h *MessageHandler) SaveMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.SaveMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	message, err := h.dbService.Queries.GetMessageById(c.Request.Context(), messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Message not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: message.ChatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	if message.IsDeleted {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Cannot save a deleted message",
+		})
+		return
+	}
+
+	// Messages the user cleared, hid or saw expire are gone from their history, so they can't be
+	// saved either
+	isVisible, err := h.dbService.Queries.IsMessageVisible(c.Request.Context(), database.IsMessageVisibleParams{
+		UserID:    userID,
+		MessageID: messageID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message",
+		})
+		return
+	}
+
+	if !isVisible {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Message not found",
+		})
+		return
+	}
+
+	if err := h.dbService.Queries.SaveMessage(c.Request.Context(), database.SaveMessageParams{
+		UserID:    userID,
+		MessageID: messageID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to save message",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// UnsaveMessage removes a bookmark. It succeeds even if the message was never saved
+// so clients can retry freely, and works after the message itself is gone.
+func (h *MessageHandler) UnsaveMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.UnsaveMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	messageID, err := uuid.Parse(req.MessageID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid message ID",
+		})
+		return
+	}
+
+	if err := h.dbService.Queries.UnsaveMessage(c.Request.Context(), database.UnsaveMessageParams{
+		UserID:    userID,
+		MessageID: messageID,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to unsave message",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// GetSavedMessages lists the user's saved messages, most recently saved first.
+// Only messages in chats the user is still a member of are returned, filtered like the
+// chat history: messages from before the user cleared the chat, hidden messages and
+// expired messages are left out. Deleted messages come back as tombstones without their content.
+func (h *MessageHandler) GetSavedMessages(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.GetSavedMessagesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = constants.DefaultMessagesPerPage
+	} else if limit > constants.MaxMessagesPerPage {
+		limit = constants.MaxMessagesPerPage
+	}
+
+	var cursorTime sql.NullTime
+	var cursorID uuid.NullUUID
+
+	if req.CursorSavedAt != "" || req.CursorID != "" {
+		savedAt, err := time.Parse(time.RFC3339Nano, req.CursorSavedAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid cursor_saved_at, expected an RFC 3339 timestamp",
+			})
+			return
+		}
+
+		id, err := uuid.Parse(req.CursorID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid cursor_id",
+			})
+			return
+		}
+
+		cursorTime = sql.NullTime{Time: savedAt, Valid: true}
+		cursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	savedData, err := h.dbService.Queries.GetSavedMessages(c.Request.Context(), database.GetSavedMessagesParams{
+		UserID:     userID,
+		CursorTime: cursorTime,
+		CursorID:   cursorID,
+		PageLimit:  int32(limit + 1), // Fetch one extra to check if there are more
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get saved messages",
+		})
+		return
+	}
+
+	hasMore := len(savedData) > limit
+	if hasMore {
+		savedData = savedData[:limit]
+	}
+
+	// Images, mentions, link previews and attachments are only loaded for messages that have not been deleted
+	liveMessageIDs := make([]uuid.UUID, 0, len(savedData))
+	for _, saved := range savedData {
+		if !saved.IsDeleted {
+			liveMessageIDs = append(liveMessageIDs, saved.MessageID)
+		}
+	}
+
+	imagesByMessage := make(map[uuid.UUID][]models.MessageImage)
+	if len(liveMessageIDs) > 0 {
+		imagesData, err := h.dbService.Queries.GetMessageImages(c.Request.Context(), liveMessageIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to get message images",
+			})
+			return
+		}
+
+		for _, img := range imagesData {
+			imagesByMessage[img.MessageID] = append(imagesByMessage[img.MessageID], signMessageImage(c.Request.Context(), h.store, toMessageImage(img)))
+		}
+	}
+
+	mentionsByMessage, err := loadMessageMentions(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message mentions",
+		})
+		return
+	}
+
+	previewsByMessage, err := loadMessageLinkPreviews(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get link previews",
+		})
+		return
+	}
+
+	attachmentsByMessage, err := loadMessageAttachments(c.Request.Context(), h.dbService.Queries, liveMessageIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get message attachments",
+		})
+		return
+	}
+
+	items := make([]models.SavedMessage, len(savedData))
+	for i, saved := range savedData {
+		message := models.Message{
+			ID:                    saved.MessageID,
+			SenderID:              saved.SenderID,
+			SenderUsername:        saved.SenderUsername,
+			SenderProfileImageUrl: signProfileImageURL(c.Request.Context(), h.store, saved.SenderProfileImageUrl),
+			IsDeleted:             saved.IsDeleted,
+			IsEdited:              saved.IsEdited,
+			IsSaved:               true,
+			Images:                []models.MessageImage{},
+			CreatedAt:             saved.CreatedAt,
+			MessageType:           saved.MessageType,
+		}
+
+		if !saved.IsDeleted {
+			message.Content = utils.NullableString(saved.Content)
+			message.Entities = decodeEntities(saved.Entities)
+			message.Mentions = mentionsByMessage[saved.MessageID]
+			message.LinkPreviews = previewsByMessage[saved.MessageID]
+			message.Attachments = attachmentsByMessage[saved.MessageID]
+			if images := imagesByMessage[saved.MessageID]; images != nil {
+				message.Images = images
+			}
+		}
+
+		items[i] = models.SavedMessage{
+			Message: message,
+			Chat: models.SavedMessageChat{
+				ID:      saved.ChatID,
+				Name:    utils.NullableString(saved.ChatName),
+				IsGroup: saved.ChatIsGroup,
+			},
+			SavedAt: saved.SavedAt,
+		}
+	}
+
+	response := models.GetSavedMessagesResponse{
+		Items: items,
+	}
+
+	if hasMore && len(items) > 0 {
+		last := items[len(items)-1]
+		response.NextCursor = &struct {
+			SavedAt time.Time `json:"saved_at"`
+			ID      uuid.UUID `json:"id"`
+		}{
+			SavedAt: last.SavedAt,
+			ID:      last.Message.ID,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
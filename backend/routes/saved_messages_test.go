@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestSavedMessagesFollowMembership(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	groupID := createTestChat(t, db, alice, bob, carol)
+	directID := createTestChat(t, db, alice, bob)
+
+	inGroup := createTestMessage(t, db, groupID, bob, "group", time.Now(), time.Time{})
+	inDirect := createTestMessage(t, db, directID, bob, "direct", time.Now(), time.Time{})
+	deleted := createTestMessage(t, db, directID, bob, "deleted", time.Now(), time.Time{})
+	if err := queries.DeleteMessage(ctx, deleted); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	saveMessage := func(userID, messageID uuid.UUID) int {
+		body, err := json.Marshal(models.SaveMessageRequest{MessageID: messageID.String()})
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/save", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.SaveMessage(c)
+		return recorder.Code
+	}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		messageID  uuid.UUID
+		wantStatus int
+	}{
+		{name: "group message", userID: alice, messageID: inGroup, wantStatus: http.StatusOK},
+		{name: "direct message", userID: alice, messageID: inDirect, wantStatus: http.StatusOK},
+		{name: "saved twice", userID: alice, messageID: inDirect, wantStatus: http.StatusOK},
+		{name: "not a member", userID: carol, messageID: inDirect, wantStatus: http.StatusForbidden},
+		{name: "deleted message", userID: alice, messageID: deleted, wantStatus: http.StatusBadRequest},
+		{name: "missing message", userID: alice, messageID: uuid.New(), wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saveMessage(tt.userID, tt.messageID); got != tt.wantStatus {
+				t.Errorf("SaveMessage() status = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+
+	savedMessages := func() []models.SavedMessage {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", alice)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/messages/saved", nil)
+
+		handler.GetSavedMessages(c)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("GetSavedMessages() status = %d, body %s", recorder.Code, recorder.Body)
+		}
+
+		var response models.GetSavedMessagesResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return response.Items
+	}
+
+	// Deleting a saved message leaves a tombstone without its content
+	if err := queries.DeleteMessage(ctx, inDirect); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+	saved := savedMessages()
+	if len(saved) != 2 || saved[0].Message.ID != inDirect || saved[1].Message.ID != inGroup {
+		t.Fatalf("saved messages = %+v, want the direct then the group message", saved)
+	}
+	if !saved[0].Message.IsDeleted || saved[0].Message.Content != nil {
+		t.Errorf("deleted saved message = %+v, want a tombstone", saved[0].Message)
+	}
+	if saved[1].Message.Content == nil || *saved[1].Message.Content != "group" || saved[1].Chat.ID != groupID {
+		t.Errorf("group saved message = %+v, want its content and chat", saved[1])
+	}
+
+	// Messages from chats the user left are no longer listed
+	if err := queries.RemoveChatMember(ctx, database.RemoveChatMemberParams{ChatID: groupID, UserID: alice}); err != nil {
+		t.Fatalf("remove chat member: %v", err)
+	}
+	saved = savedMessages()
+	if len(saved) != 1 || saved[0].Message.ID != inDirect {
+		t.Errorf("saved messages = %+v, want only the direct message", saved)
+	}
+}
+
+func TestSavedMessagesFollowClearedHistory(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	before := createTestMessage(t, db, chatID, bob, "before", time.Now().Add(-time.Hour), time.Time{})
+	if err := queries.SaveMessage(ctx, database.SaveMessageParams{UserID: alice, MessageID: before}); err != nil {
+		t.Fatalf("save message: %v", err)
+	}
+
+	if err := queries.UpdateChatMemberClearedAt(ctx, database.UpdateChatMemberClearedAtParams{ChatID: chatID, UserID: alice}); err != nil {
+		t.Fatalf("clear chat: %v", err)
+	}
+	after := createTestMessage(t, db, chatID, bob, "after", time.Now().Add(time.Minute), time.Time{})
+
+	gin.SetMode(gin.TestMode)
+	saveMessage := func(messageID uuid.UUID) int {
+		body, err := json.Marshal(models.SaveMessageRequest{MessageID: messageID.String()})
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", alice)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/save", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.SaveMessage(c)
+		return recorder.Code
+	}
+
+	tests := []struct {
+		name       string
+		messageID  uuid.UUID
+		wantStatus int
+	}{
+		{name: "cleared message", messageID: before, wantStatus: http.StatusNotFound},
+		{name: "message after clearing", messageID: after, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := saveMessage(tt.messageID); got != tt.wantStatus {
+				t.Errorf("SaveMessage() status = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+
+	saved, err := queries.GetSavedMessages(ctx, database.GetSavedMessagesParams{UserID: alice, PageLimit: 10})
+	if err != nil {
+		t.Fatalf("get saved messages: %v", err)
+	}
+	if len(saved) != 1 || saved[0].MessageID != after {
+		t.Errorf("saved messages = %+v, want only the message sent after clearing", saved)
+	}
+}
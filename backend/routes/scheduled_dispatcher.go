@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+)
+
+// How often the dispatcher polls for scheduled messages that are due
+const scheduledDispatchInterval = 15 * time.Second
+
+// RunScheduledMessageDispatcher delivers due scheduled messages until the context is cancelled.
+// Rows are claimed with FOR UPDATE SKIP LOCKED so several backend instances can run it at once.
+func (h *MessageHandler) RunScheduledMessageDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(scheduledDispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				dispatched, err := h.dispatchNextScheduledMessage(ctx)
+				if err != nil {
+					log.Printf("Failed to dispatch scheduled message: %v", err)
+					break
+				}
+				if !dispatched {
+					break
+				}
+			}
+		}
+	}
+}
+
+// dispatchNextScheduledMessage sends a single due message, reporting whether one was claimed
+func (h *MessageHandler) dispatchNextScheduledMessage(ctx context.Context) (bool, error) {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	scheduled, err := qtx.ClaimDueScheduledMessage(ctx)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	outgoing, reason, err := h.prepareScheduledMessage(ctx, qtx, scheduled)
+	if err != nil {
+		return true, h.failScheduledMessage(ctx, tx, scheduled, "Failed to prepare message", err)
+	}
+
+	if reason != "" {
+		if err := markScheduledMessageFailed(ctx, qtx, scheduled, reason); err != nil {
+			return true, err
+		}
+		return true, tx.Commit()
+	}
+
+	message, err := createMessage(ctx, qtx, outgoing)
+	if err != nil {
+		return true, h.failScheduledMessage(ctx, tx, scheduled, "Failed to create message", err)
+	}
+
+	if err := qtx.MarkScheduledMessageSent(ctx, database.MarkScheduledMessageSentParams{
+		ID:            scheduled.ID,
+		SentMessageID: uuid.NullUUID{UUID: message.ID, Valid: true},
+	}); err != nil {
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return true, err
+	}
+
+	h.broadcastMessageSent(outgoing, message)
+	h.enqueueLinkPreviews(message.Message, outgoing.Entities)
+	return true, nil
+}
+
+// prepareScheduledMessage re-checks the chat's rules at delivery time, returning a reason when the
+// message can no longer be sent (e.g. the sender has left the chat since scheduling it)
+func (h *MessageHandler) prepareScheduledMessage(ctx context.Context, qtx *database.Queries, scheduled database.ScheduledMessage) (outgoingMessage, string, error) {
+	isMember, err := qtx.IsChatMember(ctx, database.IsChatMemberParams{
+		ChatID: scheduled.ChatID,
+		UserID: scheduled.SenderID,
+	})
+	if err != nil {
+		return outgoingMessage{}, "", err
+	}
+
+	if !isMember {
+		return outgoingMessage{}, "Sender is no longer a member of this chat", nil
+	}
+
+	settings, err := qtx.GetChatSettings(ctx, scheduled.ChatID)
+	if err != nil {
+		return outgoingMessage{}, "", err
+	}
+
+	if settings.WhoCanPost != constants.WhoCanPostEveryone {
+		chat, err := qtx.GetChatMetadata(ctx, scheduled.ChatID)
+		if err != nil {
+			return outgoingMessage{}, "", err
+		}
+
+		if !canPostInChat(settings, chat.CreatedBy, scheduled.SenderID) {
+			return outgoingMessage{}, "Only the chat admin can post in this chat", nil
+		}
+	}
+
+	if len(scheduled.Content.String) > int(settings.MaxMessageLength) {
+		return outgoingMessage{}, "Message content exceeds the chat's maximum length", nil
+	}
+
+	if len(scheduled.Images) > int(settings.MaxImages) {
+		return outgoingMessage{}, "Message has more images than the chat allows", nil
+	}
+
+	sender, err := qtx.GetUserByID(ctx, scheduled.SenderID)
+	if err != nil {
+		return outgoingMessage{}, "", err
+	}
+
+	outgoing := outgoingMessage{
+		ChatID:         scheduled.ChatID,
+		SenderID:       scheduled.SenderID,
+		SenderUsername: sender.Username,
+		Content:        scheduled.Content,
+		Entities:       decodeEntities(scheduled.Entities),
+		Images:         scheduled.Images,
+		ImagesReserved: true,
+	}
+
+	// The replied message may have been removed while waiting; send without the reply in that case
+	if scheduled.ReplyToMessageID.Valid {
+		replyMessage, err := qtx.GetMessageById(ctx, scheduled.ReplyToMessageID.UUID)
+		if err != nil && err != sql.ErrNoRows {
+			return outgoingMessage{}, "", err
+		}
+
+		if err == nil && replyMessage.ChatID == scheduled.ChatID {
+			replyPayload, err := h.buildReplyPayload(ctx, replyMessage)
+			if err != nil {
+				return outgoingMessage{}, "", err
+			}
+
+			outgoing.ReplyToMessageID = scheduled.ReplyToMessageID
+			outgoing.ReplyTo = replyPayload
+		}
+	}
+
+	return outgoing, "", nil
+}
+
+// failScheduledMessage rolls back the delivery attempt and marks the message as failed so it is not retried forever
+func (h *MessageHandler) failScheduledMessage(ctx context.Context, tx *sql.Tx, scheduled database.ScheduledMessage, reason string, cause error) error {
+	log.Printf("Scheduled message %s failed: %v", scheduled.ID, cause)
+
+	if err := tx.Rollback(); err != nil {
+		return err
+	}
+
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := markScheduledMessageFailed(ctx, h.dbService.Queries.WithTx(tx), scheduled, reason); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// markScheduledMessageFailed records why the message was not sent and frees its reserved images.
+// Nothing is changed if the message was cancelled in the meantime, since cancelling releases them itself.
+func markScheduledMessageFailed(ctx context.Context, qtx *database.Queries, scheduled database.ScheduledMessage, reason string) error {
+	images, err := qtx.MarkScheduledMessageFailed(ctx, database.MarkScheduledMessageFailedParams{
+		ID:            scheduled.ID,
+		FailureReason: sql.NullString{String: reason, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The images may have been edited since the delivery attempt loaded the message
+	scheduled.Images = images
+	return releaseScheduledImages(ctx, qtx, scheduled)
+}
@@ -0,0 +1,623 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+func (h *MessageHandler) CreateScheduledMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateScheduledMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	trimmedContent, entities, err := prepareMessageContent(req.Content, req.Entities)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	req.Content = trimmedContent
+
+	if !validateSendAt(c, req.SendAt) {
+		return
+	}
+
+	chatID, err := uuid.Parse(req.ChatID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid chat ID",
+		})
+		return
+	}
+
+	isMember, err := h.dbService.Queries.IsChatMember(c.Request.Context(), database.IsChatMemberParams{
+		ChatID: chatID,
+		UserID: userID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify chat membership",
+		})
+		return
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "You are not a member of this chat",
+		})
+		return
+	}
+
+	// Clients of a private store send back the signed URLs they were given
+	req.Images = storedImageURLs(h.store, req.Images)
+
+	if !h.validateScheduledContent(c, chatID, userID, req.Content, req.Images) {
+		return
+	}
+
+	var replyTo uuid.NullUUID
+	if req.ReplyToMessageID != nil {
+		replyIDStr := strings.TrimSpace(*req.ReplyToMessageID)
+		if replyIDStr != "" {
+			replyUUID, err := uuid.Parse(replyIDStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error: "Invalid reply_to_message_id",
+				})
+				return
+			}
+
+			replyMessage, err := h.dbService.Queries.GetMessageById(c.Request.Context(), replyUUID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					c.JSON(http.StatusNotFound, models.ErrorResponse{
+						Error: "Replied message not found",
+					})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to fetch replied message",
+				})
+				return
+			}
+
+			if replyMessage.ChatID != chatID {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error: "Cannot reply to a message from another chat",
+				})
+				return
+			}
+
+			replyTo = uuid.NullUUID{UUID: replyUUID, Valid: true}
+		}
+	}
+
+	var content sql.NullString
+	if req.Content != "" {
+		content = sql.NullString{String: req.Content, Valid: true}
+	}
+
+	encodedEntities, err := encodeEntities(entities)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to encode message entities",
+		})
+		return
+	}
+
+	images := req.Images
+	if images == nil {
+		images = []string{}
+	}
+
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	// The images are reserved now so they cannot be sent or scheduled again while this message waits
+	if !reserveScheduledImages(c, qtx, userID, images) {
+		return
+	}
+
+	scheduled, err := qtx.CreateScheduledMessage(c.Request.Context(), database.CreateScheduledMessageParams{
+		ChatID:           chatID,
+		SenderID:         userID,
+		Content:          content,
+		Entities:         encodedEntities,
+		Images:           images,
+		ReplyToMessageID: replyTo,
+		SendAt:           req.SendAt.UTC(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to schedule message",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toScheduledMessageResponse(c.Request.Context(), h.store, scheduled))
+}
+
+func (h *MessageHandler) GetScheduledMessages(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.GetScheduledMessagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var chatID uuid.NullUUID
+	if req.ChatID != nil {
+		parsed, err := uuid.Parse(*req.ChatID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid chat ID",
+			})
+			return
+		}
+		chatID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	scheduledData, err := h.dbService.Queries.GetPendingScheduledMessagesBySender(c.Request.Context(), database.GetPendingScheduledMessagesBySenderParams{
+		SenderID: userID,
+		ChatID:   chatID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get scheduled messages",
+		})
+		return
+	}
+
+	items := make([]models.ScheduledMessage, len(scheduledData))
+	for i, scheduled := range scheduledData {
+		items[i] = toScheduledMessageResponse(c.Request.Context(), h.store, scheduled)
+	}
+
+	c.JSON(http.StatusOK, models.GetScheduledMessagesResponse{
+		Items: items,
+	})
+}
+
+func (h *MessageHandler) EditScheduledMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.EditScheduledMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	scheduled, ok := h.loadPendingScheduledMessage(c, req.ID, userID)
+	if !ok {
+		return
+	}
+
+	// Only overwrite the fields that were provided
+	params := database.UpdateScheduledMessageParams{
+		ID:       scheduled.ID,
+		Content:  scheduled.Content,
+		Entities: scheduled.Entities,
+		Images:   scheduled.Images,
+		SendAt:   scheduled.SendAt,
+	}
+
+	if req.Content != nil || req.Entities != nil {
+		content := scheduled.Content.String
+		if req.Content != nil {
+			content = *req.Content
+		}
+
+		// Entities are offsets into the content, so the stored ones only survive if it is unchanged
+		var entities []models.MessageEntity
+		if req.Entities != nil {
+			entities = *req.Entities
+		} else if req.Content == nil {
+			entities = decodeEntities(scheduled.Entities)
+		}
+
+		trimmedContent, prepared, err := prepareMessageContent(content, entities)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+
+		encodedEntities, err := encodeEntities(prepared)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to encode message entities",
+			})
+			return
+		}
+
+		params.Content = sql.NullString{String: trimmedContent, Valid: trimmedContent != ""}
+		params.Entities = encodedEntities
+	}
+
+	if req.Images != nil {
+		params.Images = storedImageURLs(h.store, *req.Images)
+		if params.Images == nil {
+			params.Images = []string{}
+		}
+	}
+
+	if req.SendAt != nil {
+		if !validateSendAt(c, *req.SendAt) {
+			return
+		}
+		params.SendAt = req.SendAt.UTC()
+	}
+
+	if !h.validateScheduledContent(c, scheduled.ChatID, userID, params.Content.String, params.Images) {
+		return
+	}
+
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	// Newly added images are reserved and the ones taken out are freed for other messages
+	added, removed := diffImageURLs(scheduled.Images, params.Images)
+	if !reserveScheduledImages(c, qtx, userID, added) {
+		return
+	}
+
+	if len(removed) > 0 {
+		if err := qtx.ReleaseUploads(c.Request.Context(), database.ReleaseUploadsParams{
+			OwnerID: userID,
+			Urls:    removed,
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to release images",
+			})
+			return
+		}
+	}
+
+	updated, err := qtx.UpdateScheduledMessage(c.Request.Context(), params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error: "Scheduled message has already been sent or cancelled",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update scheduled message",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toScheduledMessageResponse(c.Request.Context(), h.store, updated))
+}
+
+func (h *MessageHandler) CancelScheduledMessage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelScheduledMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	scheduled, ok := h.loadPendingScheduledMessage(c, req.ID, userID)
+	if !ok {
+		return
+	}
+
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to start transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	cancelled, err := qtx.CancelScheduledMessage(c.Request.Context(), scheduled.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to cancel scheduled message",
+		})
+		return
+	}
+
+	// The dispatcher may have picked the message up since it was loaded
+	if cancelled == 0 {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: "Scheduled message has already been sent or cancelled",
+		})
+		return
+	}
+
+	// Re-read under the row lock, since an edit may have changed the images since they were loaded
+	scheduled, err = qtx.GetScheduledMessageById(c.Request.Context(), scheduled.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get scheduled message",
+		})
+		return
+	}
+
+	if err := releaseScheduledImages(c.Request.Context(), qtx, scheduled); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to release images",
+		})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// loadPendingScheduledMessage fetches a scheduled message owned by the user that has not been sent yet
+func (h *MessageHandler) loadPendingScheduledMessage(c *gin.Context, rawID string, userID uuid.UUID) (database.ScheduledMessage, bool) {
+	scheduledID, err := uuid.Parse(rawID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid scheduled message ID",
+		})
+		return database.ScheduledMessage{}, false
+	}
+
+	scheduled, err := h.dbService.Queries.GetScheduledMessageById(c.Request.Context(), scheduledID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Scheduled message not found",
+			})
+			return database.ScheduledMessage{}, false
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get scheduled message",
+		})
+		return database.ScheduledMessage{}, false
+	}
+
+	// Other users' scheduled messages are reported as missing rather than forbidden
+	if scheduled.SenderID != userID {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Scheduled message not found",
+		})
+		return database.ScheduledMessage{}, false
+	}
+
+	if scheduled.Status != constants.ScheduledStatusPending {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: "Scheduled message has already been sent or cancelled",
+		})
+		return database.ScheduledMessage{}, false
+	}
+
+	return scheduled, true
+}
+
+// validateScheduledContent applies the same posting rules as SendMessage at scheduling time
+func (h *MessageHandler) validateScheduledContent(c *gin.Context, chatID, userID uuid.UUID, content string, images []string) bool {
+	settings, err := h.dbService.Queries.GetChatSettings(c.Request.Context(), chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to load chat settings",
+		})
+		return false
+	}
+
+	if settings.WhoCanPost != constants.WhoCanPostEveryone {
+		chat, err := h.dbService.Queries.GetChatMetadata(c.Request.Context(), chatID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load chat",
+			})
+			return false
+		}
+
+		if !canPostInChat(settings, chat.CreatedBy, userID) {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error: "Only the chat admin can post in this chat",
+			})
+			return false
+		}
+	}
+
+	if len(content) > int(settings.MaxMessageLength) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Message content exceeds maximum length of %d characters", settings.MaxMessageLength),
+		})
+		return false
+	}
+
+	if len(images) > int(settings.MaxImages) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Maximum %d images allowed per message", settings.MaxImages),
+		})
+		return false
+	}
+
+	if content == "" && len(images) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Message must have content or images",
+		})
+		return false
+	}
+
+	return true
+}
+
+// reserveScheduledImages claims the images for a scheduled message, so the dispatcher sends them
+// without claiming them again
+func reserveScheduledImages(c *gin.Context, qtx *database.Queries, userID uuid.UUID, images []string) bool {
+	err := claimUploads(c.Request.Context(), qtx, userID, images)
+	if errors.Is(err, errUnavailableImages) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Images must be your own uploads and can only be sent once",
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to reserve images",
+		})
+		return false
+	}
+
+	return true
+}
+
+// releaseScheduledImages frees the images of a scheduled message that will not be sent
+func releaseScheduledImages(ctx context.Context, qtx *database.Queries, scheduled database.ScheduledMessage) error {
+	if len(scheduled.Images) == 0 {
+		return nil
+	}
+
+	return qtx.ReleaseUploads(ctx, database.ReleaseUploadsParams{
+		OwnerID: scheduled.SenderID,
+		Urls:    scheduled.Images,
+	})
+}
+
+// diffImageURLs returns the images only in next and the images only in previous. Repeats in next
+// count as added, so claiming them fails the same way it does for a new message.
+func diffImageURLs(previous, next []string) (added, removed []string) {
+	previousSet := make(map[string]struct{}, len(previous))
+	for _, url := range previous {
+		previousSet[url] = struct{}{}
+	}
+
+	nextSet := make(map[string]struct{}, len(next))
+	for _, url := range next {
+		_, repeated := nextSet[url]
+		_, kept := previousSet[url]
+		if repeated || !kept {
+			added = append(added, url)
+		}
+		nextSet[url] = struct{}{}
+	}
+
+	for _, url := range previous {
+		if _, ok := nextSet[url]; !ok {
+			removed = append(removed, url)
+		}
+	}
+
+	return added, removed
+}
+
+func validateSendAt(c *gin.Context, sendAt time.Time) bool {
+	now := time.Now()
+
+	if !sendAt.After(now) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "send_at must be in the future",
+		})
+		return false
+	}
+
+	if sendAt.After(now.Add(constants.MaxScheduleAheadSeconds * time.Second)) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Messages can only be scheduled up to one year ahead",
+		})
+		return false
+	}
+
+	return true
+}
+
+func toScheduledMessageResponse(ctx context.Context, store storage.Storage, scheduled database.ScheduledMessage) models.ScheduledMessage {
+	var replyTo *uuid.UUID
+	if scheduled.ReplyToMessageID.Valid {
+		replyTo = &scheduled.ReplyToMessageID.UUID
+	}
+
+	return models.ScheduledMessage{
+		ID:               scheduled.ID,
+		ChatID:           scheduled.ChatID,
+		Content:          utils.NullableString(scheduled.Content),
+		Entities:         decodeEntities(scheduled.Entities),
+		Images:           signImageURLs(ctx, store, scheduled.Images),
+		ReplyToMessageID: replyTo,
+		SendAt:           scheduled.SendAt,
+		Status:           scheduled.Status,
+		CreatedAt:        scheduled.CreatedAt,
+		UpdatedAt:        scheduled.UpdatedAt,
+	}
+}
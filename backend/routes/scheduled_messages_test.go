@@ -0,0 +1,246 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+)
+
+func TestDispatchScheduledMessages(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	chatID := createTestChat(t, db, alice, bob, carol)
+
+	schedule := func(userID uuid.UUID, content string) models.ScheduledMessage {
+		t.Helper()
+
+		body, err := json.Marshal(models.CreateScheduledMessageRequest{
+			ChatID:  chatID.String(),
+			Content: content,
+			SendAt:  time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		gin.SetMode(gin.TestMode)
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", userID)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/scheduled", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handler.CreateScheduledMessage(c)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("CreateScheduledMessage() status = %d, body %s", recorder.Code, recorder.Body)
+		}
+
+		var scheduled models.ScheduledMessage
+		if err := json.Unmarshal(recorder.Body.Bytes(), &scheduled); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return scheduled
+	}
+
+	delivered := schedule(alice, "see you soon")
+	// Scheduled by a member who leaves before it is due
+	orphaned := schedule(carol, "bye")
+	if err := queries.RemoveChatMember(ctx, database.RemoveChatMemberParams{ChatID: chatID, UserID: carol}); err != nil {
+		t.Fatalf("remove chat member: %v", err)
+	}
+
+	dispatch := func() bool {
+		t.Helper()
+
+		dispatched, err := handler.dispatchNextScheduledMessage(ctx)
+		if err != nil {
+			t.Fatalf("dispatchNextScheduledMessage() error = %v", err)
+		}
+		return dispatched
+	}
+
+	if dispatch() {
+		t.Fatal("dispatched a message before it was due")
+	}
+
+	if _, err := db.Exec(`UPDATE scheduled_messages SET send_at = send_at - INTERVAL '1 day'`); err != nil {
+		t.Fatalf("make scheduled messages due: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if !dispatch() {
+			t.Fatalf("dispatched %d due messages, want 2", i)
+		}
+	}
+	if dispatch() {
+		t.Error("dispatched a message twice")
+	}
+
+	sent, err := queries.GetScheduledMessageById(ctx, delivered.ID)
+	if err != nil {
+		t.Fatalf("get scheduled message: %v", err)
+	}
+	if sent.Status != constants.ScheduledStatusSent || !sent.SentMessageID.Valid {
+		t.Fatalf("scheduled message status = %s, sent message %v, want it sent", sent.Status, sent.SentMessageID)
+	}
+	message, err := queries.GetMessageById(ctx, sent.SentMessageID.UUID)
+	if err != nil {
+		t.Fatalf("get sent message: %v", err)
+	}
+	if message.SenderID != alice || message.Content.String != "see you soon" {
+		t.Errorf("sent message from %s with content %q, want alice's scheduled content", message.SenderID, message.Content.String)
+	}
+
+	failed, err := queries.GetScheduledMessageById(ctx, orphaned.ID)
+	if err != nil {
+		t.Fatalf("get scheduled message: %v", err)
+	}
+	if failed.Status != constants.ScheduledStatusFailed || !failed.FailureReason.Valid {
+		t.Errorf("scheduled message from a former member status = %s, reason %v, want it failed", failed.Status, failed.FailureReason)
+	}
+}
+
+func TestScheduledMessagesReserveImagesAndKeepEntities(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	handler, _ := newTestHandlers(t, dbService)
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	createImage := func() string {
+		key := fmt.Sprintf("%s/1/%s.jpg", alice, uuid.NewString())
+		upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+			OwnerID:     alice,
+			Key:         key,
+			Url:         handler.store.URL(key),
+			Size:        100,
+			ContentType: "image/jpeg",
+			Variants:    json.RawMessage("[]"),
+		})
+		if err != nil {
+			t.Fatalf("create upload: %v", err)
+		}
+		return upload.Url
+	}
+
+	gin.SetMode(gin.TestMode)
+	call := func(handle gin.HandlerFunc, request any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Set("user_id", alice)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/scheduled", strings.NewReader(string(body)))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		handle(c)
+		return recorder
+	}
+	schedule := func(images []string) *httptest.ResponseRecorder {
+		return call(handler.CreateScheduledMessage, models.CreateScheduledMessageRequest{
+			ChatID:   chatID.String(),
+			Content:  "  hi there  ",
+			Entities: []models.MessageEntity{{Type: constants.EntityTypeBold, Offset: 2, Length: 2}},
+			Images:   images,
+			SendAt:   time.Now().Add(time.Hour),
+		})
+	}
+
+	image := createImage()
+	recorder := schedule([]string{image})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("CreateScheduledMessage() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	var scheduled models.ScheduledMessage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &scheduled); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	wantEntity := models.MessageEntity{Type: constants.EntityTypeBold, Offset: 0, Length: 2}
+	if len(scheduled.Entities) != 1 || scheduled.Entities[0] != wantEntity {
+		t.Errorf("scheduled entities = %+v, want %+v shifted to the trimmed content", scheduled.Entities, wantEntity)
+	}
+
+	if recorder := schedule([]string{image}); recorder.Code != http.StatusBadRequest {
+		t.Errorf("scheduling a reserved image again status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+
+	if _, err := db.Exec(`UPDATE scheduled_messages SET send_at = $1 WHERE id = $2`, time.Now().Add(-time.Minute), scheduled.ID); err != nil {
+		t.Fatalf("make message due: %v", err)
+	}
+	dispatched, err := handler.dispatchNextScheduledMessage(ctx)
+	if err != nil || !dispatched {
+		t.Fatalf("dispatchNextScheduledMessage() = %v, %v, want a dispatched message", dispatched, err)
+	}
+
+	stored, err := queries.GetScheduledMessageById(ctx, scheduled.ID)
+	if err != nil {
+		t.Fatalf("get scheduled message: %v", err)
+	}
+	if stored.Status != constants.ScheduledStatusSent || !stored.SentMessageID.Valid {
+		t.Fatalf("scheduled message status = %s, failure %q, want sent", stored.Status, stored.FailureReason.String)
+	}
+
+	message, err := queries.GetMessageById(ctx, stored.SentMessageID.UUID)
+	if err != nil {
+		t.Fatalf("get sent message: %v", err)
+	}
+	if entities := decodeEntities(message.Entities); len(entities) != 1 || entities[0] != wantEntity {
+		t.Errorf("sent entities = %+v, want %+v", entities, wantEntity)
+	}
+	images, err := queries.GetMessageImages(ctx, []uuid.UUID{message.ID})
+	if err != nil {
+		t.Fatalf("get message images: %v", err)
+	}
+	if len(images) != 1 || images[0].Url != image {
+		t.Errorf("sent images = %+v, want %s", images, image)
+	}
+
+	// Cancelling frees the reserved image for another message
+	other := createImage()
+	recorder = schedule([]string{other})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("CreateScheduledMessage() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &scheduled); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if recorder := call(handler.CancelScheduledMessage, models.CancelScheduledMessageRequest{ID: scheduled.ID.String()}); recorder.Code != http.StatusOK {
+		t.Fatalf("CancelScheduledMessage() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	available, err := uploadsAvailable(ctx, queries, alice, []string{other})
+	if err != nil {
+		t.Fatalf("check uploads: %v", err)
+	}
+	if !available {
+		t.Error("image of a cancelled scheduled message is still reserved")
+	}
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+// StorageQuotaConfig limits how many bytes each user can keep in storage
+type StorageQuotaConfig struct {
+	MaxBytes int64
+}
+
+// StorageQuotaConfigFromEnv reads STORAGE_QUOTA_MB (default: 1024)
+func StorageQuotaConfigFromEnv() StorageQuotaConfig {
+	cfg := StorageQuotaConfig{
+		MaxBytes: constants.DefaultStorageQuota,
+	}
+
+	if quotaEnv := os.Getenv("STORAGE_QUOTA_MB"); quotaEnv != "" {
+		if megabytes, err := strconv.Atoi(quotaEnv); err == nil && megabytes > 0 {
+			cfg.MaxBytes = int64(megabytes) * 1024 * 1024
+		}
+	}
+
+	return cfg
+}
+
+// errStorageQuotaExceeded is returned by recordWithinQuota when the upload does not fit
+var errStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// checkStorageQuota reports whether the user has room for another size bytes, responding with
+// an error when they do not. It is only an early check so large files are not stored in vain,
+// recordWithinQuota makes the final decision when the upload row is written.
+func (h *UploadHandler) checkStorageQuota(c *gin.Context, userID uuid.UUID, size int64) bool {
+	used, err := h.dbService.Queries.GetUserStorageUsed(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to check storage usage",
+		})
+		return false
+	}
+
+	if used+size > h.quota.MaxBytes {
+		h.respondStorageQuotaExceeded(c)
+		return false
+	}
+
+	return true
+}
+
+// recordWithinQuota runs record, which writes the upload row, in a transaction holding the user's
+// usage row, and fails with errStorageQuotaExceeded if size more bytes would not fit. Usage is
+// maintained by a trigger on the uploads table, so it counts image variants and pending direct
+// uploads, and drops as soon as an upload row is deleted. Holding the row makes concurrent uploads
+// by the same user wait for each other, so they cannot all pass the check before any is counted.
+func (h *UploadHandler) recordWithinQuota(ctx context.Context, userID uuid.UUID, size int64, record func(qtx *database.Queries) error) error {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	used, err := qtx.LockUserStorage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if size > 0 && used+size > h.quota.MaxBytes {
+		return errStorageQuotaExceeded
+	}
+
+	if err := record(qtx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (h *UploadHandler) respondStorageQuotaExceeded(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+		Error: fmt.Sprintf("Storage quota exceeded. You can store up to %dMB, delete some images or files to free up space", h.quota.MaxBytes/(1024*1024)),
+	})
+}
+
+// GetStorageUsage reports how much of their quota the user has used, broken down by upload kind
+func (h *UploadHandler) GetStorageUsage(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	used, err := h.dbService.Queries.GetUserStorageUsed(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get storage usage",
+		})
+		return
+	}
+
+	rows, err := h.dbService.Queries.GetUserStorageBreakdown(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get storage usage",
+		})
+		return
+	}
+
+	breakdown := make([]models.StorageUsageByKind, len(rows))
+	for i, row := range rows {
+		breakdown[i] = models.StorageUsageByKind{
+			Kind:    row.Kind,
+			Uploads: row.Uploads,
+			Bytes:   row.Bytes,
+		}
+	}
+
+	c.JSON(http.StatusOK, models.StorageUsageResponse{
+		UsedBytes:      used,
+		QuotaBytes:     h.quota.MaxBytes,
+		RemainingBytes: max(h.quota.MaxBytes-used, 0),
+		Breakdown:      breakdown,
+	})
+}
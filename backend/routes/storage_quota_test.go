@@ -0,0 +1,405 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+func storageUsed(t *testing.T, queries *database.Queries, userID uuid.UUID) int64 {
+	t.Helper()
+
+	used, err := queries.GetUserStorageUsed(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("get storage used: %v", err)
+	}
+	return used
+}
+
+// createTestFileUpload stores a file of size bytes and records it as the user's upload
+
+func createTestFileUpload(t *testing.T, uploadHandler *UploadHandler, userID uuid.UUID, size int64) database.Upload {
+	t.Helper()
+	ctx := context.Background()
+
+	key := fmt.Sprintf("%s/1/%s.txt", userID, uuid.NewString())
+	if err := uploadHandler.store.Put(ctx, key, strings.NewReader(strings.Repeat("x", int(size))), size, "text/plain"); err != nil {
+		t.Fatalf("store file: %v", err)
+	}
+
+	upload, err := uploadHandler.dbService.Queries.CreateFileUpload(ctx, database.CreateFileUploadParams{
+		OwnerID:     userID,
+		Key:         key,
+		Url:         uploadHandler.store.URL(key),
+		Size:        size,
+		ContentType: "text/plain",
+		FileName:    sql.NullString{String: "notes.txt", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("create file upload: %v", err)
+	}
+	return upload
+}
+
+func TestRecordWithinQuotaConcurrentUploads(t *testing.T) {
+	dbService := dbtest.Open(t)
+	_, uploadHandler := newTestHandlers(t, dbService)
+	userID := createTestUser(t, dbService.DB, "alice")
+
+	// Each upload takes 40% of the quota, so only two fit however the requests interleave
+	size := uploadHandler.quota.MaxBytes * 4 / 10
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		recorded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := uploadHandler.recordWithinQuota(context.Background(), userID, size, func(qtx *database.Queries) error {
+				_, err := qtx.CreateFileUpload(context.Background(), database.CreateFileUploadParams{
+					OwnerID:     userID,
+					Key:         fmt.Sprintf("%s/1/%d.txt", userID, i),
+					Url:         fmt.Sprintf("http://localhost/files/%s/1/%d.txt", userID, i),
+					Size:        size,
+					ContentType: "text/plain",
+				})
+				return err
+			})
+			switch {
+			case err == nil:
+				mu.Lock()
+				recorded++
+				mu.Unlock()
+			case !errors.Is(err, errStorageQuotaExceeded):
+				t.Errorf("recordWithinQuota() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if recorded != 2 {
+		t.Errorf("%d uploads recorded, want 2", recorded)
+	}
+	if used := storageUsed(t, dbService.Queries, userID); used != 2*size {
+		t.Errorf("storage used = %d, want %d", used, 2*size)
+	}
+}
+
+func TestDeleteAttachmentFilesReleasesQuota(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	_, uploadHandler := newTestHandlers(t, dbService)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	attach := func(upload database.Upload) (uuid.UUID, []database.Attachment) {
+		messageID := createTestMessage(t, db, chatID, alice, "", time.Now(), time.Time{})
+		attachments, err := queries.AddMessageAttachments(ctx, database.AddMessageAttachmentsParams{
+			MessageID: messageID,
+			UploadIds: []uuid.UUID{upload.ID},
+		})
+		if err != nil {
+			t.Fatalf("attach upload: %v", err)
+		}
+		return messageID, attachments
+	}
+
+	sent := createTestFileUpload(t, uploadHandler, alice, 1000)
+	sentMessage, sentAttachments := attach(sent)
+
+	forwarded := createTestFileUpload(t, uploadHandler, alice, 500)
+	forwardedMessage, forwardedAttachments := attach(forwarded)
+	copyID := createTestMessage(t, db, chatID, bob, "", time.Now(), time.Time{})
+	if _, err := queries.CopyMessageAttachments(ctx, database.CopyMessageAttachmentsParams{
+		SourceMessageID: forwardedMessage,
+		TargetMessageID: copyID,
+	}); err != nil {
+		t.Fatalf("copy attachments: %v", err)
+	}
+
+	if used := storageUsed(t, queries, alice); used != 1500 {
+		t.Fatalf("storage used = %d, want 1500", used)
+	}
+
+	for _, messageID := range []uuid.UUID{sentMessage, forwardedMessage} {
+		if err := queries.DeleteMessage(ctx, messageID); err != nil {
+			t.Fatalf("delete message: %v", err)
+		}
+	}
+	if err := uploadHandler.DeleteAttachmentFiles(ctx, append(sentAttachments, forwardedAttachments...)); err != nil {
+		t.Fatalf("DeleteAttachmentFiles() error = %v", err)
+	}
+
+	if used := storageUsed(t, queries, alice); used != 500 {
+		t.Errorf("storage used = %d, want the forwarded file's 500 bytes", used)
+	}
+	if _, err := uploadHandler.store.Open(ctx, sent.Key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("deleted message's file still stored, Open error = %v", err)
+	}
+
+	object, err := uploadHandler.store.Open(ctx, forwarded.Key)
+	if err != nil {
+		t.Fatalf("forwarded file was deleted: %v", err)
+	}
+	object.Body.Close()
+}
+
+func TestBatchAndChatDeletesReleaseAttachments(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	messageHandler, uploadHandler := newTestHandlers(t, dbService)
+	chatHandler := NewChatActionsHandler(dbService, ws.NewHub(dbService), uploadHandler)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	attachFile := func(chatID uuid.UUID, size int64) uuid.UUID {
+		upload := createTestFileUpload(t, uploadHandler, alice, size)
+		messageID := createTestMessage(t, db, chatID, alice, "", time.Now(), time.Time{})
+		if _, err := queries.AddMessageAttachments(ctx, database.AddMessageAttachmentsParams{
+			MessageID: messageID,
+			UploadIds: []uuid.UUID{upload.ID},
+		}); err != nil {
+			t.Fatalf("attach upload: %v", err)
+		}
+		return messageID
+	}
+
+	batchChat := createTestChat(t, db, alice, bob)
+	first := attachFile(batchChat, 300)
+	second := attachFile(batchChat, 200)
+
+	deletedChat := createTestChat(t, db, alice, bob)
+	attachFile(deletedChat, 400)
+
+	if used := storageUsed(t, queries, alice); used != 900 {
+		t.Fatalf("storage used = %d, want 900", used)
+	}
+
+	body, err := json.Marshal(models.BatchMessagesRequest{MessageIDs: []string{first.String(), second.String()}})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", alice)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/messages/delete", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	messageHandler.DeleteMessages(c, uploadHandler)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("DeleteMessages() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	if used := storageUsed(t, queries, alice); used != 400 {
+		t.Errorf("storage used after batch delete = %d, want 400", used)
+	}
+
+	if err := chatHandler.deleteChatWithAssets(ctx, deletedChat); err != nil {
+		t.Fatalf("deleteChatWithAssets() error = %v", err)
+	}
+	if used := storageUsed(t, queries, alice); used != 0 {
+		t.Errorf("storage used after chat delete = %d, want 0", used)
+	}
+}
+
+func TestEditedOutImagesStopCountingAgainstQuota(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	messageHandler, uploadHandler := newTestHandlers(t, dbService)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	messageID := createTestMessage(t, db, chatID, alice, "look", time.Now(), time.Time{})
+
+	var urls []string
+	for _, size := range []int64{1000, 300} {
+		key := fmt.Sprintf("%s/1/%s.jpg", alice, uuid.NewString())
+		upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+			OwnerID:     alice,
+			Key:         key,
+			Url:         uploadHandler.store.URL(key),
+			Size:        size,
+			ContentType: "image/jpeg",
+			Variants:    json.RawMessage("[]"),
+		})
+		if err != nil {
+			t.Fatalf("create upload: %v", err)
+		}
+		if _, err := queries.AddMessageImage(ctx, database.AddMessageImageParams{MessageID: messageID, Url: upload.Url}); err != nil {
+			t.Fatalf("add image: %v", err)
+		}
+		urls = append(urls, upload.Url)
+	}
+
+	if used := storageUsed(t, queries, alice); used != 1300 {
+		t.Fatalf("storage used = %d, want 1300", used)
+	}
+
+	body, err := json.Marshal(models.EditMessageRequest{
+		MessageID:     messageID.String(),
+		Content:       "look again",
+		RemovedImages: []string{urls[0]},
+	})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", alice)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/messages/edit", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	messageHandler.EditMessage(c, uploadHandler)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("EditMessage() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	if used := storageUsed(t, queries, alice); used != 300 {
+		t.Errorf("storage used = %d, want only the remaining image's 300 bytes", used)
+	}
+
+	// The edited-out image is still stored for the message's edit history
+	upload, err := queries.GetUploadByUrl(ctx, urls[0])
+	if err != nil {
+		t.Fatalf("edited-out upload was deleted: %v", err)
+	}
+	if upload.Charged {
+		t.Error("edited-out upload is still charged")
+	}
+
+	breakdown, err := queries.GetUserStorageBreakdown(ctx, alice)
+	if err != nil {
+		t.Fatalf("get storage breakdown: %v", err)
+	}
+	if len(breakdown) != 1 || breakdown[0].Uploads != 1 || breakdown[0].Bytes != 300 {
+		t.Errorf("storage breakdown = %+v, want one 300 byte image", breakdown)
+	}
+}
+
+func TestStorageQuotaConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		quota string
+		want  int64
+	}{
+		{name: "default", quota: "", want: constants.DefaultStorageQuota},
+		{name: "configured", quota: "50", want: 50 * 1024 * 1024},
+		{name: "invalid keeps the default", quota: "lots", want: constants.DefaultStorageQuota},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STORAGE_QUOTA_MB", tt.quota)
+
+			if got := StorageQuotaConfigFromEnv(); got.MaxBytes != tt.want {
+				t.Errorf("StorageQuotaConfigFromEnv() = %d bytes, want %d", got.MaxBytes, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadFileRespectsStorageQuota(t *testing.T) {
+	dbService := dbtest.Open(t)
+	_, uploadHandler := newTestHandlers(t, dbService)
+	uploadHandler.attachments = AttachmentConfig{AllowedTypes: map[string]bool{"application/pdf": true}, MaxSize: 1 << 20}
+	userID := createTestUser(t, dbService.DB, "alice")
+
+	// Leave room for one more copy of the test file but not two
+	createTestFileUpload(t, uploadHandler, userID, uploadHandler.quota.MaxBytes-int64(len(testPDF))-10)
+
+	if recorder := uploadFile(t, uploadHandler, userID, "first.pdf", []byte(testPDF)); recorder.Code != http.StatusOK {
+		t.Fatalf("UploadFile() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+	if recorder := uploadFile(t, uploadHandler, userID, "second.pdf", []byte(testPDF)); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("UploadFile() over the quota status = %d, want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	if used := storageUsed(t, dbService.Queries, userID); used != uploadHandler.quota.MaxBytes-10 {
+		t.Errorf("storage used = %d, want %d", used, uploadHandler.quota.MaxBytes-10)
+	}
+}
+
+func TestGetStorageUsage(t *testing.T) {
+	dbService := dbtest.Open(t)
+	queries := dbService.Queries
+	_, uploadHandler := newTestHandlers(t, dbService)
+	ctx := context.Background()
+	userID := createTestUser(t, dbService.DB, "alice")
+
+	// Resized copies count towards the quota along with the original image
+	variants, err := json.Marshal([]storedImageVariant{{Name: "small", Key: "small.jpg", URL: "http://localhost/files/small.jpg", Size: 200}})
+	if err != nil {
+		t.Fatalf("encode variants: %v", err)
+	}
+	if _, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+		OwnerID:     userID,
+		Key:         userID.String() + "/1/photo.jpg",
+		Url:         uploadHandler.store.URL(userID.String() + "/1/photo.jpg"),
+		Size:        1000,
+		ContentType: "image/jpeg",
+		Variants:    variants,
+	}); err != nil {
+		t.Fatalf("create upload: %v", err)
+	}
+	createTestFileUpload(t, uploadHandler, userID, 300)
+	createTestFileUpload(t, uploadHandler, userID, 500)
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", userID)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/user/storage", nil)
+
+	uploadHandler.GetStorageUsage(c)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GetStorageUsage() status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var response models.StorageUsageResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.UsedBytes != 2000 || response.QuotaBytes != 1<<20 || response.RemainingBytes != 1<<20-2000 {
+		t.Errorf("usage = %d of %d with %d remaining, want 2000 of %d", response.UsedBytes, response.QuotaBytes, response.RemainingBytes, 1<<20)
+	}
+
+	want := []models.StorageUsageByKind{{Kind: "file", Uploads: 2, Bytes: 800}, {Kind: "image", Uploads: 1, Bytes: 1200}}
+	if fmt.Sprint(response.Breakdown) != fmt.Sprint(want) {
+		t.Errorf("breakdown = %+v, want %+v", response.Breakdown, want)
+	}
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/utils"
+	ws "github.com/anmol7470/bubbles/backend/websocket"
+)
+
+// createSystemMessage records a chat event in the timeline, leaving the transaction to the caller.
+// The metadata always carries the event name and the acting user so clients can render it themselves.
+func createSystemMessage(ctx context.Context, qtx *database.Queries, chatID, actorID uuid.UUID, event, content string, details map[string]any) (database.Message, error) {
+	metadata := map[string]any{
+		"event":    event,
+		"actor_id": actorID.String(),
+	}
+	for key, value := range details {
+		metadata[key] = value
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return database.Message{}, err
+	}
+
+	return qtx.CreateSystemMessage(ctx, database.CreateSystemMessageParams{
+		ChatID:   chatID,
+		SenderID: actorID,
+		Content:  sql.NullString{String: content, Valid: true},
+		Metadata: encoded,
+	})
+}
+
+func broadcastSystemMessage(hub *ws.Hub, message database.Message, actorUsername string) {
+	hub.BroadcastToChat(message.ChatID.String(), ws.WSMessage{
+		Type: ws.EventMessageSent,
+		Payload: ws.MessageSentPayload{
+			ID:             message.ID.String(),
+			ChatID:         message.ChatID.String(),
+			SenderID:       message.SenderID.String(),
+			SenderUsername: actorUsername,
+			Content:        utils.NullableString(message.Content),
+			Images:         []ws.MessageImage{},
+			IsDeleted:      false,
+			IsEdited:       false,
+			CreatedAt:      message.CreatedAt,
+			MessageType:    message.MessageType,
+			Metadata:       message.Metadata,
+		},
+	})
+}
+
+// systemMessageMetadata only exposes metadata for system messages, user messages carry an empty object
+func systemMessageMetadata(messageType string, metadata json.RawMessage) json.RawMessage {
+	if messageType != constants.MessageTypeSystem {
+		return nil
+	}
+	return metadata
+}
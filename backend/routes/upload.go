@@ -3,70 +3,46 @@ package routes
 import (
 	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/imaging"
 	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
 )
 
 type UploadHandler struct {
-	s3Client   *s3.Client
-	bucketName string
-	publicURL  string
-}
-
-func NewUploadHandler() (*UploadHandler, error) {
-	accountID := os.Getenv("R2_ACCOUNT_ID")
-	accessKeyID := os.Getenv("R2_ACCESS_KEY_ID")
-	secretAccessKey := os.Getenv("R2_SECRET_ACCESS_KEY")
-	bucketName := os.Getenv("R2_BUCKET_NAME")
-	publicURL := os.Getenv("R2_PUBLIC_URL")
-
-	if accountID == "" || accessKeyID == "" || secretAccessKey == "" || bucketName == "" || publicURL == "" {
-		return nil, fmt.Errorf("missing required R2 configuration")
-	}
-
-	// Construct the R2 endpoint
-	r2Endpoint := fmt.Sprintf("https://%s.r2.cloudflarestorage.com", accountID)
-
-	// Create AWS config with custom credentials
-	cfg, err := config.LoadDefaultConfig(context.Background(),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			accessKeyID,
-			secretAccessKey,
-			"",
-		)),
-		config.WithRegion("auto"),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
-	}
-
-	// Create S3 client with R2 endpoint
-	s3Client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(r2Endpoint)
-	})
+	dbService   *database.Service
+	store       storage.Storage
+	attachments AttachmentConfig
+	quota       StorageQuotaConfig
+}
 
+func NewUploadHandler(dbService *database.Service, store storage.Storage, attachments AttachmentConfig, quota StorageQuotaConfig) *UploadHandler {
 	return &UploadHandler{
-		s3Client:   s3Client,
-		bucketName: bucketName,
-		publicURL:  publicURL,
-	}, nil
+		dbService:   dbService,
+		store:       store,
+		attachments: attachments,
+		quota:       quota,
+	}
 }
 
+// errUnavailableImages is returned when a message references images the sender did not upload
+// or has already attached elsewhere
+var errUnavailableImages = errors.New("images must be unused uploads owned by the sender")
+
 var allowedImageTypes = map[string]bool{
 	"image/jpeg": true,
 	"image/jpg":  true,
@@ -147,56 +123,296 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 		return
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(header.Filename)
-	if ext == "" {
-		// Determine extension from content type
-		switch contentType {
-		case "image/jpeg":
-			ext = ".jpg"
-		case "image/png":
-			ext = ".png"
-		case "image/webp":
-			ext = ".webp"
-		}
+	// Decode the whole file and re-encode it, which rejects malformed images and drops EXIF
+	// metadata such as GPS coordinates
+	processed, err := imaging.Process(fileBytes, imaging.DefaultSizes)
+	if err != nil {
+		respondImageProcessingError(c, err)
+		return
 	}
 
-	// Create a unique key with user ID and timestamp
-	timestamp := time.Now().Unix()
-	filename := fmt.Sprintf("%s/%d/%s%s", userID, timestamp, uuid.New().String(), ext)
-
-	// Upload to R2
-	_, err = h.s3Client.PutObject(context.Background(), &s3.PutObjectInput{
-		Bucket:      aws.String(h.bucketName),
-		Key:         aws.String(filename),
-		Body:        bytes.NewReader(fileBytes),
-		ContentType: aws.String(contentType),
-	})
+	// The quota covers the resized copies as well as the original
+	if !h.checkStorageQuota(c, userID.(uuid.UUID), processedImageSize(processed)) {
+		return
+	}
 
+	filename, variants, err := h.storeProcessedImage(c.Request.Context(), userID.(uuid.UUID), processed)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: fmt.Sprintf("Failed to upload image: %v", err),
 		})
 		return
 	}
+	storedKeys := storedImageKeys(filename, variants)
 
-	// Construct public URL
-	var imageURL = fmt.Sprintf("%s/%s", strings.TrimSuffix(h.publicURL, "/"), filename)
+	encodedVariants, err := json.Marshal(variants)
+	if err != nil {
+		h.deleteKeys(storedKeys)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"url": imageURL,
+	// Record ownership so the image can only be attached by the user who uploaded it
+	var upload database.Upload
+	err = h.recordWithinQuota(c.Request.Context(), userID.(uuid.UUID), processedImageSize(processed), func(qtx *database.Queries) error {
+		var err error
+		upload, err = qtx.CreateUpload(c.Request.Context(), database.CreateUploadParams{
+			OwnerID:     userID.(uuid.UUID),
+			Key:         filename,
+			Url:         h.store.URL(filename),
+			Size:        int64(len(processed.Original.Data)),
+			ContentType: processed.ContentType,
+			Width:       sql.NullInt32{Int32: int32(processed.Original.Width), Valid: true},
+			Height:      sql.NullInt32{Int32: int32(processed.Original.Height), Valid: true},
+			Variants:    encodedVariants,
+			Blurhash:    sql.NullString{String: processed.Blurhash, Valid: processed.Blurhash != ""},
+		})
+		return err
 	})
+	if err != nil {
+		h.deleteKeys(storedKeys)
+		if errors.Is(err, errStorageQuotaExceeded) {
+			h.respondStorageQuotaExceeded(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, signMessageImage(c.Request.Context(), h.store, models.MessageImage{
+		URL:      upload.Url,
+		Width:    utils.NullableInt32(upload.Width),
+		Height:   utils.NullableInt32(upload.Height),
+		Blurhash: utils.NullableString(upload.Blurhash),
+		Variants: decodeImageVariants(upload.Variants),
+	}))
+}
+
+// respondImageProcessingError maps an imaging.Process failure to a response
+func respondImageProcessingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid file type. Only JPEG, PNG, and WebP images are allowed",
+		})
+	case errors.Is(err, imaging.ErrTooLarge):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Image dimensions too large. Maximum is %d megapixels", imaging.MaxPixels/1_000_000),
+		})
+	case errors.Is(err, imaging.ErrMalformed):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid or corrupted image",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process image",
+		})
+	}
+}
+
+// processedImageSize is how many bytes a processed image takes up in storage with its variants
+func processedImageSize(processed *imaging.Result) int64 {
+	size := int64(len(processed.Original.Data))
+	for _, variant := range processed.Variants {
+		size += int64(len(variant.Data))
+	}
+	return size
+}
+
+// storeProcessedImage stores a processed image and its variants under a new key and returns the
+// original's key. Objects already stored are removed again if a later one fails.
+func (h *UploadHandler) storeProcessedImage(ctx context.Context, userID uuid.UUID, processed *imaging.Result) (string, []storedImageVariant, error) {
+	// Create a unique key with user ID and timestamp. The extension comes from the re-encoded
+	// format rather than the client's filename so stored objects are always served as images.
+	timestamp := time.Now().Unix()
+	baseKey := fmt.Sprintf("%s/%d/%s", userID, timestamp, uuid.New().String())
+	filename := baseKey + processed.Ext
+
+	storedKeys := make([]string, 0, len(processed.Variants)+1)
+	put := func(key string, data []byte) error {
+		if err := h.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), processed.ContentType); err != nil {
+			return err
+		}
+		storedKeys = append(storedKeys, key)
+		return nil
+	}
+
+	if err := put(filename, processed.Original.Data); err != nil {
+		return "", nil, err
+	}
+
+	variants := make([]storedImageVariant, 0, len(processed.Variants))
+	for _, variant := range processed.Variants {
+		key := baseKey + "_" + variant.Name + processed.Ext
+		if err := put(key, variant.Data); err != nil {
+			h.deleteKeys(storedKeys)
+			return "", nil, err
+		}
+
+		variants = append(variants, storedImageVariant{
+			Name:   variant.Name,
+			Key:    key,
+			URL:    h.store.URL(key),
+			Width:  int32(variant.Width),
+			Height: int32(variant.Height),
+			Size:   int64(len(variant.Data)),
+		})
+	}
+
+	return filename, variants, nil
+}
+
+// storedImageKeys lists every object stored for an image
+func storedImageKeys(key string, variants []storedImageVariant) []string {
+	keys := make([]string, 0, len(variants)+1)
+	keys = append(keys, key)
+	for _, variant := range variants {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
+
+// storedImageVariant is how a resized copy of an upload is recorded in the uploads and images tables
+type storedImageVariant struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+	Size   int64  `json:"size"`
+}
+
+func decodeStoredVariants(raw json.RawMessage) []storedImageVariant {
+	var variants []storedImageVariant
+	if err := json.Unmarshal(raw, &variants); err != nil {
+		return nil
+	}
+	return variants
 }
 
+// deleteKeys removes objects stored for an upload that could not be completed
+func (h *UploadHandler) deleteKeys(keys []string) {
+	for _, key := range keys {
+		if err := h.store.Delete(context.Background(), key); err != nil {
+			log.Printf("Failed to delete unrecorded upload %s: %v", key, err)
+		}
+	}
+}
+
+// DeleteImage removes an uploaded image and its variants by URL. URLs that do not belong to the
+// configured storage (e.g. external links or objects from a previous storage driver) are left alone.
 func (h *UploadHandler) DeleteImage(imageURL string) error {
-	key := strings.TrimPrefix(imageURL, h.publicURL+"/")
-	key = strings.TrimPrefix(key, h.publicURL)
-	key = strings.TrimPrefix(key, "/")
+	key, ok := h.store.KeyForURL(imageURL)
+	if !ok {
+		return nil
+	}
 
-	_, err := h.s3Client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
-		Bucket: aws.String(h.bucketName),
-		Key:    aws.String(key),
+	ctx := context.Background()
+
+	// Images from before uploads were recorded have no variants
+	var variants []storedImageVariant
+	upload, err := h.dbService.Queries.GetUploadByUrl(ctx, imageURL)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		variants = decodeStoredVariants(upload.Variants)
+	}
+
+	if err := h.deleteUploadObjects(ctx, key, variants); err != nil {
+		return err
+	}
+
+	// Dropping the row releases the space from the owner's quota right away instead of on the next sweep
+	if upload.ID != uuid.Nil {
+		return h.dbService.Queries.DeleteUploads(ctx, []uuid.UUID{upload.ID})
+	}
+	return nil
+}
+
+// deleteUploadObjects removes an upload's variants and then the original object
+func (h *UploadHandler) deleteUploadObjects(ctx context.Context, key string, variants []storedImageVariant) error {
+	for _, variant := range variants {
+		if err := h.store.Delete(ctx, variant.Key); err != nil {
+			return err
+		}
+	}
+	return h.store.Delete(ctx, key)
+}
+
+// unreferencedImageURLs filters out images that are still attached to a live message, since
+// forwarded messages share the original message's stored objects, or kept in the edit history
+// of one. Images only kept for history stop counting against the owner's quota and are left to
+// the upload sweeper once the message is gone.
+func unreferencedImageURLs(ctx context.Context, queries *database.Queries, imageURLs []string) ([]string, error) {
+	if len(imageURLs) == 0 {
+		return imageURLs, nil
+	}
+
+	if err := queries.UnchargeRevisionImages(ctx, imageURLs); err != nil {
+		return nil, err
+	}
+
+	inUse, err := queries.GetImageUrlsInUse(ctx, imageURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	inUseSet := make(map[string]struct{}, len(inUse))
+	for _, url := range inUse {
+		inUseSet[url] = struct{}{}
+	}
+
+	unreferenced := make([]string, 0, len(imageURLs))
+	for _, url := range imageURLs {
+		if _, ok := inUseSet[url]; !ok {
+			unreferenced = append(unreferenced, url)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+// claimUploads marks the images as used by the owner, failing with errUnavailableImages unless
+// every URL is an upload of theirs that has not been attached before
+func claimUploads(ctx context.Context, qtx *database.Queries, ownerID uuid.UUID, imageURLs []string) error {
+	if len(imageURLs) == 0 {
+		return nil
+	}
+
+	claimed, err := qtx.ClaimUploads(ctx, database.ClaimUploadsParams{
+		OwnerID: ownerID,
+		Urls:    imageURLs,
 	})
+	if err != nil {
+		return err
+	}
+
+	// Duplicate URLs claim a single row, so they are rejected here as well
+	if len(claimed) != len(imageURLs) {
+		return errUnavailableImages
+	}
+
+	return nil
+}
+
+// uploadsAvailable reports whether every URL is an unused upload of the owner without claiming them
+func uploadsAvailable(ctx context.Context, queries *database.Queries, ownerID uuid.UUID, imageURLs []string) (bool, error) {
+	if len(imageURLs) == 0 {
+		return true, nil
+	}
+
+	available, err := queries.GetAvailableUploadUrls(ctx, database.GetAvailableUploadUrlsParams{
+		OwnerID: ownerID,
+		Urls:    imageURLs,
+	})
+	if err != nil {
+		return false, err
+	}
 
-	return err
+	return len(available) == len(imageURLs), nil
 }
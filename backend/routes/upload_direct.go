@@ -0,0 +1,386 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/imaging"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+// How much of a direct upload is read back to check its type and dimensions
+const directUploadSniffSize = 64 * 1024
+
+var directUploadExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// PresignUpload starts a direct upload: the client gets a short-lived URL to PUT the file to
+// storage itself, then calls CompleteUpload, which re-encodes the file like UploadImage does.
+func (h *UploadHandler) PresignUpload(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	uploader, ok := h.store.(storage.DirectUploader)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{
+			Error: "Direct uploads are not supported by the configured storage",
+		})
+		return
+	}
+
+	var req models.PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ext, ok := directUploadExtensions[req.ContentType]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid file type. Only JPEG, PNG, and WebP images are allowed",
+		})
+		return
+	}
+
+	if req.Size > constants.MaxImageSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "File too large. Maximum size is 4MB",
+		})
+		return
+	}
+
+	// The declared size is reserved until the upload is completed or swept
+	if !h.checkStorageQuota(c, userID, req.Size) {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d/%s%s", userID, time.Now().Unix(), uuid.New().String(), ext)
+
+	presigned, err := uploader.PresignPut(c.Request.Context(), key, req.ContentType, req.Size, constants.PresignedUploadTTLSeconds*time.Second)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to prepare upload",
+		})
+		return
+	}
+
+	// Pending uploads cannot be attached until they are completed, and are swept if they never are
+	var upload database.Upload
+	err = h.recordWithinQuota(c.Request.Context(), userID, req.Size, func(qtx *database.Queries) error {
+		var err error
+		upload, err = qtx.CreatePendingUpload(c.Request.Context(), database.CreatePendingUploadParams{
+			OwnerID:     userID,
+			Key:         key,
+			Url:         h.store.URL(key),
+			Size:        req.Size,
+			ContentType: req.ContentType,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, errStorageQuotaExceeded) {
+			h.respondStorageQuotaExceeded(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PresignUploadResponse{
+		UploadID:      upload.ID,
+		UploadURL:     presigned.URL,
+		UploadMethod:  presigned.Method,
+		UploadHeaders: presigned.Headers,
+		ExpiresAt:     presigned.ExpiresAt,
+		URL:           upload.Url,
+	})
+}
+
+// CompleteUpload checks that a direct upload arrived as declared, then re-encodes it the same way
+// as UploadImage, replacing the raw object with the cleaned original and its variants so
+// metadata such as GPS coordinates never reaches other users. Files of the wrong size or type
+// are deleted and the upload has to be started again.
+func (h *UploadHandler) CompleteUpload(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	uploader, ok := h.store.(storage.DirectUploader)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{
+			Error: "Direct uploads are not supported by the configured storage",
+		})
+		return
+	}
+
+	var req models.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	uploadID, err := uuid.Parse(req.UploadID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid upload ID",
+		})
+		return
+	}
+
+	upload, err := h.dbService.Queries.GetPendingUpload(c.Request.Context(), database.GetPendingUploadParams{
+		ID:      uploadID,
+		OwnerID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Upload not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get upload",
+		})
+		return
+	}
+
+	// A rejected upload has to be started again, so neither the object nor its reservation is kept
+	discard := func() {
+		if err := h.store.Delete(c.Request.Context(), upload.Key); err != nil {
+			log.Printf("Failed to delete rejected upload %s: %v", upload.Key, err)
+		}
+		if err := h.discardPendingUpload(c.Request.Context(), upload); err != nil {
+			log.Printf("Failed to delete rejected upload record %s: %v", upload.ID, err)
+		}
+	}
+
+	reason, err := verifyDirectUpload(c.Request.Context(), uploader, upload)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "The file has not been uploaded yet",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to verify upload",
+		})
+		return
+	}
+
+	if reason != "" {
+		discard()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: reason,
+		})
+		return
+	}
+
+	object, err := h.store.Open(c.Request.Context(), upload.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read upload",
+		})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(object.Body, upload.Size))
+	object.Body.Close()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read upload",
+		})
+		return
+	}
+
+	processed, err := imaging.Process(data, imaging.DefaultSizes)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) || errors.Is(err, imaging.ErrMalformed) {
+			discard()
+		}
+		respondImageProcessingError(c, err)
+		return
+	}
+
+	// The declared size is already counted against the quota, so only growth needs checking
+	storedSize := processedImageSize(processed)
+	if storedSize > upload.Size && !h.checkStorageQuota(c, userID, storedSize-upload.Size) {
+		return
+	}
+
+	filename, variants, err := h.storeProcessedImage(c.Request.Context(), userID, processed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: fmt.Sprintf("Failed to upload image: %v", err),
+		})
+		return
+	}
+	storedKeys := storedImageKeys(filename, variants)
+
+	encodedVariants, err := json.Marshal(variants)
+	if err != nil {
+		h.deleteKeys(storedKeys)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
+
+	var completed database.Upload
+	err = h.recordWithinQuota(c.Request.Context(), userID, storedSize-upload.Size, func(qtx *database.Queries) error {
+		var err error
+		completed, err = qtx.CompleteUpload(c.Request.Context(), database.CompleteUploadParams{
+			ID:          upload.ID,
+			Key:         filename,
+			Url:         h.store.URL(filename),
+			Size:        int64(len(processed.Original.Data)),
+			ContentType: processed.ContentType,
+			Width:       sql.NullInt32{Int32: int32(processed.Original.Width), Valid: true},
+			Height:      sql.NullInt32{Int32: int32(processed.Original.Height), Valid: true},
+			Variants:    encodedVariants,
+			Blurhash:    sql.NullString{String: processed.Blurhash, Valid: processed.Blurhash != ""},
+		})
+		if err != nil {
+			return err
+		}
+		return qtx.TrackRawUpload(c.Request.Context(), rawUploadParams(upload))
+	})
+	if err != nil {
+		h.deleteKeys(storedKeys)
+		if errors.Is(err, errStorageQuotaExceeded) {
+			h.respondStorageQuotaExceeded(c)
+			return
+		}
+		// A concurrent request may have completed the upload first
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error: "Upload not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to complete upload",
+		})
+		return
+	}
+
+	// Only the re-encoded copies are served, so the file as sent by the client is not kept. Its key
+	// stays tracked in case it is uploaded to again before the presigned URL expires.
+	if err := h.store.Delete(c.Request.Context(), upload.Key); err != nil {
+		log.Printf("Failed to delete raw direct upload %s: %v", upload.Key, err)
+	}
+
+	c.JSON(http.StatusOK, signMessageImage(c.Request.Context(), h.store, models.MessageImage{
+		URL:      completed.Url,
+		Width:    utils.NullableInt32(completed.Width),
+		Height:   utils.NullableInt32(completed.Height),
+		Blurhash: utils.NullableString(completed.Blurhash),
+		Variants: decodeImageVariants(completed.Variants),
+	}))
+}
+
+// discardPendingUpload drops a rejected upload's reservation while keeping its raw key tracked
+func (h *UploadHandler) discardPendingUpload(ctx context.Context, upload database.Upload) error {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	if err := qtx.DeleteUploads(ctx, []uuid.UUID{upload.ID}); err != nil {
+		return err
+	}
+	if err := qtx.TrackRawUpload(ctx, rawUploadParams(upload)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rawUploadParams records the key a direct upload was presigned for. The presigned URL can be
+// used again until it expires, so the key is left for the upload sweeper, whose grace period is
+// longer than PresignedUploadTTLSeconds, instead of being forgotten with an object still to come.
+// The row is pending so it can never be attached, and uncharged so it does not count to the quota.
+func rawUploadParams(upload database.Upload) database.TrackRawUploadParams {
+	return database.TrackRawUploadParams{
+		OwnerID:     upload.OwnerID,
+		Key:         upload.Key,
+		Url:         upload.Url,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+	}
+}
+
+// verifyDirectUpload compares the stored object with what was declared when it was presigned and
+// sniffs its first bytes, so obviously wrong files are rejected before the whole file is
+// downloaded and decoded. A non-empty reason means the upload must be rejected.
+func verifyDirectUpload(ctx context.Context, uploader storage.DirectUploader, upload database.Upload) (string, error) {
+	object, err := uploader.Stat(ctx, upload.Key)
+	if err != nil {
+		return "", err
+	}
+
+	if object.Size != upload.Size {
+		return "Uploaded file size does not match the declared size", nil
+	}
+	if object.ContentType != upload.ContentType {
+		return "Uploaded file type does not match the declared type", nil
+	}
+
+	prefix, err := uploader.OpenPrefix(ctx, upload.Key, directUploadSniffSize)
+	if err != nil {
+		return "", err
+	}
+	defer prefix.Body.Close()
+
+	head, err := io.ReadAll(io.LimitReader(prefix.Body, directUploadSniffSize))
+	if err != nil {
+		return "", err
+	}
+
+	if http.DetectContentType(head) != upload.ContentType {
+		return "Uploaded file is not a valid image of the declared type", nil
+	}
+
+	// Large JPEG metadata can push the frame header past the prefix, in which case the size is
+	// only checked once imaging.Process decodes the whole file
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return "", nil
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > imaging.MaxPixels {
+		return fmt.Sprintf("Image dimensions too large. Maximum is %d megapixels", imaging.MaxPixels/1_000_000), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/storage"
+)
+
+// directStore lets a local store accept direct uploads by writing them itself
+type directStore struct {
+	*storage.LocalStorage
+}
+
+func (d directStore) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*storage.PresignedUpload, error) {
+	return &storage.PresignedUpload{URL: d.URL(key), Method: http.MethodPut, ExpiresAt: time.Now().Add(expires)}, nil
+}
+
+func (d directStore) Stat(ctx context.Context, key string) (*storage.Object, error) {
+	object, err := d.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	object.Body.Close()
+	object.Body = nil
+	return object, nil
+}
+
+func (d directStore) OpenPrefix(ctx context.Context, key string, n int64) (*storage.Object, error) {
+	object, err := d.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	object.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(object.Body, n), object.Body}
+	return object, nil
+}
+
+func testJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// completeDirectUpload stores data as a pending direct upload that declared size, then completes it
+func completeDirectUpload(t *testing.T, dbService *database.Service, data []byte, size int64) (*httptest.ResponseRecorder, database.Upload, directStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	local, err := storage.NewLocalStorage(t.TempDir(), "http://localhost/files")
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	store := directStore{local}
+	handler := NewUploadHandler(dbService, store, AttachmentConfig{}, StorageQuotaConfig{MaxBytes: 1 << 20})
+
+	userID := createTestUser(t, dbService.DB, "uploader")
+	key := userID.String() + "/1/raw.jpg"
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatalf("store raw upload: %v", err)
+	}
+
+	upload, err := dbService.Queries.CreatePendingUpload(ctx, database.CreatePendingUploadParams{
+		OwnerID:     userID,
+		Key:         key,
+		Url:         store.URL(key),
+		Size:        size,
+		ContentType: "image/jpeg",
+	})
+	if err != nil {
+		t.Fatalf("create pending upload: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Set("user_id", userID)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/upload/complete", strings.NewReader(`{"upload_id":"`+upload.ID.String()+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CompleteUpload(c)
+	return recorder, upload, store
+}
+
+func TestCompleteUploadReencodesImage(t *testing.T) {
+	dbService := dbtest.Open(t)
+	ctx := context.Background()
+
+	data := testJPEG(t, 640, 480)
+	recorder, pending, store := completeDirectUpload(t, dbService, data, int64(len(data)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", recorder.Code, recorder.Body)
+	}
+
+	var completedImage models.MessageImage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &completedImage); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if completedImage.URL == pending.Url {
+		t.Errorf("URL = %s, want the re-encoded copy rather than the raw upload", completedImage.URL)
+	}
+	if completedImage.Blurhash == nil || *completedImage.Blurhash == "" {
+		t.Error("response has no blurhash")
+	}
+	if len(completedImage.Variants) == 0 {
+		t.Error("response has no variants")
+	}
+
+	completed, err := dbService.Queries.GetUploadByUrl(ctx, completedImage.URL)
+	if err != nil {
+		t.Fatalf("get completed upload: %v", err)
+	}
+	if completed.ID != pending.ID || completed.Pending {
+		t.Errorf("upload %s pending = %v, want %s completed", completed.ID, completed.Pending, pending.ID)
+	}
+
+	if _, err := store.Open(ctx, pending.Key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("raw upload still stored, Open error = %v", err)
+	}
+	checkRawKeySwept(t, dbService, store, pending.Key)
+	for _, key := range storedImageKeys(completed.Key, decodeStoredVariants(completed.Variants)) {
+		object, err := store.Open(ctx, key)
+		if err != nil {
+			t.Errorf("open %s: %v", key, err)
+			continue
+		}
+		object.Body.Close()
+	}
+}
+
+func TestCompleteUploadRejectsMismatchedSize(t *testing.T) {
+	dbService := dbtest.Open(t)
+	ctx := context.Background()
+
+	data := testJPEG(t, 64, 64)
+	recorder, pending, store := completeDirectUpload(t, dbService, data, int64(len(data))+1)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+
+	if _, err := store.Open(ctx, pending.Key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("rejected upload still stored, Open error = %v", err)
+	}
+	var remaining int
+	if err := dbService.DB.QueryRow(`SELECT COUNT(*) FROM uploads WHERE id = $1`, pending.ID).Scan(&remaining); err != nil {
+		t.Fatalf("count uploads: %v", err)
+	}
+	if remaining != 0 {
+		t.Error("rejected upload record still exists")
+	}
+	checkRawKeySwept(t, dbService, store, pending.Key)
+}
+
+// checkRawKeySwept uploads to the raw key again, the way a client still holding the presigned URL
+// could, and checks that the key stayed tracked so the sweeper removes the new object
+func checkRawKeySwept(t *testing.T, dbService *database.Service, store directStore, key string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var pending, charged bool
+	if err := dbService.DB.QueryRow(`SELECT pending, charged FROM uploads WHERE key = $1`, key).Scan(&pending, &charged); err != nil {
+		t.Fatalf("raw key is no longer tracked: %v", err)
+	}
+	if !pending || charged {
+		t.Errorf("raw key pending, charged = %v, %v, want true, false", pending, charged)
+	}
+
+	if err := store.Put(ctx, key, strings.NewReader("again"), 5, "image/jpeg"); err != nil {
+		t.Fatalf("upload again: %v", err)
+	}
+	if _, err := dbService.DB.Exec(`UPDATE uploads SET created_at = $1 WHERE key = $2`, time.Now().Add(-48*time.Hour), key); err != nil {
+		t.Fatalf("age raw key: %v", err)
+	}
+
+	handler := NewUploadHandler(dbService, store, AttachmentConfig{}, StorageQuotaConfig{MaxBytes: 1 << 20})
+	var result uploadSweepResult
+	if _, _, err := handler.sweepUploadBatch(ctx, time.Now().UTC().Add(-24*time.Hour), uuid.Nil, false, &result); err != nil {
+		t.Fatalf("sweepUploadBatch() error = %v", err)
+	}
+
+	if _, err := store.Open(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("object uploaded again to the raw key was not swept, Open error = %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+)
+
+// Maximum uploads examined per sweeper transaction
+const uploadSweepBatchSize = 100
+
+// Counters for the orphaned upload sweeper, published under "upload_sweeper" in /debug/vars
+var uploadSweepMetrics = expvar.NewMap("upload_sweeper")
+
+// UploadSweepConfig controls how often orphaned uploads are removed and how old they must be
+type UploadSweepConfig struct {
+	Interval    time.Duration
+	GracePeriod time.Duration
+	// DryRun logs the uploads that would be removed without deleting anything
+	DryRun bool
+}
+
+// UploadSweepConfigFromEnv reads UPLOAD_SWEEP_INTERVAL_MINUTES (default: 60),
+// UPLOAD_SWEEP_GRACE_HOURS (default: 24) and UPLOAD_SWEEP_DRY_RUN
+func UploadSweepConfigFromEnv() UploadSweepConfig {
+	cfg := UploadSweepConfig{
+		Interval:    time.Hour,
+		GracePeriod: 24 * time.Hour,
+		DryRun:      os.Getenv("UPLOAD_SWEEP_DRY_RUN") == "true",
+	}
+
+	if intervalEnv := os.Getenv("UPLOAD_SWEEP_INTERVAL_MINUTES"); intervalEnv != "" {
+		if minutes, err := strconv.Atoi(intervalEnv); err == nil && minutes > 0 {
+			cfg.Interval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if graceEnv := os.Getenv("UPLOAD_SWEEP_GRACE_HOURS"); graceEnv != "" {
+		if hours, err := strconv.Atoi(graceEnv); err == nil && hours > 0 {
+			cfg.GracePeriod = time.Duration(hours) * time.Hour
+		}
+	}
+
+	return cfg
+}
+
+type uploadSweepResult struct {
+	found          int
+	deleted        int
+	failed         int
+	bytesReclaimed int64
+}
+
+// RunOrphanedUploadSweeper periodically deletes uploads that nothing references any more: files
+// that were uploaded but never sent, replaced avatars and objects left behind by failed deletions.
+// Uploads still held by a draft or a pending scheduled message are kept.
+func (h *UploadHandler) RunOrphanedUploadSweeper(ctx context.Context, cfg UploadSweepConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sweepOrphanedUploads(ctx, cfg)
+		}
+	}
+}
+
+// sweepOrphanedUploads makes one pass over all uploads older than the grace period
+func (h *UploadHandler) sweepOrphanedUploads(ctx context.Context, cfg UploadSweepConfig) {
+	started := time.Now()
+	cutoff := started.UTC().Add(-cfg.GracePeriod)
+
+	var result uploadSweepResult
+	afterID := uuid.Nil
+	for ctx.Err() == nil {
+		examined, lastID, err := h.sweepUploadBatch(ctx, cutoff, afterID, cfg.DryRun, &result)
+		if err != nil {
+			uploadSweepMetrics.Add("failed_runs", 1)
+			log.Printf("Failed to sweep orphaned uploads: %v", err)
+			break
+		}
+		if examined < uploadSweepBatchSize {
+			break
+		}
+		afterID = lastID
+	}
+
+	uploadSweepMetrics.Add("runs", 1)
+	uploadSweepMetrics.Add("orphans_found", int64(result.found))
+	uploadSweepMetrics.Add("objects_deleted", int64(result.deleted))
+	uploadSweepMetrics.Add("delete_errors", int64(result.failed))
+	uploadSweepMetrics.Add("bytes_reclaimed", result.bytesReclaimed)
+
+	lastRun := new(expvar.Int)
+	lastRun.Set(started.Unix())
+	uploadSweepMetrics.Set("last_run_unix", lastRun)
+
+	if result.found > 0 {
+		log.Printf("Upload sweep (dry run: %t): %d orphaned, %d deleted, %d failed, %d bytes reclaimed in %s",
+			cfg.DryRun, result.found, result.deleted, result.failed, result.bytesReclaimed, time.Since(started).Round(time.Millisecond))
+	}
+}
+
+// sweepUploadBatch removes one batch of orphaned uploads after afterID, returning how many were examined
+// and the last ID seen. The rows stay locked while their objects are deleted, so an upload being swept
+// cannot be claimed by a message at the same time.
+func (h *UploadHandler) sweepUploadBatch(ctx context.Context, cutoff time.Time, afterID uuid.UUID, dryRun bool, result *uploadSweepResult) (int, uuid.UUID, error) {
+	tx, err := h.dbService.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, afterID, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	orphans, err := qtx.GetOrphanedUploads(ctx, database.GetOrphanedUploadsParams{
+		CreatedBefore: cutoff,
+		AfterID:       afterID,
+		BatchSize:     uploadSweepBatchSize,
+	})
+	if err != nil {
+		return 0, afterID, err
+	}
+
+	if len(orphans) == 0 {
+		return 0, afterID, nil
+	}
+
+	result.found += len(orphans)
+
+	deletedIDs := make([]uuid.UUID, 0, len(orphans))
+	for _, upload := range orphans {
+		variants := decodeStoredVariants(upload.Variants)
+
+		size := upload.Size
+		for _, variant := range variants {
+			size += variant.Size
+		}
+
+		if dryRun {
+			log.Printf("Upload sweep dry run: would delete %s and %d variants (%d bytes)", upload.Key, len(variants), size)
+			continue
+		}
+
+		// Variants go first so a failure never leaves them behind without the row that lists them
+		if err := h.deleteUploadObjects(ctx, upload.Key, variants); err != nil {
+			result.failed++
+			log.Printf("Failed to delete orphaned upload %s: %v", upload.Key, err)
+			continue
+		}
+
+		deletedIDs = append(deletedIDs, upload.ID)
+		result.deleted++
+		result.bytesReclaimed += size
+	}
+
+	if len(deletedIDs) > 0 {
+		if err := qtx.DeleteUploads(ctx, deletedIDs); err != nil {
+			return 0, afterID, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, afterID, err
+	}
+
+	return len(orphans), orphans[len(orphans)-1].ID, nil
+}
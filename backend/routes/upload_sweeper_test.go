@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+	"github.com/anmol7470/bubbles/backend/storage"
+)
+
+func TestUploadSweepConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval string
+		grace    string
+		dryRun   string
+		want     UploadSweepConfig
+	}{
+		{name: "defaults", want: UploadSweepConfig{Interval: time.Hour, GracePeriod: 24 * time.Hour}},
+		{
+			name:     "configured",
+			interval: "5",
+			grace:    "2",
+			dryRun:   "true",
+			want:     UploadSweepConfig{Interval: 5 * time.Minute, GracePeriod: 2 * time.Hour, DryRun: true},
+		},
+		{
+			name:     "invalid values keep the defaults",
+			interval: "0",
+			grace:    "soon",
+			dryRun:   "yes",
+			want:     UploadSweepConfig{Interval: time.Hour, GracePeriod: 24 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("UPLOAD_SWEEP_INTERVAL_MINUTES", tt.interval)
+			t.Setenv("UPLOAD_SWEEP_GRACE_HOURS", tt.grace)
+			t.Setenv("UPLOAD_SWEEP_DRY_RUN", tt.dryRun)
+
+			if got := UploadSweepConfigFromEnv(); got != tt.want {
+				t.Errorf("UploadSweepConfigFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweepUploadBatch(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	_, uploadHandler := newTestHandlers(t, dbService)
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+
+	// createImage stores an image with one variant, created age ago
+	createImage := func(age time.Duration) database.Upload {
+		t.Helper()
+
+		key := fmt.Sprintf("%s/1/%s.jpg", alice, uuid.NewString())
+		variantKey := strings.TrimSuffix(key, ".jpg") + "_small.jpg"
+		for _, k := range []string{key, variantKey} {
+			if err := uploadHandler.store.Put(ctx, k, strings.NewReader("image"), 5, "image/jpeg"); err != nil {
+				t.Fatalf("store %s: %v", k, err)
+			}
+		}
+
+		variants, err := json.Marshal([]storedImageVariant{{Name: "small", Key: variantKey, URL: uploadHandler.store.URL(variantKey), Size: 5}})
+		if err != nil {
+			t.Fatalf("encode variants: %v", err)
+		}
+		upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+			OwnerID:     alice,
+			Key:         key,
+			Url:         uploadHandler.store.URL(key),
+			Size:        5,
+			ContentType: "image/jpeg",
+			Variants:    variants,
+		})
+		if err != nil {
+			t.Fatalf("create upload: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE uploads SET created_at = $1 WHERE id = $2`, time.Now().UTC().Add(-age), upload.ID); err != nil {
+			t.Fatalf("age upload: %v", err)
+		}
+		return upload
+	}
+
+	orphan := createImage(48 * time.Hour)
+	recent := createImage(time.Hour)
+	sent := createImage(48 * time.Hour)
+	messageID := createTestMessage(t, db, chatID, alice, "", time.Now(), time.Time{})
+	if _, err := queries.AddMessageImage(ctx, database.AddMessageImageParams{MessageID: messageID, Url: sent.Url}); err != nil {
+		t.Fatalf("add image: %v", err)
+	}
+
+	stored := func(upload database.Upload) bool {
+		t.Helper()
+
+		_, err := queries.GetUploadByUrl(ctx, upload.Url)
+		if err != nil && err != sql.ErrNoRows {
+			t.Fatalf("get upload: %v", err)
+		}
+		hasRow := err == nil
+
+		for _, key := range storedImageKeys(upload.Key, decodeStoredVariants(upload.Variants)) {
+			object, err := uploadHandler.store.Open(ctx, key)
+			if errors.Is(err, storage.ErrNotFound) {
+				if hasRow {
+					t.Errorf("%s deleted while its upload is still recorded", key)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("open %s: %v", key, err)
+			}
+			object.Body.Close()
+			if !hasRow {
+				t.Errorf("%s still stored after its upload was removed", key)
+			}
+		}
+		return hasRow
+	}
+
+	cutoff := time.Now().UTC().Add(-24 * time.Hour)
+	sweep := func(dryRun bool) uploadSweepResult {
+		t.Helper()
+
+		var result uploadSweepResult
+		examined, lastID, err := uploadHandler.sweepUploadBatch(ctx, cutoff, uuid.Nil, dryRun, &result)
+		if err != nil {
+			t.Fatalf("sweepUploadBatch() error = %v", err)
+		}
+		if examined != result.found {
+			t.Errorf("sweepUploadBatch() examined %d, found %d", examined, result.found)
+		}
+		if examined > 0 && lastID == uuid.Nil {
+			t.Error("sweepUploadBatch() returned no last ID")
+		}
+		return result
+	}
+
+	result := sweep(true)
+	if result.found != 1 || result.deleted != 0 || result.bytesReclaimed != 0 {
+		t.Errorf("dry run result = %+v, want one orphan found and nothing deleted", result)
+	}
+	if !stored(orphan) {
+		t.Error("dry run deleted the orphaned upload")
+	}
+
+	used := storageUsed(t, queries, alice)
+	result = sweep(false)
+	if result.found != 1 || result.deleted != 1 || result.failed != 0 || result.bytesReclaimed != 10 {
+		t.Errorf("sweep result = %+v, want the orphan and its variant deleted", result)
+	}
+	if stored(orphan) {
+		t.Error("orphaned upload was not swept")
+	}
+	if !stored(recent) {
+		t.Error("upload inside the grace period was swept")
+	}
+	if !stored(sent) {
+		t.Error("upload sent in a message was swept")
+	}
+	if got := storageUsed(t, queries, alice); got != used-10 {
+		t.Errorf("storage used = %d, want %d", got, used-10)
+	}
+}
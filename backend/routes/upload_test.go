@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/database/dbtest"
+)
+
+func TestClaimUploads(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	upload := func(owner uuid.UUID, name string) string {
+		t.Helper()
+
+		created, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+			OwnerID:     owner,
+			Key:         owner.String() + "/1/" + name,
+			Url:         "http://localhost/files/" + owner.String() + "/1/" + name,
+			Size:        1000,
+			ContentType: "image/jpeg",
+			Variants:    json.RawMessage("[]"),
+		})
+		if err != nil {
+			t.Fatalf("create upload: %v", err)
+		}
+		return created.Url
+	}
+
+	first := upload(alice, "first.jpg")
+	second := upload(alice, "second.jpg")
+	others := upload(bob, "other.jpg")
+
+	tests := []struct {
+		name    string
+		urls    []string
+		wantErr bool
+	}{
+		{name: "no images", urls: nil},
+		{name: "someone else's upload", urls: []string{first, others}, wantErr: true},
+		{name: "not an upload", urls: []string{"https://example.com/cat.jpg"}, wantErr: true},
+		{name: "same upload twice", urls: []string{first, first}, wantErr: true},
+		{name: "own uploads", urls: []string{first, second}},
+		{name: "already used", urls: []string{first}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, err := uploadsAvailable(ctx, queries, alice, tt.urls)
+			if err != nil {
+				t.Fatalf("uploadsAvailable() error = %v", err)
+			}
+			if available == tt.wantErr {
+				t.Errorf("uploadsAvailable() = %t, want %t", available, !tt.wantErr)
+			}
+
+			// Claiming runs inside the caller's transaction, so a failed claim is rolled back
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("begin transaction: %v", err)
+			}
+			err = claimUploads(ctx, queries.WithTx(tx), alice, tt.urls)
+			if tt.wantErr {
+				if !errors.Is(err, errUnavailableImages) {
+					t.Errorf("claimUploads() error = %v, want %v", err, errUnavailableImages)
+				}
+				if err := tx.Rollback(); err != nil {
+					t.Fatalf("roll back: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("claimUploads() error = %v", err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("commit: %v", err)
+			}
+		})
+	}
+}
+
+func TestEditedOutImagesAreKeptForRevisions(t *testing.T) {
+	dbService := dbtest.Open(t)
+	db := dbService.DB
+	queries := dbService.Queries
+	ctx := context.Background()
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	chatID := createTestChat(t, db, alice, bob)
+	messageID := createTestMessage(t, db, chatID, alice, "look", time.Now(), time.Time{})
+
+	upload, err := queries.CreateUpload(ctx, database.CreateUploadParams{
+		OwnerID:     alice,
+		Key:         alice.String() + "/1/photo.jpg",
+		Url:         "http://localhost/files/" + alice.String() + "/1/photo.jpg",
+		Size:        1000,
+		ContentType: "image/jpeg",
+		Variants:    json.RawMessage("[]"),
+	})
+	if err != nil {
+		t.Fatalf("create upload: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE uploads SET created_at = $1 WHERE id = $2`, time.Now().Add(-48*time.Hour), upload.ID); err != nil {
+		t.Fatalf("age upload: %v", err)
+	}
+	if _, err := queries.AddMessageImage(ctx, database.AddMessageImageParams{MessageID: messageID, Url: upload.Url}); err != nil {
+		t.Fatalf("add image: %v", err)
+	}
+
+	// Edit the image out of the message the way EditMessage does
+	if err := queries.CreateMessageRevision(ctx, messageID); err != nil {
+		t.Fatalf("create revision: %v", err)
+	}
+	if err := queries.DeleteMessageImages(ctx, database.DeleteMessageImagesParams{MessageID: messageID, Column2: []string{upload.Url}}); err != nil {
+		t.Fatalf("remove image: %v", err)
+	}
+
+	isOrphaned := func() bool {
+		t.Helper()
+		orphans, err := queries.GetOrphanedUploads(ctx, database.GetOrphanedUploadsParams{
+			CreatedBefore: time.Now().UTC().Add(-24 * time.Hour),
+			AfterID:       uuid.Nil,
+			BatchSize:     10,
+		})
+		if err != nil {
+			t.Fatalf("get orphaned uploads: %v", err)
+		}
+		for _, orphan := range orphans {
+			if orphan.ID == upload.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	unreferenced, err := unreferencedImageURLs(ctx, queries, []string{upload.Url})
+	if err != nil {
+		t.Fatalf("unreferencedImageURLs() error = %v", err)
+	}
+	if len(unreferenced) != 0 {
+		t.Errorf("unreferencedImageURLs() = %v, want the revision's image kept", unreferenced)
+	}
+	if isOrphaned() {
+		t.Error("sweeper would delete an image kept in a revision")
+	}
+
+	if err := queries.DeleteMessage(ctx, messageID); err != nil {
+		t.Fatalf("delete message: %v", err)
+	}
+
+	unreferenced, err = unreferencedImageURLs(ctx, queries, []string{upload.Url})
+	if err != nil {
+		t.Fatalf("unreferencedImageURLs() error = %v", err)
+	}
+	if len(unreferenced) != 1 {
+		t.Errorf("unreferencedImageURLs() = %v, want the image released with its message", unreferenced)
+	}
+	if !isOrphaned() {
+		t.Error("sweeper would keep an image whose message was deleted")
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -11,16 +12,18 @@ import (
 
 	"github.com/anmol7470/bubbles/backend/database"
 	"github.com/anmol7470/bubbles/backend/models"
-	"github.com/anmol7470/bubbles/backend/utils"
+	"github.com/anmol7470/bubbles/backend/storage"
 )
 
 type UserHandler struct {
 	dbService *database.Service
+	store     storage.Storage
 }
 
-func NewUserHandler(dbService *database.Service) *UserHandler {
+func NewUserHandler(dbService *database.Service, store storage.Storage) *UserHandler {
 	return &UserHandler{
 		dbService: dbService,
+		store:     store,
 	}
 }
 
@@ -50,11 +53,50 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		if trimmed == "" {
 			profileImage = sql.NullString{Valid: false}
 		} else {
-			profileImage = sql.NullString{String: trimmed, Valid: true}
+			// Clients of a private store send back the signed URL they were given
+			profileImage = sql.NullString{String: storedImageURL(h.store, trimmed), Valid: true}
 		}
 	}
 
-	user, err := h.dbService.Queries.UpdateUserProfile(c.Request.Context(), database.UpdateUserProfileParams{
+	tx, err := h.dbService.DB.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to begin transaction",
+		})
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.dbService.Queries.WithTx(tx)
+
+	if profileImage.Valid {
+		current, err := qtx.GetUserByID(c.Request.Context(), userID.(uuid.UUID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to load user",
+			})
+			return
+		}
+
+		// Re-saving the current picture must not require a fresh upload
+		if !current.ProfileImageUrl.Valid || current.ProfileImageUrl.String != profileImage.String {
+			err := claimUploads(c.Request.Context(), qtx, userID.(uuid.UUID), []string{profileImage.String})
+			if errors.Is(err, errUnavailableImages) {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error: "Profile image must be an unused image you uploaded",
+				})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error: "Failed to verify profile image",
+				})
+				return
+			}
+		}
+	}
+
+	user, err := qtx.UpdateUserProfile(c.Request.Context(), database.UpdateUserProfileParams{
 		ID:                 userID.(uuid.UUID),
 		Username:           req.Username,
 		UpdateProfileImage: updateImage,
@@ -75,12 +117,19 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to commit transaction",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.UpdateUserProfileResponse{
 		User: models.UserInfo{
 			ID:              user.ID,
 			Username:        user.Username,
 			Email:           user.Email,
-			ProfileImageURL: utils.NullableString(user.ProfileImageUrl),
+			ProfileImageURL: signProfileImageURL(c.Request.Context(), h.store, user.ProfileImageUrl),
 			CreatedAt:       user.CreatedAt,
 		},
 	})
@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/anmol7470/bubbles/backend/audio"
+	"github.com/anmol7470/bubbles/backend/constants"
+	"github.com/anmol7470/bubbles/backend/database"
+	"github.com/anmol7470/bubbles/backend/models"
+	"github.com/anmol7470/bubbles/backend/utils"
+)
+
+// UploadVoice stores a recorded voice message. The recording is checked to be a well-formed
+// Ogg Opus or M4A (AAC) file and its duration and waveform are read from the container, so
+// clients cannot misreport either. It is then sent as an attachment by its upload ID.
+func (h *UploadHandler) UploadVoice(c *gin.Context) {
+	userID, ok := utils.GetUserIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	maxSizeMessage := fmt.Sprintf("Recording too large. Maximum size is %dMB", constants.MaxVoiceMessageSize/(1024*1024))
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MaxVoiceMessageSize+1024*1024)
+
+	file, header, err := c.Request.FormFile("voice")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: maxSizeMessage,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "No recording provided",
+		})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > constants.MaxVoiceMessageSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: maxSizeMessage,
+		})
+		return
+	}
+
+	// The whole container has to be parsed to find the duration, so the recording is read into memory
+	data, err := io.ReadAll(io.LimitReader(file, constants.MaxVoiceMessageSize+1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to read recording",
+		})
+		return
+	}
+	if len(data) > constants.MaxVoiceMessageSize {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: maxSizeMessage,
+		})
+		return
+	}
+
+	info, err := audio.Probe(data)
+	if err != nil {
+		if errors.Is(err, audio.ErrUnsupportedFormat) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Unsupported recording format. Allowed formats: Ogg Opus, M4A (AAC)",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Recording is damaged or incomplete",
+		})
+		return
+	}
+
+	if info.Duration > constants.MaxVoiceMessageSeconds*time.Second {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: fmt.Sprintf("Recording too long. Maximum length is %d minutes", constants.MaxVoiceMessageSeconds/60),
+		})
+		return
+	}
+
+	waveform, err := json.Marshal(info.Waveform)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process recording",
+		})
+		return
+	}
+
+	size := int64(len(data))
+	if !h.checkStorageQuota(c, userID, size) {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d/%s%s", userID, time.Now().Unix(), uuid.New().String(), info.Ext)
+
+	if err := h.store.Put(c.Request.Context(), key, bytes.NewReader(data), size, info.ContentType); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to upload recording",
+		})
+		return
+	}
+
+	durationMs := int32(info.Duration.Milliseconds())
+	var upload database.Upload
+	err = h.recordWithinQuota(c.Request.Context(), userID, size, func(qtx *database.Queries) error {
+		var err error
+		upload, err = qtx.CreateVoiceUpload(c.Request.Context(), database.CreateVoiceUploadParams{
+			OwnerID:     userID,
+			Key:         key,
+			Url:         h.store.URL(key),
+			Size:        size,
+			ContentType: info.ContentType,
+			FileName:    sql.NullString{String: "voice-message" + info.Ext, Valid: true},
+			DurationMs:  sql.NullInt32{Int32: durationMs, Valid: true},
+			Waveform:    waveform,
+		})
+		return err
+	})
+	if err != nil {
+		h.deleteKeys([]string{key})
+		if errors.Is(err, errStorageQuotaExceeded) {
+			h.respondStorageQuotaExceeded(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to record upload",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UploadVoiceResponse{
+		UploadID:    upload.ID,
+		ContentType: info.ContentType,
+		Size:        size,
+		DurationMs:  durationMs,
+		Waveform:    info.Waveform,
+	})
+}
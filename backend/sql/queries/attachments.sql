@@ -0,0 +1,39 @@
+-- name: AddMessageAttachments :many
+INSERT INTO attachments (message_id, key, file_name, content_type, size, kind, duration_ms, waveform)
+SELECT sqlc.arg(message_id)::uuid, u.key, COALESCE(u.file_name, 'file'), u.content_type, u.size, u.kind, u.duration_ms, u.waveform
+FROM uploads u
+WHERE u.id = ANY(sqlc.arg(upload_ids)::uuid[])
+RETURNING *;
+
+-- name: CopyMessageAttachments :many
+INSERT INTO attachments (message_id, key, file_name, content_type, size, kind, duration_ms, waveform)
+SELECT sqlc.arg(target_message_id)::uuid, key, file_name, content_type, size, kind, duration_ms, waveform
+FROM attachments
+WHERE message_id = sqlc.arg(source_message_id)::uuid
+RETURNING *;
+
+-- name: GetMessageAttachments :many
+SELECT *
+FROM attachments
+WHERE message_id = ANY($1::uuid[])
+ORDER BY created_at, id;
+
+-- name: GetChatAttachments :many
+SELECT a.*
+FROM attachments a
+INNER JOIN messages m ON a.message_id = m.id
+WHERE m.chat_id = $1;
+
+-- name: GetAttachmentForMember :one
+SELECT a.*
+FROM attachments a
+INNER JOIN messages m ON a.message_id = m.id
+INNER JOIN chat_members cm ON cm.chat_id = m.chat_id
+WHERE a.id = sqlc.arg(id) AND cm.user_id = sqlc.arg(user_id) AND m.is_deleted = false;
+
+-- name: GetAttachmentKeysInUse :many
+SELECT DISTINCT a.key
+FROM attachments a
+INNER JOIN messages m ON a.message_id = m.id
+WHERE a.key = ANY(sqlc.arg(keys)::text[])
+  AND m.is_deleted = false;
@@ -4,3 +4,41 @@ SELECT EXISTS(
     FROM chat_members
     WHERE chat_id = $1 AND user_id = $2
 ) AS is_member;
+
+-- name: CanAccessImage :one
+SELECT EXISTS(
+    SELECT 1
+    FROM images i
+    JOIN messages m ON m.id = i.message_id
+    JOIN chat_members cm ON cm.chat_id = m.chat_id
+    WHERE cm.user_id = sqlc.arg(user_id)
+      AND NOT m.is_deleted
+      AND (i.url = sqlc.arg(url)::text OR i.variants @> jsonb_build_array(jsonb_build_object('url', sqlc.arg(url)::text)))
+) OR EXISTS(
+    SELECT 1
+    FROM uploads u
+    WHERE u.owner_id = sqlc.arg(user_id)
+      AND (u.url = sqlc.arg(url)::text OR u.variants @> jsonb_build_array(jsonb_build_object('url', sqlc.arg(url)::text)))
+) OR EXISTS(
+    SELECT 1
+    FROM users us
+    JOIN chat_members them ON them.user_id = us.id
+    JOIN chat_members me ON me.chat_id = them.chat_id
+    WHERE me.user_id = sqlc.arg(user_id)
+      AND us.profile_image_url = sqlc.arg(url)::text
+) AS can_access;
+
+-- name: IsMessageVisible :one
+SELECT EXISTS(
+    SELECT 1
+    FROM messages m
+    JOIN chat_members cm ON cm.chat_id = m.chat_id AND cm.user_id = sqlc.arg(user_id)::uuid
+    WHERE m.id = sqlc.arg(message_id)::uuid
+      AND (cm.cleared_at IS NULL OR m.created_at > cm.cleared_at)
+      AND NOT EXISTS (
+        SELECT 1
+        FROM hidden_messages hm
+        WHERE hm.message_id = m.id AND hm.user_id = sqlc.arg(user_id)::uuid
+      )
+      AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+) AS is_visible;
@@ -0,0 +1,22 @@
+-- name: CreateChatSettings :exec
+INSERT INTO chat_settings (chat_id)
+VALUES ($1)
+ON CONFLICT (chat_id) DO NOTHING;
+
+-- name: GetChatSettings :one
+SELECT chat_id, edit_window_seconds, allow_deletes, max_images, max_message_length, who_can_post, updated_at, message_ttl_seconds
+FROM chat_settings
+WHERE chat_id = $1;
+
+-- name: UpdateChatSettings :one
+UPDATE chat_settings
+SET
+    edit_window_seconds = $2,
+    allow_deletes = $3,
+    max_images = $4,
+    max_message_length = $5,
+    who_can_post = $6,
+    message_ttl_seconds = $7,
+    updated_at = CURRENT_TIMESTAMP
+WHERE chat_id = $1
+RETURNING chat_id, edit_window_seconds, allow_deletes, max_images, max_message_length, who_can_post, updated_at, message_ttl_seconds;
@@ -61,28 +61,52 @@ WITH user_chats AS (
         lm.content as msg_content,
         lm.sender_id as msg_sender_id,
         lm.is_deleted as msg_is_deleted,
+        lm.message_type as msg_message_type,
+        lm.metadata as msg_metadata,
         lm.created_at as msg_created_at,
         sender.username as msg_sender_username,
         sender.profile_image_url as msg_sender_profile_image_url,
         COALESCE(unread_counts.unread_count, 0) as unread_count,
+        COALESCE(unread_counts.unread_mention_count, 0) as unread_mention_count,
         ROW_NUMBER() OVER (PARTITION BY c.id ORDER BY COALESCE(lm.created_at, c.created_at) DESC) as rn
     FROM chats c
     INNER JOIN chat_members cm_user ON c.id = cm_user.chat_id AND cm_user.user_id = $1
     LEFT JOIN LATERAL (
-        SELECT id, chat_id, sender_id, content, is_deleted, created_at
+        SELECT id, chat_id, sender_id, content, is_deleted, message_type, metadata, created_at
         FROM messages
         WHERE chat_id = c.id
           AND (cm_user.cleared_at IS NULL OR created_at > cm_user.cleared_at)
+          AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+          AND NOT EXISTS (
+            SELECT 1
+            FROM hidden_messages hm
+            WHERE hm.message_id = messages.id AND hm.user_id = $1
+          )
         ORDER BY created_at DESC
         LIMIT 1
     ) lm ON true
     LEFT JOIN users sender ON lm.sender_id = sender.id
     LEFT JOIN chat_read_receipts cr ON cr.chat_id = c.id AND cr.user_id = $1
     LEFT JOIN LATERAL (
-        SELECT COUNT(*) as unread_count
+        SELECT
+            COUNT(*) as unread_count,
+            COUNT(*) FILTER (
+                WHERE NOT m.is_deleted
+                  AND EXISTS (
+                    SELECT 1
+                    FROM message_mentions mm
+                    WHERE mm.message_id = m.id AND mm.user_id = $1
+                  )
+            ) as unread_mention_count
         FROM messages m
         WHERE m.chat_id = c.id
           AND m.sender_id <> $1
+          AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+          AND NOT EXISTS (
+            SELECT 1
+            FROM hidden_messages hm
+            WHERE hm.message_id = m.id AND hm.user_id = $1
+          )
           AND (
             cr.last_read_at IS NULL
             OR m.created_at > cr.last_read_at
@@ -108,7 +132,10 @@ SELECT
     COALESCE(uc.msg_created_at, uc.created_at) as last_message_created_at,
     uc.msg_sender_username as last_message_sender_username,
     uc.msg_sender_profile_image_url as last_message_sender_profile_image_url,
-    uc.unread_count
+    COALESCE(uc.msg_message_type, 'user') as last_message_type,
+    COALESCE(uc.msg_metadata, '{}'::jsonb) as last_message_metadata,
+    uc.unread_count,
+    uc.unread_mention_count
 FROM user_chats uc
 INNER JOIN chat_members cm ON uc.chat_id = cm.chat_id
 INNER JOIN users u ON cm.user_id = u.id
@@ -117,7 +144,7 @@ WHERE uc.rn = 1
 ORDER BY COALESCE(uc.msg_created_at, uc.created_at) DESC, u.username ASC;
 
 -- name: GetLastMessageImages :many
-SELECT message_id, url
+SELECT id, message_id, url, created_at, width, height, variants, blurhash
 FROM images
 WHERE message_id = ANY($1::uuid[]);
 
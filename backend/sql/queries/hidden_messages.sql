@@ -0,0 +1,9 @@
+-- name: HideMessage :exec
+INSERT INTO hidden_messages (user_id, message_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, message_id) DO NOTHING;
+
+-- name: HideMessages :exec
+INSERT INTO hidden_messages (user_id, message_id)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(message_ids)::uuid[])
+ON CONFLICT (user_id, message_id) DO NOTHING;
@@ -0,0 +1,27 @@
+-- name: GetLinkPreview :one
+SELECT * FROM link_previews WHERE url = $1 LIMIT 1;
+
+-- name: UpsertLinkPreview :one
+INSERT INTO link_previews (url, title, description, image_url, site_name, fetch_failed)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (url) DO UPDATE
+SET
+    title = excluded.title,
+    description = excluded.description,
+    image_url = excluded.image_url,
+    site_name = excluded.site_name,
+    fetch_failed = excluded.fetch_failed,
+    fetched_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: AddMessageLinkPreview :exec
+INSERT INTO message_link_previews (message_id, url, position)
+VALUES ($1, $2, $3)
+ON CONFLICT (message_id, url) DO NOTHING;
+
+-- name: GetMessageLinkPreviews :many
+SELECT mlp.message_id, lp.url, lp.title, lp.description, lp.image_url, lp.site_name
+FROM message_link_previews mlp
+INNER JOIN link_previews lp ON mlp.url = lp.url
+WHERE mlp.message_id = ANY($1::uuid[]) AND lp.fetch_failed = false
+ORDER BY mlp.message_id, mlp.position ASC;
@@ -0,0 +1,20 @@
+-- name: GetChatMembersByUsernames :many
+SELECT u.id, u.username
+FROM chat_members cm
+INNER JOIN users u ON cm.user_id = u.id
+WHERE cm.chat_id = sqlc.arg(chat_id) AND u.username = ANY(sqlc.arg(usernames)::text[]);
+
+-- name: AddMessageMentions :exec
+INSERT INTO message_mentions (message_id, user_id, position, length)
+SELECT sqlc.arg(message_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[]), unnest(sqlc.arg(positions)::int[]), unnest(sqlc.arg(lengths)::int[]);
+
+-- name: DeleteMessageMentions :exec
+DELETE FROM message_mentions
+WHERE message_id = $1;
+
+-- name: GetMessageMentions :many
+SELECT mm.message_id, mm.user_id, u.username, mm.position, mm.length
+FROM message_mentions mm
+INNER JOIN users u ON mm.user_id = u.id
+WHERE mm.message_id = ANY($1::uuid[])
+ORDER BY mm.message_id, mm.position ASC;
@@ -0,0 +1,22 @@
+-- name: UpsertMessageDraft :one
+INSERT INTO message_drafts (user_id, chat_id, content, images, reply_to_message_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, chat_id) DO UPDATE
+SET
+    content = excluded.content,
+    images = excluded.images,
+    reply_to_message_id = excluded.reply_to_message_id,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetMessageDraft :one
+SELECT * FROM message_drafts
+WHERE user_id = $1 AND chat_id = $2;
+
+-- name: GetMessageDraftsByUser :many
+SELECT * FROM message_drafts
+WHERE user_id = $1;
+
+-- name: DeleteMessageDraft :exec
+DELETE FROM message_drafts
+WHERE user_id = $1 AND chat_id = $2;
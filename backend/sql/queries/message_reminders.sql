@@ -0,0 +1,70 @@
+-- name: CreateMessageReminder :one
+INSERT INTO message_reminders (user_id, message_id, chat_id, remind_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetMessageRemindersByUser :many
+SELECT
+    r.id,
+    r.message_id,
+    r.chat_id,
+    r.remind_at,
+    r.created_at,
+    c.name AS chat_name,
+    c.is_group AS chat_is_group,
+    m.content,
+    u.username AS sender_username
+FROM message_reminders r
+INNER JOIN messages m ON r.message_id = m.id
+INNER JOIN chats c ON r.chat_id = c.id
+INNER JOIN users u ON m.sender_id = u.id
+WHERE r.user_id = sqlc.arg(user_id)::uuid
+  AND (sqlc.narg(chat_id)::uuid IS NULL OR r.chat_id = sqlc.narg(chat_id)::uuid)
+ORDER BY r.remind_at ASC;
+
+-- name: CancelMessageReminder :execrows
+DELETE FROM message_reminders
+WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDueMessageReminders :many
+SELECT
+    r.id,
+    r.user_id,
+    r.message_id,
+    r.chat_id,
+    r.remind_at,
+    c.name AS chat_name,
+    c.is_group AS chat_is_group,
+    m.content,
+    u.username AS sender_username,
+    (
+        cm.user_id IS NOT NULL
+        AND NOT m.is_deleted
+        AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+        AND NOT EXISTS (
+            SELECT 1
+            FROM hidden_messages hm
+            WHERE hm.message_id = r.message_id AND hm.user_id = r.user_id
+        )
+    )::boolean AS is_deliverable
+FROM message_reminders r
+INNER JOIN messages m ON r.message_id = m.id
+INNER JOIN chats c ON r.chat_id = c.id
+INNER JOIN users u ON m.sender_id = u.id
+LEFT JOIN chat_members cm ON cm.chat_id = r.chat_id AND cm.user_id = r.user_id
+WHERE r.remind_at <= CURRENT_TIMESTAMP
+ORDER BY r.remind_at ASC
+LIMIT $1
+FOR UPDATE OF r SKIP LOCKED;
+
+-- name: DeleteMessageReminders :exec
+DELETE FROM message_reminders
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: DeleteMessageRemindersByMessages :exec
+DELETE FROM message_reminders
+WHERE message_id = ANY(sqlc.arg(message_ids)::uuid[]);
+
+-- name: DeleteMessageRemindersInChat :exec
+DELETE FROM message_reminders
+WHERE user_id = $1 AND chat_id = $2;
@@ -0,0 +1,19 @@
+-- name: CreateMessageRevision :exec
+INSERT INTO message_revisions (message_id, content, images)
+SELECT
+    m.id,
+    m.content,
+    ARRAY(
+        SELECT i.url
+        FROM images i
+        WHERE i.message_id = m.id
+        ORDER BY i.created_at ASC
+    )
+FROM messages m
+WHERE m.id = sqlc.arg(message_id);
+
+-- name: GetMessageRevisions :many
+SELECT id, message_id, content, images, edited_at
+FROM message_revisions
+WHERE message_id = $1
+ORDER BY edited_at DESC;
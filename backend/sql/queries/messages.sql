@@ -1,11 +1,33 @@
 -- name: CreateMessage :one
-INSERT INTO messages (chat_id, sender_id, content, reply_to_message_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO messages (chat_id, sender_id, content, reply_to_message_id, entities, expires_at)
+VALUES (
+    $1, $2, $3, $4, $5,
+    (
+        SELECT CURRENT_TIMESTAMP + make_interval(secs => cs.message_ttl_seconds)
+        FROM chat_settings cs
+        WHERE cs.chat_id = $1 AND cs.message_ttl_seconds > 0
+    )
+)
 RETURNING *;
 
--- name: AddMessageImage :exec
-INSERT INTO images (message_id, url)
-VALUES ($1, $2);
+-- name: CreateForwardedMessage :one
+INSERT INTO messages (chat_id, sender_id, content, forwarded_from_message_id, forwarded_from_sender_id, entities, expires_at)
+VALUES (
+    $1, $2, $3, $4, $5, $6,
+    (
+        SELECT CURRENT_TIMESTAMP + make_interval(secs => cs.message_ttl_seconds)
+        FROM chat_settings cs
+        WHERE cs.chat_id = $1 AND cs.message_ttl_seconds > 0
+    )
+)
+RETURNING *;
+
+-- name: AddMessageImage :one
+INSERT INTO images (message_id, url, width, height, variants, blurhash)
+SELECT sqlc.arg(message_id)::uuid, sqlc.arg(url)::text, u.width, u.height, COALESCE(u.variants, '[]'), u.blurhash
+FROM (SELECT 1) AS image
+LEFT JOIN uploads u ON u.url = sqlc.arg(url)::text
+RETURNING *;
 
 -- name: GetMessagesByChat :many
 SELECT
@@ -50,14 +72,29 @@ SELECT
     rm.is_deleted AS reply_is_deleted,
     rm.sender_id AS reply_sender_id,
     ru.username AS reply_sender_username,
-    ru.profile_image_url AS reply_sender_profile_image_url
+    ru.profile_image_url AS reply_sender_profile_image_url,
+    m.forwarded_from_message_id,
+    m.forwarded_from_sender_id,
+    fu.username AS forwarded_from_sender_username,
+    m.message_type,
+    m.metadata,
+    m.expires_at,
+    m.entities,
+    COALESCE(rm.entities, '[]'::jsonb) AS reply_entities
 FROM messages m
 INNER JOIN chat_members cm_filter ON cm_filter.chat_id = m.chat_id AND cm_filter.user_id = sqlc.arg(user_id)::uuid
 INNER JOIN users u ON m.sender_id = u.id
 LEFT JOIN messages rm ON m.reply_to_message_id = rm.id
 LEFT JOIN users ru ON rm.sender_id = ru.id
+LEFT JOIN users fu ON m.forwarded_from_sender_id = fu.id
 WHERE m.chat_id = sqlc.arg(chat_id)::uuid
   AND (cm_filter.cleared_at IS NULL OR m.created_at > cm_filter.cleared_at)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM hidden_messages hm
+    WHERE hm.message_id = m.id AND hm.user_id = sqlc.arg(user_id)::uuid
+  )
+  AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
   AND (
     sqlc.narg(cursor_time)::timestamp IS NULL OR
     m.created_at < sqlc.narg(cursor_time)::timestamp OR
@@ -67,7 +104,7 @@ ORDER BY m.created_at DESC, m.id DESC
 LIMIT sqlc.arg(page_limit);
 
 -- name: GetMessageImages :many
-SELECT id, message_id, url, created_at
+SELECT id, message_id, url, created_at, width, height, variants, blurhash
 FROM images
 WHERE message_id = ANY($1::uuid[]);
 
@@ -78,7 +115,7 @@ WHERE id = $1;
 
 -- name: EditMessage :exec
 UPDATE messages
-SET content = $2, is_edited = true, updated_at = CURRENT_TIMESTAMP
+SET content = $2, entities = $3, is_edited = true, updated_at = CURRENT_TIMESTAMP
 WHERE id = $1;
 
 -- name: GetMessageById :one
@@ -90,6 +127,10 @@ SELECT
     m.is_deleted,
     m.is_edited,
     m.reply_to_message_id,
+    m.forwarded_from_message_id,
+    m.forwarded_from_sender_id,
+    m.message_type,
+    m.entities,
     m.created_at,
     m.updated_at
 FROM messages m
@@ -124,3 +165,64 @@ SET
 SELECT chat_id, user_id, last_read_message_id, last_read_at
 FROM chat_read_receipts
 WHERE chat_id = $1;
+
+-- name: GetMessagesByIds :many
+SELECT id, chat_id, sender_id, is_deleted, message_type
+FROM messages
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: DeleteMessages :exec
+UPDATE messages
+SET is_deleted = true, updated_at = CURRENT_TIMESTAMP
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetImageUrlsInUse :many
+SELECT i.url
+FROM images i
+INNER JOIN messages m ON i.message_id = m.id
+WHERE i.url = ANY(sqlc.arg(urls)::text[])
+  AND m.is_deleted = false
+UNION
+SELECT r.url
+FROM message_revisions mr
+INNER JOIN messages m ON mr.message_id = m.id
+CROSS JOIN LATERAL unnest(mr.images) AS r(url)
+WHERE r.url = ANY(sqlc.arg(urls)::text[])
+  AND m.is_deleted = false;
+
+-- name: CreateSystemMessage :one
+INSERT INTO messages (chat_id, sender_id, content, message_type, metadata)
+VALUES ($1, $2, $3, 'system', $4)
+RETURNING *;
+
+-- name: GetExpiredMessages :many
+SELECT id, chat_id
+FROM messages
+WHERE expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP
+ORDER BY expires_at ASC
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: HardDeleteMessages :exec
+DELETE FROM messages
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: MoveReadReceiptsOffMessages :exec
+UPDATE chat_read_receipts cr
+SET last_read_message_id = (
+    SELECT m.id
+    FROM messages m
+    WHERE m.chat_id = cr.chat_id
+      AND m.created_at <= cr.last_read_at
+      AND m.id <> ALL(sqlc.arg(ids)::uuid[])
+    ORDER BY m.created_at DESC, m.id DESC
+    LIMIT 1
+)
+WHERE cr.last_read_message_id = ANY(sqlc.arg(ids)::uuid[])
+  AND EXISTS (
+    SELECT 1
+    FROM messages m
+    WHERE m.chat_id = cr.chat_id
+      AND m.created_at <= cr.last_read_at
+      AND m.id <> ALL(sqlc.arg(ids)::uuid[])
+  );
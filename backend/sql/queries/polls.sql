@@ -0,0 +1,48 @@
+-- name: CreatePoll :one
+INSERT INTO polls (message_id, question, allows_multiple, is_anonymous, closes_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreatePollOption :one
+INSERT INTO poll_options (poll_id, text, position)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPollByMessageId :one
+SELECT * FROM polls
+WHERE message_id = $1;
+
+-- name: GetPollsByMessageIds :many
+SELECT * FROM polls
+WHERE message_id = ANY(sqlc.arg(message_ids)::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT * FROM poll_options
+WHERE poll_id = ANY(sqlc.arg(poll_ids)::uuid[])
+ORDER BY poll_id, position ASC;
+
+-- name: GetPollVotes :many
+SELECT
+    pv.poll_id,
+    pv.option_id,
+    pv.user_id,
+    u.username,
+    u.profile_image_url
+FROM poll_votes pv
+INNER JOIN users u ON pv.user_id = u.id
+WHERE pv.poll_id = ANY(sqlc.arg(poll_ids)::uuid[])
+ORDER BY pv.created_at ASC;
+
+-- name: AddPollVotes :exec
+INSERT INTO poll_votes (poll_id, option_id, user_id)
+SELECT sqlc.arg(poll_id)::uuid, unnest(sqlc.arg(option_ids)::uuid[]), sqlc.arg(user_id)::uuid
+ON CONFLICT (option_id, user_id) DO NOTHING;
+
+-- name: DeletePollVotes :exec
+DELETE FROM poll_votes
+WHERE poll_id = $1 AND user_id = $2;
+
+-- name: LockPoll :exec
+SELECT id FROM polls
+WHERE id = $1
+FOR UPDATE;
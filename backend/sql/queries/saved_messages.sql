@@ -0,0 +1,55 @@
+-- name: SaveMessage :exec
+INSERT INTO saved_messages (user_id, message_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, message_id) DO NOTHING;
+
+-- name: UnsaveMessage :exec
+DELETE FROM saved_messages
+WHERE user_id = $1 AND message_id = $2;
+
+-- name: DeleteSavedMessagesInChat :exec
+DELETE FROM saved_messages sm
+USING messages m
+WHERE sm.message_id = m.id AND sm.user_id = $1 AND m.chat_id = $2;
+
+-- name: GetSavedMessageIds :many
+SELECT message_id
+FROM saved_messages
+WHERE user_id = sqlc.arg(user_id)::uuid AND message_id = ANY(sqlc.arg(message_ids)::uuid[]);
+
+-- name: GetSavedMessages :many
+SELECT
+    sm.message_id,
+    sm.saved_at,
+    m.chat_id,
+    c.name AS chat_name,
+    c.is_group AS chat_is_group,
+    m.sender_id,
+    u.username AS sender_username,
+    u.profile_image_url AS sender_profile_image_url,
+    m.content,
+    m.entities,
+    m.is_deleted,
+    m.is_edited,
+    m.message_type,
+    m.created_at
+FROM saved_messages sm
+INNER JOIN messages m ON sm.message_id = m.id
+INNER JOIN chats c ON m.chat_id = c.id
+INNER JOIN chat_members cm ON cm.chat_id = m.chat_id AND cm.user_id = sm.user_id
+INNER JOIN users u ON m.sender_id = u.id
+WHERE sm.user_id = sqlc.arg(user_id)::uuid
+  AND (cm.cleared_at IS NULL OR m.created_at > cm.cleared_at)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM hidden_messages hm
+    WHERE hm.message_id = m.id AND hm.user_id = sqlc.arg(user_id)::uuid
+  )
+  AND (m.expires_at IS NULL OR m.expires_at > CURRENT_TIMESTAMP)
+  AND (
+    sqlc.narg(cursor_time)::timestamp IS NULL OR
+    sm.saved_at < sqlc.narg(cursor_time)::timestamp OR
+    (sm.saved_at = sqlc.narg(cursor_time)::timestamp AND sm.message_id < sqlc.narg(cursor_id)::uuid)
+  )
+ORDER BY sm.saved_at DESC, sm.message_id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,48 @@
+-- name: CreateScheduledMessage :one
+INSERT INTO scheduled_messages (chat_id, sender_id, content, entities, images, reply_to_message_id, send_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetScheduledMessageById :one
+SELECT * FROM scheduled_messages
+WHERE id = $1;
+
+-- name: GetPendingScheduledMessagesBySender :many
+SELECT * FROM scheduled_messages
+WHERE sender_id = sqlc.arg(sender_id)::uuid
+  AND status = 'pending'
+  AND (sqlc.narg(chat_id)::uuid IS NULL OR chat_id = sqlc.narg(chat_id)::uuid)
+ORDER BY send_at ASC;
+
+-- name: UpdateScheduledMessage :one
+UPDATE scheduled_messages
+SET
+    content = $2,
+    entities = $3,
+    images = $4,
+    send_at = $5
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: CancelScheduledMessage :execrows
+UPDATE scheduled_messages
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'pending';
+
+-- name: ClaimDueScheduledMessage :one
+SELECT * FROM scheduled_messages
+WHERE status = 'pending' AND send_at <= CURRENT_TIMESTAMP
+ORDER BY send_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkScheduledMessageSent :exec
+UPDATE scheduled_messages
+SET status = 'sent', sent_message_id = $2
+WHERE id = $1;
+
+-- name: MarkScheduledMessageFailed :one
+UPDATE scheduled_messages
+SET status = 'failed', failure_reason = $2
+WHERE id = $1 AND status = 'pending'
+RETURNING images;
@@ -0,0 +1,143 @@
+-- name: CreateUpload :one
+INSERT INTO uploads (owner_id, key, url, size, content_type, width, height, variants, blurhash)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetUploadByUrl :one
+SELECT *
+FROM uploads
+WHERE url = $1;
+
+-- name: GetAvailableUploadUrls :many
+SELECT url
+FROM uploads
+WHERE owner_id = sqlc.arg(owner_id)::uuid
+  AND url = ANY(sqlc.arg(urls)::text[])
+  AND used_at IS NULL
+  AND NOT pending
+  AND kind = 'image';
+
+-- name: ClaimUploads :many
+UPDATE uploads
+SET used_at = CURRENT_TIMESTAMP
+WHERE owner_id = sqlc.arg(owner_id)::uuid
+  AND url = ANY(sqlc.arg(urls)::text[])
+  AND used_at IS NULL
+  AND NOT pending
+  AND kind = 'image'
+RETURNING url;
+
+-- name: GetOrphanedUploads :many
+SELECT u.id, u.key, u.url, u.size, u.variants
+FROM uploads u
+WHERE u.created_at < sqlc.arg(created_before)::timestamp
+  AND u.id > sqlc.arg(after_id)::uuid
+  AND NOT EXISTS (
+    SELECT 1
+    FROM images i
+    INNER JOIN messages m ON i.message_id = m.id
+    WHERE i.url = u.url AND m.is_deleted = false
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM message_revisions mr
+    INNER JOIN messages m ON mr.message_id = m.id
+    WHERE u.url = ANY(mr.images) AND m.is_deleted = false
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM attachments a
+    INNER JOIN messages m ON a.message_id = m.id
+    WHERE a.key = u.key AND m.is_deleted = false
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM users us
+    WHERE us.profile_image_url = u.url
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM message_drafts d
+    WHERE u.url = ANY(d.images)
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM scheduled_messages sm
+    WHERE sm.status = 'pending' AND u.url = ANY(sm.images)
+  )
+ORDER BY u.id
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE OF u SKIP LOCKED;
+
+-- name: DeleteUploads :exec
+DELETE FROM uploads
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: DeleteUploadsByKeys :exec
+DELETE FROM uploads
+WHERE key = ANY(sqlc.arg(keys)::text[]);
+
+-- name: CreatePendingUpload :one
+INSERT INTO uploads (owner_id, key, url, size, content_type, pending)
+VALUES ($1, $2, $3, $4, $5, true)
+RETURNING *;
+
+-- name: GetPendingUpload :one
+SELECT *
+FROM uploads
+WHERE id = $1 AND owner_id = $2 AND pending;
+
+-- name: CompleteUpload :one
+UPDATE uploads
+SET pending = false, key = $2, url = $3, size = $4, content_type = $5, width = $6, height = $7, variants = $8, blurhash = $9
+WHERE id = $1 AND pending
+RETURNING *;
+
+-- name: CreateFileUpload :one
+INSERT INTO uploads (owner_id, key, url, size, content_type, kind, file_name)
+VALUES ($1, $2, $3, $4, $5, 'file', $6)
+RETURNING *;
+
+-- name: ClaimFileUploads :many
+UPDATE uploads
+SET used_at = CURRENT_TIMESTAMP
+WHERE owner_id = sqlc.arg(owner_id)::uuid
+  AND id = ANY(sqlc.arg(ids)::uuid[])
+  AND used_at IS NULL
+  AND NOT pending
+  AND kind IN ('file', 'voice')
+RETURNING id;
+
+-- name: CreateVoiceUpload :one
+INSERT INTO uploads (owner_id, key, url, size, content_type, kind, file_name, duration_ms, waveform)
+VALUES ($1, $2, $3, $4, $5, 'voice', $6, $7, $8)
+RETURNING *;
+
+-- name: UnchargeRevisionImages :exec
+UPDATE uploads u
+SET charged = false
+WHERE u.url = ANY(sqlc.arg(urls)::text[])
+  AND u.charged
+  AND NOT EXISTS (
+    SELECT 1
+    FROM images i
+    INNER JOIN messages m ON i.message_id = m.id
+    WHERE i.url = u.url AND m.is_deleted = false
+  )
+  AND EXISTS (
+    SELECT 1
+    FROM message_revisions mr
+    INNER JOIN messages m ON mr.message_id = m.id
+    WHERE u.url = ANY(mr.images) AND m.is_deleted = false
+  );
+
+-- name: TrackRawUpload :exec
+INSERT INTO uploads (owner_id, key, url, size, content_type, pending, charged)
+VALUES ($1, $2, $3, $4, $5, true, false);
+
+-- name: ReleaseUploads :exec
+UPDATE uploads
+SET used_at = NULL
+WHERE owner_id = sqlc.arg(owner_id)::uuid
+  AND url = ANY(sqlc.arg(urls)::text[])
+  AND kind = 'image';
@@ -0,0 +1,14 @@
+-- name: GetUserStorageUsed :one
+SELECT COALESCE((SELECT bytes_used FROM user_storage WHERE user_id = $1), 0)::bigint AS bytes_used;
+
+-- name: GetUserStorageBreakdown :many
+SELECT kind, COUNT(*) AS uploads, COALESCE(SUM(upload_stored_bytes(size, variants)), 0)::bigint AS bytes
+FROM uploads
+WHERE owner_id = $1 AND charged
+GROUP BY kind
+ORDER BY kind;
+
+-- name: LockUserStorage :one
+INSERT INTO user_storage (user_id) VALUES ($1)
+ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+RETURNING bytes_used;
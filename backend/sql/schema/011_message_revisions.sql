@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE message_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content TEXT,
+    images TEXT[] NOT NULL DEFAULT '{}',
+    edited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions(message_id, edited_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_revisions_message_id;
+DROP TABLE IF EXISTS message_revisions;
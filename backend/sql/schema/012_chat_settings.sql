@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE chat_settings (
+    chat_id UUID PRIMARY KEY REFERENCES chats(id) ON DELETE CASCADE,
+    edit_window_seconds INTEGER NOT NULL DEFAULT 900 CHECK (edit_window_seconds >= 0),
+    allow_deletes BOOLEAN NOT NULL DEFAULT true,
+    max_images INTEGER NOT NULL DEFAULT 5 CHECK (max_images >= 0),
+    max_message_length INTEGER NOT NULL DEFAULT 5000 CHECK (max_message_length > 0),
+    who_can_post VARCHAR(20) NOT NULL DEFAULT 'everyone' CHECK (who_can_post IN ('everyone', 'admin')),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO chat_settings (chat_id)
+SELECT id FROM chats
+ON CONFLICT (chat_id) DO NOTHING;
+
+-- +goose StatementBegin
+CREATE TRIGGER update_chat_settings_updated_at BEFORE UPDATE ON chat_settings
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_chat_settings_updated_at ON chat_settings;
+DROP TABLE IF EXISTS chat_settings;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE hidden_messages (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    hidden_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, message_id)
+);
+
+CREATE INDEX idx_hidden_messages_message_id ON hidden_messages(message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_hidden_messages_message_id;
+DROP TABLE IF EXISTS hidden_messages;
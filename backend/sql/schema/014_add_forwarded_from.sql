@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE messages
+ADD COLUMN forwarded_from_message_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+ALTER TABLE messages
+ADD COLUMN forwarded_from_sender_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_messages_forwarded_from_message_id ON messages(forwarded_from_message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_forwarded_from_message_id;
+
+ALTER TABLE messages
+DROP COLUMN forwarded_from_sender_id;
+
+ALTER TABLE messages
+DROP COLUMN forwarded_from_message_id;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE scheduled_messages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content TEXT,
+    images TEXT[] NOT NULL DEFAULT '{}',
+    reply_to_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    send_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'cancelled', 'failed')),
+    failure_reason TEXT,
+    sent_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scheduled_messages_due ON scheduled_messages(send_at) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_messages_sender_id ON scheduled_messages(sender_id, send_at);
+
+-- +goose StatementBegin
+CREATE TRIGGER update_scheduled_messages_updated_at BEFORE UPDATE ON scheduled_messages
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_scheduled_messages_updated_at ON scheduled_messages;
+DROP INDEX IF EXISTS idx_scheduled_messages_sender_id;
+DROP INDEX IF EXISTS idx_scheduled_messages_due;
+DROP TABLE IF EXISTS scheduled_messages;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE chat_settings
+ADD COLUMN message_ttl_seconds INTEGER NOT NULL DEFAULT 0 CHECK (message_ttl_seconds >= 0);
+
+ALTER TABLE messages
+ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_expires_at;
+
+ALTER TABLE messages
+DROP COLUMN expires_at;
+
+ALTER TABLE chat_settings
+DROP COLUMN message_ttl_seconds;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE messages
+ADD COLUMN message_type VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (message_type IN ('user', 'system'));
+
+ALTER TABLE messages
+ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE messages
+DROP COLUMN metadata;
+
+ALTER TABLE messages
+DROP COLUMN message_type;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE message_mentions (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    length INTEGER NOT NULL CHECK (length > 0),
+    PRIMARY KEY (message_id, position)
+);
+
+CREATE INDEX idx_message_mentions_user_id ON message_mentions(user_id, message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_mentions_user_id;
+DROP TABLE IF EXISTS message_mentions;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE messages
+ADD COLUMN entities JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE messages
+DROP COLUMN entities;
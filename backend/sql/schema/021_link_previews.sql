@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    title TEXT,
+    description TEXT,
+    image_url TEXT,
+    site_name TEXT,
+    fetch_failed BOOLEAN NOT NULL DEFAULT false,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE message_link_previews (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    url TEXT NOT NULL REFERENCES link_previews(url) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (message_id, url)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_link_previews;
+DROP TABLE IF EXISTS link_previews;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE saved_messages (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    saved_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, message_id)
+);
+
+CREATE INDEX idx_saved_messages_user_saved_at ON saved_messages(user_id, saved_at DESC, message_id DESC);
+CREATE INDEX idx_saved_messages_message_id ON saved_messages(message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_saved_messages_message_id;
+DROP INDEX IF EXISTS idx_saved_messages_user_saved_at;
+DROP TABLE IF EXISTS saved_messages;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE message_drafts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    content TEXT,
+    images TEXT[] NOT NULL DEFAULT '{}',
+    reply_to_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, chat_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_drafts;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE message_reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    remind_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_reminders_due ON message_reminders(remind_at);
+CREATE INDEX idx_message_reminders_user_id ON message_reminders(user_id, remind_at);
+CREATE INDEX idx_message_reminders_message_id ON message_reminders(message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_reminders_message_id;
+DROP INDEX IF EXISTS idx_message_reminders_user_id;
+DROP INDEX IF EXISTS idx_message_reminders_due;
+DROP TABLE IF EXISTS message_reminders;
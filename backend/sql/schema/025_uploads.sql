@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL UNIQUE,
+    url TEXT NOT NULL UNIQUE,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    content_type VARCHAR(100) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_uploads_owner_id ON uploads(owner_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_uploads_owner_id;
+DROP TABLE IF EXISTS uploads;
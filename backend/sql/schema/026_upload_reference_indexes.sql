@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX idx_images_url ON images(url);
+CREATE INDEX idx_users_profile_image_url ON users(profile_image_url) WHERE profile_image_url IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_profile_image_url;
+DROP INDEX IF EXISTS idx_images_url;
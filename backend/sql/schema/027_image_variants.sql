@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE uploads
+ADD COLUMN width INTEGER,
+ADD COLUMN height INTEGER,
+ADD COLUMN variants JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE images
+ADD COLUMN width INTEGER,
+ADD COLUMN height INTEGER,
+ADD COLUMN variants JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE images
+DROP COLUMN variants,
+DROP COLUMN height,
+DROP COLUMN width;
+
+ALTER TABLE uploads
+DROP COLUMN variants,
+DROP COLUMN height,
+DROP COLUMN width;
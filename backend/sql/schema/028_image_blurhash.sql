@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE uploads
+ADD COLUMN blurhash TEXT;
+
+ALTER TABLE images
+ADD COLUMN blurhash TEXT;
+
+-- +goose Down
+ALTER TABLE images
+DROP COLUMN blurhash;
+
+ALTER TABLE uploads
+DROP COLUMN blurhash;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE uploads
+ADD COLUMN pending BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE uploads
+DROP COLUMN pending;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE uploads
+ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'image' CHECK (kind IN ('image', 'file')),
+ADD COLUMN file_name TEXT;
+
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_attachments_message_id ON attachments(message_id);
+CREATE INDEX idx_attachments_key ON attachments(key);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_attachments_key;
+DROP INDEX IF EXISTS idx_attachments_message_id;
+DROP TABLE IF EXISTS attachments;
+
+ALTER TABLE uploads
+DROP COLUMN file_name,
+DROP COLUMN kind;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE uploads
+DROP CONSTRAINT uploads_kind_check,
+ADD CONSTRAINT uploads_kind_check CHECK (kind IN ('image', 'file', 'voice')),
+ADD COLUMN duration_ms INTEGER,
+ADD COLUMN waveform JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE attachments
+ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'file' CHECK (kind IN ('file', 'voice')),
+ADD COLUMN duration_ms INTEGER,
+ADD COLUMN waveform JSONB NOT NULL DEFAULT '[]';
+
+-- +goose Down
+ALTER TABLE attachments
+DROP COLUMN waveform,
+DROP COLUMN duration_ms,
+DROP COLUMN kind;
+
+DELETE FROM uploads WHERE kind = 'voice';
+
+ALTER TABLE uploads
+DROP COLUMN waveform,
+DROP COLUMN duration_ms,
+DROP CONSTRAINT uploads_kind_check,
+ADD CONSTRAINT uploads_kind_check CHECK (kind IN ('image', 'file'));
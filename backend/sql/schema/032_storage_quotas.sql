@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE user_storage (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bytes_used BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION upload_stored_bytes(size BIGINT, variants JSONB)
+RETURNS BIGINT AS $$
+    SELECT size + COALESCE((SELECT SUM((v->>'size')::BIGINT) FROM jsonb_array_elements(variants) v), 0);
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION track_user_storage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE user_storage
+        SET bytes_used = bytes_used - upload_stored_bytes(OLD.size, OLD.variants), updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = OLD.owner_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO user_storage (user_id, bytes_used)
+        VALUES (NEW.owner_id, upload_stored_bytes(NEW.size, NEW.variants))
+        ON CONFLICT (user_id) DO UPDATE
+        SET bytes_used = user_storage.bytes_used + EXCLUDED.bytes_used, updated_at = CURRENT_TIMESTAMP;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER track_uploads_user_storage AFTER INSERT OR DELETE OR UPDATE OF owner_id, size, variants ON uploads
+FOR EACH ROW EXECUTE FUNCTION track_user_storage();
+-- +goose StatementEnd
+
+INSERT INTO user_storage (user_id, bytes_used)
+SELECT owner_id, SUM(upload_stored_bytes(size, variants))
+FROM uploads
+GROUP BY owner_id;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS track_uploads_user_storage ON uploads;
+DROP FUNCTION IF EXISTS track_user_storage();
+DROP FUNCTION IF EXISTS upload_stored_bytes(BIGINT, JSONB);
+DROP TABLE IF EXISTS user_storage;
@@ -0,0 +1,67 @@
+-- +goose Up
+-- Images edited out of a message stay stored for its edit history, but no longer count against the owner's quota
+ALTER TABLE uploads ADD COLUMN charged BOOLEAN NOT NULL DEFAULT true;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION track_user_storage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.charged THEN
+        UPDATE user_storage
+        SET bytes_used = bytes_used - upload_stored_bytes(OLD.size, OLD.variants), updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = OLD.owner_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.charged THEN
+        INSERT INTO user_storage (user_id, bytes_used)
+        VALUES (NEW.owner_id, upload_stored_bytes(NEW.size, NEW.variants))
+        ON CONFLICT (user_id) DO UPDATE
+        SET bytes_used = user_storage.bytes_used + EXCLUDED.bytes_used, updated_at = CURRENT_TIMESTAMP;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS track_uploads_user_storage ON uploads;
+
+-- +goose StatementBegin
+CREATE TRIGGER track_uploads_user_storage AFTER INSERT OR DELETE OR UPDATE OF owner_id, size, variants, charged ON uploads
+FOR EACH ROW EXECUTE FUNCTION track_user_storage();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS track_uploads_user_storage ON uploads;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION track_user_storage()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE user_storage
+        SET bytes_used = bytes_used - upload_stored_bytes(OLD.size, OLD.variants), updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = OLD.owner_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO user_storage (user_id, bytes_used)
+        VALUES (NEW.owner_id, upload_stored_bytes(NEW.size, NEW.variants))
+        ON CONFLICT (user_id) DO UPDATE
+        SET bytes_used = user_storage.bytes_used + EXCLUDED.bytes_used, updated_at = CURRENT_TIMESTAMP;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER track_uploads_user_storage AFTER INSERT OR DELETE OR UPDATE OF owner_id, size, variants ON uploads
+FOR EACH ROW EXECUTE FUNCTION track_user_storage();
+-- +goose StatementEnd
+
+UPDATE user_storage us
+SET bytes_used = COALESCE((SELECT SUM(upload_stored_bytes(u.size, u.variants)) FROM uploads u WHERE u.owner_id = us.user_id), 0);
+
+ALTER TABLE uploads DROP COLUMN IF EXISTS charged;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE scheduled_messages
+ADD COLUMN entities JSONB NOT NULL DEFAULT '[]';
+
+-- Images of pending scheduled messages are now reserved when scheduling instead of when sending
+UPDATE uploads u
+SET used_at = CURRENT_TIMESTAMP
+FROM scheduled_messages sm
+WHERE sm.status = 'pending'
+  AND sm.sender_id = u.owner_id
+  AND u.url = ANY(sm.images)
+  AND u.used_at IS NULL
+  AND NOT u.pending
+  AND u.kind = 'image';
+
+-- +goose Down
+ALTER TABLE scheduled_messages
+DROP COLUMN entities;
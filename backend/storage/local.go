@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps objects on the local filesystem for development, CI and self-hosted
+// deployments. The files are served by the backend itself under LocalFilesRoute.
+type LocalStorage struct {
+	root      string
+	publicURL string
+}
+
+// LocalFilesRoute is the path prefix the backend serves local objects from
+const LocalFilesRoute = "/files"
+
+func NewLocalStorage(root, publicURL string) (*LocalStorage, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStorage{
+		root:      absRoot,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func NewLocalFromEnv() (*LocalStorage, error) {
+	root := os.Getenv("LOCAL_STORAGE_DIR")
+	if root == "" {
+		root = "./uploads"
+	}
+
+	publicURL := os.Getenv("LOCAL_STORAGE_PUBLIC_URL")
+	if publicURL == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8000"
+		}
+		publicURL = fmt.Sprintf("http://localhost:%s%s", port, LocalFilesRoute)
+	}
+
+	return NewLocalStorage(root, publicURL)
+}
+
+// path maps a key to a file under the root, rejecting keys that would escape it
+func (l *LocalStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (l *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partially written object
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) URL(key string) string {
+	return l.publicURL + "/" + key
+}
+
+func (l *LocalStorage) KeyForURL(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, l.publicURL+"/")
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func (l *LocalStorage) Open(ctx context.Context, key string) (*Object, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(target))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &Object{
+		Body:        file,
+		ContentType: contentType,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+	}, nil
+}
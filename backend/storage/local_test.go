@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+
+	store, err := NewLocalStorage(filepath.Join(t.TempDir(), "root"), "http://localhost/files/")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	return store
+}
+
+func TestLocalStoragePath(t *testing.T) {
+	store := newTestLocalStorage(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "nested key", key: "user/1/photo.jpg", want: "user/1/photo.jpg"},
+		{name: "top-level key", key: "photo.jpg", want: "photo.jpg"},
+		{name: "dots in name", key: "user/..photo.jpg", want: "user/..photo.jpg"},
+		{name: "empty", key: "", wantErr: true},
+		{name: "root", key: "/", wantErr: true},
+		{name: "current directory", key: ".", wantErr: true},
+		{name: "parent directory", key: "..", wantErr: true},
+		{name: "escapes root", key: "../escape.txt", wantErr: true},
+		{name: "escapes through a subdirectory", key: "user/../../escape.txt", wantErr: true},
+		{name: "stays inside but not clean", key: "user/../photo.jpg", wantErr: true},
+		{name: "absolute", key: "/etc/passwd", wantErr: true},
+		{name: "double slash", key: "user//photo.jpg", wantErr: true},
+		{name: "dot segment", key: "user/./photo.jpg", wantErr: true},
+		{name: "trailing slash", key: "user/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("path(%q) = %q, want an error", tt.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) error = %v", tt.key, err)
+			}
+
+			want := filepath.Join(store.root, filepath.FromSlash(tt.want))
+			if got != want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, want)
+			}
+		})
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	store := newTestLocalStorage(t)
+	ctx := context.Background()
+	outside := filepath.Join(filepath.Dir(store.root), "escape.txt")
+
+	if err := store.Put(ctx, "../escape.txt", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Error("Put() accepted a key outside the root")
+	}
+	if _, err := os.Stat(outside); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file written outside the root, Stat error = %v", err)
+	}
+
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("write file outside root: %v", err)
+	}
+	if _, err := store.Open(ctx, "../escape.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "../escape.txt"); err == nil {
+		t.Error("Delete() accepted a key outside the root")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside the root was deleted: %v", err)
+	}
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	store := newTestLocalStorage(t)
+	ctx := context.Background()
+	key := "user/1/notes.txt"
+
+	if err := store.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	object, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := io.ReadAll(object.Body)
+	object.Body.Close()
+	if err != nil {
+		t.Fatalf("read object: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("object = %q, want %q", data, "hello")
+	}
+
+	url := store.URL(key)
+	if url != "http://localhost/files/"+key {
+		t.Errorf("URL() = %q", url)
+	}
+	if got, ok := store.KeyForURL(url); !ok || got != key {
+		t.Errorf("KeyForURL(%q) = %q, %v, want %q", url, got, ok, key)
+	}
+	for _, other := range []string{"http://localhost/files/", "https://example.com/files/" + key} {
+		if got, ok := store.KeyForURL(other); ok {
+			t.Errorf("KeyForURL(%q) = %q, want no key", other, got)
+		}
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+type S3Config struct {
+	// Endpoint overrides the AWS endpoint for S3-compatible services such as R2 or MinIO
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	// PublicURL is the base address objects are served from
+	PublicURL      string
+	ForcePathStyle bool
+	// Private serves objects through signed URLs instead. PublicURL still forms the stored URLs,
+	// so an existing bucket can be made private without rewriting them.
+	Private bool
+}
+
+// S3Storage stores objects in an S3-compatible bucket that is either publicly readable through
+// PublicURL or private and read through signed URLs
+type S3Storage struct {
+	client    *s3.Client
+	bucket    string
+	publicURL string
+	private   bool
+	// endpointHost is the host signed URLs are issued for, empty for AWS
+	endpointHost string
+}
+
+func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.PublicURL == "" {
+		return nil, errors.New("bucket and public url are required")
+	}
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithRegion(cfg.Region),
+	}
+	// Fall back to the default credential chain (env, shared config, instance role) when no keys are given
+	if cfg.AccessKeyID != "" || cfg.SecretAccessKey != "" {
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			cfg.AccessKeyID,
+			cfg.SecretAccessKey,
+			"",
+		)))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.ForcePathStyle
+	})
+
+	var endpointHost string
+	if cfg.Endpoint != "" {
+		endpoint, err := url.Parse(cfg.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint: %w", err)
+		}
+		endpointHost = endpoint.Host
+	}
+
+	return &S3Storage{
+		client:       client,
+		bucket:       cfg.Bucket,
+		publicURL:    strings.TrimSuffix(cfg.PublicURL, "/"),
+		private:      cfg.Private,
+		endpointHost: endpointHost,
+	}, nil
+}
+
+func NewR2FromEnv(ctx context.Context) (*S3Storage, error) {
+	accountID := os.Getenv("R2_ACCOUNT_ID")
+	accessKeyID := os.Getenv("R2_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("R2_SECRET_ACCESS_KEY")
+	bucketName := os.Getenv("R2_BUCKET_NAME")
+	publicURL := os.Getenv("R2_PUBLIC_URL")
+
+	if accountID == "" || accessKeyID == "" || secretAccessKey == "" || bucketName == "" || publicURL == "" {
+		return nil, errors.New("missing required R2 configuration")
+	}
+
+	return NewS3Storage(ctx, S3Config{
+		Endpoint:        fmt.Sprintf("https://%s.r2.cloudflarestorage.com", accountID),
+		Region:          "auto",
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Bucket:          bucketName,
+		PublicURL:       publicURL,
+		Private:         os.Getenv("STORAGE_PRIVATE") == "true",
+	})
+}
+
+func NewS3FromEnv(ctx context.Context) (*S3Storage, error) {
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return NewS3Storage(ctx, S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          region,
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		ForcePathStyle:  os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		Private:         os.Getenv("STORAGE_PRIVATE") == "true",
+	})
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	})
+	return err
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+func (s *S3Storage) KeyForURL(rawURL string) (string, bool) {
+	if key, ok := strings.CutPrefix(rawURL, s.publicURL+"/"); ok && key != "" {
+		return key, true
+	}
+	if s.private {
+		return s.keyForSignedURL(rawURL)
+	}
+	return "", false
+}
+
+// keyForSignedURL recognises URLs from SignURL, which clients of a private bucket send back
+// in place of the stored URL
+func (s *S3Storage) keyForSignedURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Query().Get("X-Amz-Signature") == "" {
+		return "", false
+	}
+
+	host := u.Host
+	if s.endpointHost == "" {
+		// AWS itself, e.g. bucket.s3.us-east-1.amazonaws.com
+		if !strings.HasSuffix(host, ".amazonaws.com") {
+			return "", false
+		}
+	} else if host != s.endpointHost && host != s.bucket+"."+s.endpointHost {
+		return "", false
+	}
+
+	var key string
+	if strings.HasPrefix(host, s.bucket+".") {
+		key = strings.TrimPrefix(u.Path, "/")
+	} else {
+		var ok bool
+		key, ok = strings.CutPrefix(u.Path, "/"+s.bucket+"/")
+		if !ok {
+			return "", false
+		}
+	}
+
+	if key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func (s *S3Storage) Private() bool {
+	return s.private
+}
+
+func (s *S3Storage) SignURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presigner := s3.NewPresignClient(s.client)
+
+	req, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func (s *S3Storage) Open(ctx context.Context, key string) (*Object, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	object := &Object{
+		Body:        out.Body,
+		ContentType: aws.ToString(out.ContentType),
+		Size:        aws.ToInt64(out.ContentLength),
+	}
+	if out.LastModified != nil {
+		object.ModTime = *out.LastModified
+	}
+	return object, nil
+}
+
+func (s *S3Storage) PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedUpload, error) {
+	presigner := s3.NewPresignClient(s.client)
+
+	// Content type and length are signed, so the store rejects any other file
+	req, err := presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return nil, err
+	}
+
+	// Browsers set Host and Content-Length themselves and refuse to send them explicitly
+	headers := make(map[string]string, len(req.SignedHeader))
+	for name, values := range req.SignedHeader {
+		canonical := http.CanonicalHeaderKey(name)
+		if canonical == "Host" || canonical == "Content-Length" || len(values) == 0 {
+			continue
+		}
+		headers[canonical] = values[0]
+	}
+
+	return &PresignedUpload{
+		URL:       req.URL,
+		Method:    req.Method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(expires),
+	}, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*Object, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		// HEAD responses have no body, so a missing key is reported as NotFound rather than NoSuchKey
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	object := &Object{
+		ContentType: aws.ToString(out.ContentType),
+		Size:        aws.ToInt64(out.ContentLength),
+	}
+	if out.LastModified != nil {
+		object.ModTime = *out.LastModified
+	}
+	return object, nil
+}
+
+func (s *S3Storage) OpenPrefix(ctx context.Context, key string, n int64) (*Object, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	object := &Object{
+		Body:        out.Body,
+		ContentType: aws.ToString(out.ContentType),
+		Size:        aws.ToInt64(out.ContentLength),
+	}
+	if out.LastModified != nil {
+		object.ModTime = *out.LastModified
+	}
+	return object, nil
+}
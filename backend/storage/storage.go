@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Object is an open stored object. Callers must close Body.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage is an object store for uploaded files, addressed by slash-separated keys
+type Storage interface {
+	// Put stores body under key, replacing any existing object
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes the object, succeeding if it does not exist
+	Delete(ctx context.Context, key string) error
+	// URL is the address clients use to fetch the object
+	URL(key string) string
+	// KeyForURL reverses URL, reporting false for URLs this storage did not produce
+	KeyForURL(url string) (string, bool)
+	// Open reads the object back, returning ErrNotFound if it does not exist
+	Open(ctx context.Context, key string) (*Object, error)
+}
+
+// PresignedUpload is a request the client sends to the store itself, bypassing the API
+type PresignedUpload struct {
+	URL    string
+	Method string
+	// Headers must be sent exactly as given, they are part of the signature
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// DirectUploader is implemented by drivers that let clients upload straight to the store
+type DirectUploader interface {
+	// PresignPut returns a request that stores exactly size bytes of contentType under key
+	PresignPut(ctx context.Context, key string, contentType string, size int64, expires time.Duration) (*PresignedUpload, error)
+	// Stat returns the object's metadata with a nil Body, or ErrNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+	// OpenPrefix reads at most the first n bytes of the object, or returns ErrNotFound
+	OpenPrefix(ctx context.Context, key string, n int64) (*Object, error)
+}
+
+// URLSigner is implemented by drivers that can hand out temporary read access to objects
+type URLSigner interface {
+	// Private reports whether objects are only readable through signed URLs, in which case URL
+	// is just a stable identifier for the object
+	Private() bool
+	// SignURL returns a URL that can read the object until it expires
+	SignURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// NewFromEnv creates the storage driver selected by STORAGE_DRIVER ("r2", "s3" or "local").
+// When unset, R2 is used if it is configured and the local filesystem otherwise.
+// STORAGE_PRIVATE=true keeps the bucket private and serves objects through signed URLs.
+func NewFromEnv(ctx context.Context) (Storage, error) {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		if os.Getenv("R2_ACCOUNT_ID") != "" {
+			driver = "r2"
+		} else {
+			driver = "local"
+		}
+	}
+
+	switch driver {
+	case "r2":
+		return NewR2FromEnv(ctx)
+	case "s3":
+		return NewS3FromEnv(ctx)
+	case "local":
+		if os.Getenv("STORAGE_PRIVATE") == "true" {
+			return nil, errors.New("private storage requires the r2 or s3 driver")
+		}
+		return NewLocalFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
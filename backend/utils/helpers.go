@@ -3,6 +3,7 @@ package utils
 import (
 	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -18,6 +19,22 @@ func NullableString(value sql.NullString) *string {
 	return nil
 }
 
+func NullableTime(value sql.NullTime) *time.Time {
+	if value.Valid {
+		v := value.Time
+		return &v
+	}
+	return nil
+}
+
+func NullableInt32(value sql.NullInt32) *int32 {
+	if value.Valid {
+		v := value.Int32
+		return &v
+	}
+	return nil
+}
+
 func GetUserIDFromContext(c *gin.Context) (uuid.UUID, bool) {
 	userIDValue, exists := c.Get("user_id")
 	if !exists {
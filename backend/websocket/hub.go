@@ -156,6 +156,32 @@ func (h *Hub) BroadcastToChatExclude(chatID string, message WSMessage, excludeUs
 	log.Printf("Broadcast to chat: chat_id=%s, type=%s, sent_to=%d clients", chatID, message.Type, sentCount)
 }
 
+func (h *Hub) SendToUser(userID string, message WSMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	sentCount := 0
+	for client := range h.Clients {
+		if client.userID != userID {
+			continue
+		}
+		select {
+		case client.send <- data:
+			sentCount++
+		default:
+			log.Printf("Client send buffer full, skipping: user_id=%s", client.userID)
+		}
+	}
+
+	log.Printf("Sent to user: user_id=%s, type=%s, sent_to=%d clients", userID, message.Type, sentCount)
+}
+
 func (h *Hub) BroadcastTyping(chatID string, eventType EventType, payload TypingPayload) {
 	message := WSMessage{
 		Type:    eventType,
@@ -1,18 +1,28 @@
 package websocket
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type EventType string
 
 const (
-	EventMessageSent    EventType = "message_sent"
-	EventMessageEdited  EventType = "message_edited"
-	EventMessageDeleted EventType = "message_deleted"
-	EventMessageRead    EventType = "message_read"
-	EventTypingStart    EventType = "typing_start"
-	EventTypingStop     EventType = "typing_stop"
-	EventJoinChat       EventType = "join_chat"
-	EventLeaveChat      EventType = "leave_chat"
+	EventMessageSent         EventType = "message_sent"
+	EventMessageEdited       EventType = "message_edited"
+	EventMessageDeleted      EventType = "message_deleted"
+	EventMessageHidden       EventType = "message_hidden"
+	EventMessagesDeleted     EventType = "messages_deleted"
+	EventMessagesHidden      EventType = "messages_hidden"
+	EventMessageRead         EventType = "message_read"
+	EventPollUpdated         EventType = "poll_updated"
+	EventMessagePreviewReady EventType = "message_preview_ready"
+	EventDraftUpdated        EventType = "draft_updated"
+	EventReminderFired       EventType = "reminder_fired"
+	EventTypingStart         EventType = "typing_start"
+	EventTypingStop          EventType = "typing_stop"
+	EventJoinChat            EventType = "join_chat"
+	EventLeaveChat           EventType = "leave_chat"
 )
 
 type WSMessage struct {
@@ -21,25 +31,35 @@ type WSMessage struct {
 }
 
 type MessageSentPayload struct {
-	ID             string        `json:"id"`
-	ChatID         string        `json:"chat_id"`
-	SenderID       string        `json:"sender_id"`
-	SenderUsername string        `json:"sender_username"`
-	Content        *string       `json:"content,omitempty"`
-	Images         []string      `json:"images"`
-	IsDeleted      bool          `json:"is_deleted"`
-	IsEdited       bool          `json:"is_edited"`
-	CreatedAt      time.Time     `json:"created_at"`
-	ReplyTo        *ReplyMessage `json:"reply_to,omitempty"`
+	ID             string          `json:"id"`
+	ChatID         string          `json:"chat_id"`
+	SenderID       string          `json:"sender_id"`
+	SenderUsername string          `json:"sender_username"`
+	Content        *string         `json:"content,omitempty"`
+	Images         []MessageImage  `json:"images"`
+	Attachments    []Attachment    `json:"attachments,omitempty"`
+	IsDeleted      bool            `json:"is_deleted"`
+	IsEdited       bool            `json:"is_edited"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ReplyTo        *ReplyMessage   `json:"reply_to,omitempty"`
+	ForwardedFrom  *ForwardedFrom  `json:"forwarded_from,omitempty"`
+	Poll           *PollPayload    `json:"poll,omitempty"`
+	Mentions       []MentionEntity `json:"mentions,omitempty"`
+	Entities       []MessageEntity `json:"entities,omitempty"`
+	MessageType    string          `json:"message_type"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time      `json:"expires_at,omitempty"`
 }
 
 type MessageEditedPayload struct {
-	ID        string    `json:"id"`
-	ChatID    string    `json:"chat_id"`
-	Content   *string   `json:"content,omitempty"`
-	Images    []string  `json:"images"`
-	IsEdited  bool      `json:"is_edited"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        string          `json:"id"`
+	ChatID    string          `json:"chat_id"`
+	Content   *string         `json:"content,omitempty"`
+	Images    []MessageImage  `json:"images"`
+	Mentions  []MentionEntity `json:"mentions,omitempty"`
+	Entities  []MessageEntity `json:"entities,omitempty"`
+	IsEdited  bool            `json:"is_edited"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 type MessageDeletedPayload struct {
@@ -48,6 +68,21 @@ type MessageDeletedPayload struct {
 	IsDeleted bool   `json:"is_deleted"`
 }
 
+type MessageHiddenPayload struct {
+	ID     string `json:"id"`
+	ChatID string `json:"chat_id"`
+}
+
+type MessagesDeletedPayload struct {
+	ChatID     string   `json:"chat_id"`
+	MessageIDs []string `json:"message_ids"`
+	IsDeleted  bool     `json:"is_deleted"`
+}
+
+type MessagesHiddenPayload struct {
+	Messages []MessageHiddenPayload `json:"messages"`
+}
+
 type MessageReadPayload struct {
 	ChatID            string    `json:"chat_id"`
 	UserID            string    `json:"user_id"`
@@ -56,12 +91,118 @@ type MessageReadPayload struct {
 }
 
 type ReplyMessage struct {
-	ID             string   `json:"id"`
-	SenderID       string   `json:"sender_id"`
-	SenderUsername string   `json:"sender_username"`
-	Content        *string  `json:"content,omitempty"`
-	Images         []string `json:"images"`
-	IsDeleted      bool     `json:"is_deleted"`
+	ID             string          `json:"id"`
+	SenderID       string          `json:"sender_id"`
+	SenderUsername string          `json:"sender_username"`
+	Content        *string         `json:"content,omitempty"`
+	Entities       []MessageEntity `json:"entities,omitempty"`
+	Images         []MessageImage  `json:"images"`
+	IsDeleted      bool            `json:"is_deleted"`
+}
+
+type MessageImage struct {
+	URL      string         `json:"url"`
+	Width    *int32         `json:"width,omitempty"`
+	Height   *int32         `json:"height,omitempty"`
+	Blurhash *string        `json:"blurhash,omitempty"`
+	Variants []ImageVariant `json:"variants"`
+}
+
+type ImageVariant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+}
+
+type Attachment struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	DurationMs  *int32 `json:"duration_ms,omitempty"`
+	Waveform    []int  `json:"waveform,omitempty"`
+}
+
+type ForwardedFrom struct {
+	MessageID      *string `json:"message_id,omitempty"`
+	SenderID       *string `json:"sender_id,omitempty"`
+	SenderUsername string  `json:"sender_username"`
+}
+
+type MentionEntity struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Offset   int32  `json:"offset"`
+	Length   int32  `json:"length"`
+}
+
+type MessageEntity struct {
+	Type     string  `json:"type"`
+	Offset   int32   `json:"offset"`
+	Length   int32   `json:"length"`
+	URL      string  `json:"url,omitempty"`
+	Language string  `json:"language,omitempty"`
+	UserID   *string `json:"user_id,omitempty"`
+	Username string  `json:"username,omitempty"`
+}
+
+type LinkPreviewPayload struct {
+	URL         string  `json:"url"`
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	ImageURL    *string `json:"image_url,omitempty"`
+	SiteName    *string `json:"site_name,omitempty"`
+}
+
+type MessagePreviewReadyPayload struct {
+	MessageID string               `json:"message_id"`
+	ChatID    string               `json:"chat_id"`
+	Previews  []LinkPreviewPayload `json:"previews"`
+}
+
+type DraftPayload struct {
+	Content          *string   `json:"content,omitempty"`
+	Images           []string  `json:"images"`
+	ReplyToMessageID *string   `json:"reply_to_message_id,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// DraftUpdatedPayload carries a nil draft when the draft was cleared
+type DraftUpdatedPayload struct {
+	ChatID string        `json:"chat_id"`
+	Draft  *DraftPayload `json:"draft"`
+}
+
+type ReminderFiredPayload struct {
+	ID             string    `json:"id"`
+	MessageID      string    `json:"message_id"`
+	ChatID         string    `json:"chat_id"`
+	ChatName       *string   `json:"chat_name,omitempty"`
+	SenderUsername string    `json:"sender_username"`
+	Content        *string   `json:"content,omitempty"`
+	RemindAt       time.Time `json:"remind_at"`
+}
+
+type PollPayload struct {
+	ID             string              `json:"id"`
+	MessageID      string              `json:"message_id"`
+	ChatID         string              `json:"chat_id"`
+	Question       string              `json:"question"`
+	AllowsMultiple bool                `json:"allows_multiple"`
+	IsAnonymous    bool                `json:"is_anonymous"`
+	ClosesAt       *time.Time          `json:"closes_at,omitempty"`
+	IsClosed       bool                `json:"is_closed"`
+	TotalVoters    int                 `json:"total_voters"`
+	Options        []PollOptionPayload `json:"options"`
+}
+
+type PollOptionPayload struct {
+	ID        string   `json:"id"`
+	Text      string   `json:"text"`
+	VoteCount int      `json:"vote_count"`
+	VoterIDs  []string `json:"voter_ids,omitempty"`
 }
 
 type TypingPayload struct {
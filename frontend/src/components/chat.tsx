@@ -4,7 +4,7 @@ import { Textarea } from '@/components/ui/textarea'
 import { UserAvatar } from '@/components/user-avatar'
 import { useImageUpload } from '@/hooks/use-image-upload'
 import { useTypingIndicator } from '@/hooks/use-typing-indicator'
-import { formatRetryAfter } from '@/lib/utils'
+import { formatRetryAfter, imageThumbnailUrl } from '@/lib/utils'
 import { getChatByIdFn, sendMessageFn } from '@/server/chat'
 import type { Message } from '@/types/chat'
 import { useMutation, useQuery } from '@tanstack/react-query'
@@ -248,10 +248,10 @@ export function Chat({ chatId }: ChatProps) {
                 </p>
                 {!replyTo.is_deleted && replyTo.images.length > 0 && (
                   <div className="mt-2 flex gap-2">
-                    {replyTo.images.slice(0, 2).map((imageUrl, idx) => (
+                    {replyTo.images.slice(0, 2).map((image, idx) => (
                       <img
-                        key={imageUrl}
-                        src={imageUrl}
+                        key={image.url}
+                        src={imageThumbnailUrl(image)}
                         alt={`Reply attachment ${idx + 1}`}
                         className="h-12 w-12 rounded-md object-cover"
                       />
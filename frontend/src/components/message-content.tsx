@@ -1,7 +1,7 @@
 import { Button } from '@/components/ui/button'
 import { ContextMenu, ContextMenuContent, ContextMenuItem, ContextMenuTrigger } from '@/components/ui/context-menu'
 import { Textarea } from '@/components/ui/textarea'
-import { cn, formatMessageTime } from '@/lib/utils'
+import { cn, formatMessageTime, imageThumbnailUrl } from '@/lib/utils'
 import { deleteMessageFn, editMessageFn } from '@/server/chat'
 import type { Message } from '@/types/chat'
 import { useMutation } from '@tanstack/react-query'
@@ -30,7 +30,8 @@ export function MessageContent({
   const [_, copy] = useCopyToClipboard()
   const [isEditing, setIsEditing] = useState(false)
   const [editContent, setEditContent] = useState(message.content ?? '')
-  const [remainingImages, setRemainingImages] = useState<string[]>(message.images ?? [])
+  const imageUrls = message.images?.map((image) => image.url) ?? []
+  const [remainingImages, setRemainingImages] = useState<string[]>(imageUrls)
   const timestampLabel = formatMessageTime(new Date(message.created_at))
 
   const canEdit = () => {
@@ -43,7 +44,7 @@ export function MessageContent({
 
   const editMutation = useMutation({
     mutationFn: async () => {
-      const removedImages = message.images?.filter((img) => !remainingImages.includes(img)) ?? []
+      const removedImages = imageUrls.filter((url) => !remainingImages.includes(url))
       return await editMessageFn({
         data: { message_id: message.id, content: editContent, removed_images: removedImages },
       })
@@ -81,7 +82,7 @@ export function MessageContent({
   const handleCancelEdit = () => {
     setIsEditing(false)
     setEditContent(message.content ?? '')
-    setRemainingImages(message.images ?? [])
+    setRemainingImages(imageUrls)
   }
 
   const handleSaveEdit = () => {
@@ -148,10 +149,10 @@ export function MessageContent({
         <p className="line-clamp-2">{replyText}</p>
         {!reply.is_deleted && reply.images.length > 0 && (
           <div className="mt-2 flex gap-1">
-            {reply.images.slice(0, 2).map((imageUrl, index) => (
+            {reply.images.slice(0, 2).map((image, index) => (
               <img
-                key={imageUrl + index}
-                src={imageUrl}
+                key={image.url + index}
+                src={imageThumbnailUrl(image)}
                 alt="Replied message attachment"
                 className={cn('h-10 w-10 rounded-md object-cover', thumbnailBorder)}
               />
@@ -241,7 +242,7 @@ export function MessageContent({
         <div className="flex flex-col gap-2">
           {imageCount > 0 && (
             <div className={cn('flex flex-wrap gap-2', isOwn ? 'justify-end' : 'justify-start')}>
-              {message.images?.map((imageUrl, index) => (
+              {message.images?.map((image, index) => (
                 <div
                   key={index}
                   className={cn(
@@ -250,10 +251,10 @@ export function MessageContent({
                   )}
                 >
                   <img
-                    src={imageUrl}
+                    src={imageThumbnailUrl(image)}
                     alt={`Message image ${index + 1}`}
                     className="h-full w-full cursor-pointer object-cover transition-opacity hover:opacity-90"
-                    onClick={() => window.open(imageUrl, '_blank')}
+                    onClick={() => window.open(image.url, '_blank')}
                   />
                 </div>
               ))}
@@ -1,7 +1,14 @@
 import { useWebSocket } from '@/contexts/websocket-context'
 import { useQueryClient } from '@tanstack/react-query'
 import { useEffect } from 'react'
-import type { ChatInfo, ChatReadReceipt, GetChatMessagesResponse, Message, ReplyToMessage } from '../types/chat'
+import type {
+  ChatInfo,
+  ChatReadReceipt,
+  GetChatMessagesResponse,
+  Message,
+  MessageImage,
+  ReplyToMessage,
+} from '../types/chat'
 
 type MessageSentPayload = {
   id: string
@@ -9,7 +16,7 @@ type MessageSentPayload = {
   sender_id: string
   sender_username: string
   content?: string
-  images: string[]
+  images: MessageImage[]
   is_deleted: boolean
   is_edited: boolean
   created_at: string
@@ -21,7 +28,7 @@ type ReplyPayload = {
   sender_id: string
   sender_username: string
   content?: string
-  images: string[]
+  images: MessageImage[]
   is_deleted: boolean
 }
 
@@ -29,7 +36,7 @@ type MessageEditedPayload = {
   id: string
   chat_id: string
   content?: string
-  images: string[]
+  images: MessageImage[]
   is_edited: boolean
   updated_at: string
 }
@@ -1,3 +1,4 @@
+import type { MessageImage } from '@/types/chat'
 import { clsx, type ClassValue } from 'clsx'
 import { format, isSameMonth, isSameWeek, isThisYear, isToday, isYesterday } from 'date-fns'
 import { twMerge } from 'tailwind-merge'
@@ -36,3 +37,8 @@ export function formatRetryAfter(seconds: number): string {
   const minutes = Math.ceil(seconds / 60)
   return `${minutes} minute${minutes !== 1 ? 's' : ''}`
 }
+
+// Prefer the square thumbnail for previews and fall back to the original for older images
+export function imageThumbnailUrl(image: MessageImage) {
+  return image.variants?.find((variant) => variant.name === 'thumbnail')?.url ?? image.url
+}
@@ -23,7 +23,7 @@ export type LastMessage = {
   content?: string
   sender?: MessageSender
   is_deleted: boolean
-  images: string[]
+  images: MessageImage[]
   created_at: string
 }
 
@@ -66,11 +66,26 @@ export type Message = {
   sender_profile_image_url?: string | null
   is_deleted: boolean
   is_edited: boolean
-  images: string[]
+  images: MessageImage[]
   created_at: string
   reply_to?: ReplyToMessage
 }
 
+export type ImageVariant = {
+  name: string
+  url: string
+  width: number
+  height: number
+}
+
+export type MessageImage = {
+  url: string
+  width?: number
+  height?: number
+  blurhash?: string
+  variants: ImageVariant[]
+}
+
 export type ChatReadReceipt = {
   user_id: string
   last_read_message_id: string
@@ -83,7 +98,7 @@ export type ReplyToMessage = {
   sender_username: string
   sender_profile_image_url?: string | null
   content?: string
-  images: string[]
+  images: MessageImage[]
   is_deleted: boolean
 }
 